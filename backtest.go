@@ -0,0 +1,268 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andmar/fraudion/config"
+	"github.com/andmar/fraudion/monitors"
+	"github.com/andmar/fraudion/softswitches"
+	"github.com/andmar/fraudion/utils"
+
+	"github.com/andmar/marlog"
+)
+
+const (
+	// NOTE: "from"/"to" are taken in these layouts, the ones without a time zone are local time
+	constBacktestTimeLayout      = "2006-01-02 15:04:05"
+	constBacktestDateLayout      = "2006-01-02"
+	constBacktestDefaultLookback = 24 * time.Hour
+)
+
+// runBacktest Executes the CDR based monitors on the CDRs of a period of the past, at each of their "execute_interval" in that period
+// (see softswitches.Replay), and writes to STDOUT when each of them would have been in alarm and on which prefixes. No action chains are
+// executed. CDRs come from the configured CDRs Source, which has to be one that has them from the past (e.g. "*database"), or from an
+// Asterisk cdr_csv file ("-cdrs")
+func runBacktest(arguments []string) {
+
+	log := marlog.MarLog
+
+	flags := flag.NewFlagSet("backtest", flag.ExitOnError)
+	argFrom := flags.String("from", "", "Start of the period to replay (\""+constBacktestTimeLayout+"\", \""+constBacktestDateLayout+"\" or RFC3339), defaults to a day before \"to\".")
+	argTo := flags.String("to", "", "End of the period to replay, defaults to now.")
+	argCDRsFilePath := flags.String("cdrs", "", "Asterisk cdr_csv (Master.csv) file to read the CDRs from instead of the configured CDRs Source.")
+	argCDRsUseGMTime := flags.Bool("cdrsgmtime", false, "The times in the \"cdrs\" file are in GMT (\"usegmtime\" is set in the [csv] section of cdr.conf).")
+	argSoftswitch := flags.String("softswitch", "", "Name of the Softswitch to replay, defaults to all of them.")
+	flags.Parse(arguments)
+
+	to := time.Now()
+	if *argTo != "" {
+		parsed, err := parseBacktestTime(*argTo)
+		if err != nil {
+			log.LogO("ERROR", "Can't proceed. :( There was an Error ("+err.Error()+")", marlog.OptionFatal)
+		}
+		to = parsed
+	}
+
+	from := to.Add(-constBacktestDefaultLookback)
+	if *argFrom != "" {
+		parsed, err := parseBacktestTime(*argFrom)
+		if err != nil {
+			log.LogO("ERROR", "Can't proceed. :( There was an Error ("+err.Error()+")", marlog.OptionFatal)
+		}
+		from = parsed
+	}
+
+	if !from.Before(to) {
+		log.LogO("ERROR", "Can't proceed. :( There was an Error (\"from\" has to be before \"to\")", marlog.OptionFatal)
+	}
+
+	var alarms []*monitors.BacktestAlarm
+	var rateTable *utils.RateTable
+
+	for index := range config.Loaded.Softswitches {
+
+		softswitchConfig := &config.Loaded.Softswitches[index]
+
+		if *argSoftswitch != "" && *argSoftswitch != softswitchConfig.Name {
+			continue
+		}
+
+		// NOTE: Monitors look back from each tick, the first ones need the CDRs from before "from"
+		window, _ := getCDRsCacheWindowAndInterval(softswitchConfig)
+
+		softswitch := setupNumberNormalizer(softswitchConfig, setupBacktestSoftswitch(softswitchConfig, *argCDRsFilePath, *argCDRsUseGMTime))
+
+		replay, err := softswitches.NewReplay(softswitch, from.Add(-window), to)
+		if err != nil {
+			log.LogO("ERROR", "Can't proceed. :( There was an Error (could not get the CDRs of Softswitch \""+softswitchConfig.Name+"\": "+err.Error()+")", marlog.OptionFatal)
+		}
+
+		var backtesters []monitors.Backtester
+
+		if config.Loaded.Monitors.DangerousDestinations.Enabled == true && config.Loaded.Monitors.DangerousDestinations.IsBoundTo(softswitchConfig.Name) {
+			monitor := new(monitors.DangerousDestinations)
+			monitor.Config = &config.Loaded.Monitors.DangerousDestinations
+			monitor.SoftswitchName = softswitchConfig.Name
+			backtesters = append(backtesters, monitor)
+		}
+
+		if config.Loaded.Monitors.ExpectedDestinations.Enabled == true && config.Loaded.Monitors.ExpectedDestinations.IsBoundTo(softswitchConfig.Name) {
+			monitor := new(monitors.ExpectedDestinations)
+			monitor.Config = &config.Loaded.Monitors.ExpectedDestinations
+			monitor.SoftswitchName = softswitchConfig.Name
+			backtesters = append(backtesters, monitor)
+		}
+
+		if config.Loaded.Monitors.SmallDurationCalls.Enabled == true && config.Loaded.Monitors.SmallDurationCalls.IsBoundTo(softswitchConfig.Name) {
+			monitor := new(monitors.SmallDurationCalls)
+			monitor.Config = &config.Loaded.Monitors.SmallDurationCalls
+			monitor.SoftswitchName = softswitchConfig.Name
+			backtesters = append(backtesters, monitor)
+		}
+
+		if config.Loaded.Monitors.TollCost.Enabled == true && config.Loaded.Monitors.TollCost.IsBoundTo(softswitchConfig.Name) {
+			if rateTable == nil {
+				rateTable = setupRateTable()
+			}
+			monitor := new(monitors.TollCost)
+			monitor.Config = &config.Loaded.Monitors.TollCost
+			monitor.RateTable = rateTable
+			monitor.SoftswitchName = softswitchConfig.Name
+			backtesters = append(backtesters, monitor)
+		}
+
+		if config.Loaded.Monitors.CallVelocity.Enabled == true && config.Loaded.Monitors.CallVelocity.IsBoundTo(softswitchConfig.Name) {
+			monitor := new(monitors.CallVelocity)
+			monitor.Config = &config.Loaded.Monitors.CallVelocity
+			monitor.SoftswitchName = softswitchConfig.Name
+			backtesters = append(backtesters, monitor)
+		}
+
+		if config.Loaded.Monitors.SimultaneousCalls.Enabled == true {
+			log.LogS("INFO", "Monitor \"SimultaneousCalls\" looks at live calls, it can't be backtested")
+		}
+
+		for _, backtester := range backtesters {
+
+			monitorAlarms, err := backtester.Backtest(replay, from, to)
+			if err != nil {
+				log.LogO("ERROR", "Can't proceed. :( There was an Error (could not backtest on Softswitch \""+softswitchConfig.Name+"\": "+err.Error()+")", marlog.OptionFatal)
+			}
+
+			alarms = append(alarms, monitorAlarms...)
+
+		}
+
+	}
+
+	writeBacktestAlarms(alarms, from, to)
+
+}
+
+// setupBacktestSoftswitch Creates the configured Softswitch without a Live Calls Source or a CDRs cache, with its CDRs coming from the file
+// in "cdrsFilePath" if it's set
+func setupBacktestSoftswitch(softswitchConfig *config.Softswitch, cdrsFilePath string, cdrsUseGMTime bool) softswitches.Softswitch {
+
+	log := marlog.MarLog
+
+	var defaultColumnMap map[string]string
+	switch softswitchConfig.Type {
+	case softswitches.TypeAsterisk:
+		defaultColumnMap = softswitches.AsteriskCDRColumnMap
+	case softswitches.TypeFreeSwitch:
+		defaultColumnMap = softswitches.FreeSwitchCDRColumnMap
+	case softswitches.TypeKamailio:
+		defaultColumnMap = softswitches.KamailioCDRColumnMap
+	case softswitches.TypeOpenSIPS:
+		defaultColumnMap = softswitches.OpenSIPSCDRColumnMap
+	}
+
+	var cdrsSource softswitches.CDRsSource
+	if cdrsFilePath != "" {
+		log.LogS("DEBUG", "CDRs Source is CSV File \""+cdrsFilePath+"\"")
+		cdrsSource = &softswitches.CDRsSourceCSVFile{FilePath: cdrsFilePath, UseGMTime: cdrsUseGMTime}
+	} else {
+		switch softswitchConfig.CDRsSource.Type {
+		case softswitches.CDRSourceDatabase, softswitches.CDRSourceCEL, softswitches.CDRSourceAccounting, softswitches.CDRSourceCSVFile:
+			cdrsSource = setupCDRsSource(softswitchConfig, defaultColumnMap)
+		default:
+			// NOTE: The ones that keep CDRs in memory only have the ones from after Fraudion started
+			log.LogO("ERROR", "Can't proceed. :( There was an Error (CDRs Source \""+softswitchConfig.CDRsSource.Type+"\" of Softswitch \""+softswitchConfig.Name+"\" has no CDRs from the past, use \"-cdrs\")", marlog.OptionFatal)
+		}
+	}
+
+	switch softswitchConfig.Type {
+	case softswitches.TypeAsterisk:
+		return &softswitches.Asterisk{Version: softswitchConfig.Version, CDRsSource: cdrsSource}
+	case softswitches.TypeFreeSwitch:
+		return &softswitches.FreeSwitch{Version: softswitchConfig.Version, CDRsSource: cdrsSource}
+	case softswitches.TypeKamailio, softswitches.TypeOpenSIPS:
+		return &softswitches.SIPProxy{Version: softswitchConfig.Version, CDRsSource: cdrsSource}
+	}
+
+	// NOTE: This should not happen in the future because it's going to be validated in the configuration parsing/loading phase
+	log.LogO("ERROR", "Can't proceed. :( There was an Error (unknown Softswitch type \""+softswitchConfig.Type+"\" configured)", marlog.OptionFatal)
+
+	return nil
+
+}
+
+// writeBacktestAlarms Writes the alarms to STDOUT in the order they would have happened, one per line with the groups (and their Hits)
+// that were above threshold, followed by how many times each monitor would have been in alarm
+func writeBacktestAlarms(alarms []*monitors.BacktestAlarm, from time.Time, to time.Time) {
+
+	sort.Stable(backtestAlarmsByTime(alarms))
+
+	fmt.Fprintln(os.Stdout, "Backtest from "+from.Format(constBacktestTimeLayout)+" to "+to.Format(constBacktestTimeLayout)+", no actions were executed")
+
+	counts := make(map[string]int)
+	var names []string
+
+	for _, alarm := range alarms {
+
+		var groups []string
+		for group := range alarm.Hits {
+			groups = append(groups, group)
+		}
+		sort.Strings(groups)
+
+		for index, group := range groups {
+			groups[index] = monitors.DescribeGroup(alarm.Hits[group], alarm.GroupBy) + " (" + strconv.Itoa(int(alarm.Hits[group].NumberOfHits)) + " hits: " + strings.Join(alarm.Hits[group].DescribeDestinations(), ", ") + ")"
+		}
+
+		fmt.Fprintln(os.Stdout, alarm.Time.Format(constBacktestTimeLayout)+" "+alarm.Monitor+" on \""+alarm.Softswitch+"\" in alarm: "+strings.Join(groups, "; "))
+
+		name := alarm.Monitor + " on \"" + alarm.Softswitch + "\""
+		if _, found := counts[name]; found == false {
+			names = append(names, name)
+		}
+		counts[name]++
+
+	}
+
+	if len(alarms) == 0 {
+		fmt.Fprintln(os.Stdout, "No monitor would have been in alarm")
+	}
+
+	for _, name := range names {
+		fmt.Fprintln(os.Stdout, name+" would have been in alarm "+strconv.Itoa(counts[name])+" times")
+	}
+
+}
+
+// parseBacktestTime ...
+func parseBacktestTime(value string) (time.Time, error) {
+
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+
+	for _, layout := range []string{constBacktestTimeLayout, constBacktestDateLayout} {
+		if parsed, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return parsed, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("could not parse time \"" + value + "\"")
+
+}
+
+type backtestAlarmsByTime []*monitors.BacktestAlarm
+
+func (alarms backtestAlarmsByTime) Len() int {
+	return len(alarms)
+}
+
+func (alarms backtestAlarmsByTime) Swap(i, j int) {
+	alarms[i], alarms[j] = alarms[j], alarms[i]
+}
+
+func (alarms backtestAlarmsByTime) Less(i, j int) bool {
+	return alarms[i].Time.Before(alarms[j].Time)
+}
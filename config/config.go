@@ -1,5 +1,9 @@
 package config
 
+import (
+	"time"
+)
+
 const (
 	ConstOriginURL  = "url"
 	ConstOriginFile = "file"
@@ -12,3 +16,10 @@ const (
 	// TODO This "DefaultConfigURL" should not have a default right?
 	ConstDefaultConfigURL = "http://mirrors.voipit.pt/fraudion.json"
 )
+
+const (
+	// DefaultSoftswitchName Name of the Softswitch configured in "softswitch" when it has no "name"
+	DefaultSoftswitchName = "default"
+	// DefaultNumberRangesReloadInterval How often the number ranges files are checked for changes when there's no "reload_interval"
+	DefaultNumberRangesReloadInterval = time.Minute
+)
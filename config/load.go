@@ -4,12 +4,15 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
 	"time"
 
 	"io/ioutil"
 	"net/http"
 
+	"github.com/andmar/fraudion/utils"
+
 	"github.com/andmar/marlog"
 )
 
@@ -103,10 +106,31 @@ func loadFromParsed() error {
 	// * General Section
 	Loaded.General.Hostname = parsed.General.Hostname
 
-	// * Softswitch Section
-	Loaded.Softswitch.Type = parsed.Softswitch.Type
-	Loaded.Softswitch.Version = parsed.Softswitch.Version
-	Loaded.Softswitch.CDRsSource = *parsed.Softswitch.CDRsSource
+	// * Softswitches Section
+	// NOTE: "softswitch" is the one Softswitch of a single Softswitch setup and "softswitches" the named Softswitches of the others, the
+	// first one is named DefaultSoftswitchName unless it has a "name"
+	parsedSoftswitches := parsed.Softswitches
+	if parsed.Softswitch != nil {
+		if parsed.Softswitch.Name == "" {
+			parsed.Softswitch.Name = DefaultSoftswitchName
+		}
+		parsedSoftswitches = append([]*softswitchJSON{parsed.Softswitch}, parsedSoftswitches...)
+	}
+	if len(parsedSoftswitches) == 0 {
+		return fmt.Errorf("no softswitch configured")
+	}
+	for _, parsedSoftswitch := range parsedSoftswitches {
+		for _, loadedSoftswitch := range Loaded.Softswitches {
+			if loadedSoftswitch.Name == parsedSoftswitch.Name {
+				return fmt.Errorf("softswitch \"%s\" is configured more than once", parsedSoftswitch.Name)
+			}
+		}
+		loadedSoftswitch, err := loadSoftswitch(parsedSoftswitch)
+		if err != nil {
+			return err
+		}
+		Loaded.Softswitches = append(Loaded.Softswitches, loadedSoftswitch)
+	}
 
 	// * Monitors
 	if parsed.Monitors.SimultaneousCalls == nil {
@@ -119,6 +143,8 @@ func loadFromParsed() error {
 		}
 		Loaded.Monitors.SimultaneousCalls.ExecuteInterval = executeInterval
 		Loaded.Monitors.SimultaneousCalls.HitThreshold = parsed.Monitors.SimultaneousCalls.HitThreshold
+		Loaded.Monitors.SimultaneousCalls.EventDriven = parsed.Monitors.SimultaneousCalls.EventDriven
+		Loaded.Monitors.SimultaneousCalls.Softswitches = parsed.Monitors.SimultaneousCalls.Softswitches
 		Loaded.Monitors.SimultaneousCalls.MinimumNumberLength = parsed.Monitors.SimultaneousCalls.MinimumNumberLength
 		Loaded.Monitors.SimultaneousCalls.ActionChainName = parsed.Monitors.SimultaneousCalls.ActionChainName
 	}
@@ -133,6 +159,8 @@ func loadFromParsed() error {
 		}
 		Loaded.Monitors.DangerousDestinations.ExecuteInterval = executeInterval
 		Loaded.Monitors.DangerousDestinations.HitThreshold = parsed.Monitors.DangerousDestinations.HitThreshold
+		Loaded.Monitors.DangerousDestinations.EventDriven = parsed.Monitors.DangerousDestinations.EventDriven
+		Loaded.Monitors.DangerousDestinations.Softswitches = parsed.Monitors.DangerousDestinations.Softswitches
 		Loaded.Monitors.DangerousDestinations.MinimumNumberLength = parsed.Monitors.DangerousDestinations.MinimumNumberLength
 		Loaded.Monitors.DangerousDestinations.ActionChainName = parsed.Monitors.DangerousDestinations.ActionChainName
 		if considerFromLast, err := time.ParseDuration(parsed.Monitors.DangerousDestinations.ConsiderCDRsFromLast); err != nil {
@@ -148,9 +176,21 @@ func loadFromParsed() error {
 		} else {
 			Loaded.Monitors.DangerousDestinations.ConsiderCDRsFromLast = considerFromLast
 		}
+		Loaded.Monitors.DangerousDestinations.Incremental = parsed.Monitors.DangerousDestinations.Incremental
 		Loaded.Monitors.DangerousDestinations.PrefixList = parsed.Monitors.DangerousDestinations.PrefixList
 		Loaded.Monitors.DangerousDestinations.MatchRegex = parsed.Monitors.DangerousDestinations.MatchRegex
 		Loaded.Monitors.DangerousDestinations.IgnoreRegex = parsed.Monitors.DangerousDestinations.IgnoreRegex
+		Loaded.Monitors.DangerousDestinations.GroupBy = parsed.Monitors.DangerousDestinations.GroupBy
+		Loaded.Monitors.DangerousDestinations.GroupThresholds = parsed.Monitors.DangerousDestinations.GroupThresholds
+		// NOTE: Without a minimum risk calls to any of the number ranges are dangerous
+		Loaded.Monitors.DangerousDestinations.NumberRangesMinimumRisk = utils.RiskLow
+		if parsed.Monitors.DangerousDestinations.NumberRangesMinimumRisk != "" {
+			minimumRisk, err := utils.ParseRisk(parsed.Monitors.DangerousDestinations.NumberRangesMinimumRisk)
+			if err != nil {
+				return err
+			}
+			Loaded.Monitors.DangerousDestinations.NumberRangesMinimumRisk = minimumRisk
+		}
 	}
 
 	if parsed.Monitors.ExpectedDestinations == nil {
@@ -163,6 +203,8 @@ func loadFromParsed() error {
 		}
 		Loaded.Monitors.ExpectedDestinations.ExecuteInterval = executeInterval
 		Loaded.Monitors.ExpectedDestinations.HitThreshold = parsed.Monitors.ExpectedDestinations.HitThreshold
+		Loaded.Monitors.ExpectedDestinations.EventDriven = parsed.Monitors.ExpectedDestinations.EventDriven
+		Loaded.Monitors.ExpectedDestinations.Softswitches = parsed.Monitors.ExpectedDestinations.Softswitches
 		Loaded.Monitors.ExpectedDestinations.MinimumNumberLength = parsed.Monitors.ExpectedDestinations.MinimumNumberLength
 		Loaded.Monitors.ExpectedDestinations.ActionChainName = parsed.Monitors.ExpectedDestinations.ActionChainName
 		if considerFromLast, err := time.ParseDuration(parsed.Monitors.ExpectedDestinations.ConsiderCDRsFromLast); err != nil {
@@ -178,9 +220,12 @@ func loadFromParsed() error {
 		} else {
 			Loaded.Monitors.ExpectedDestinations.ConsiderCDRsFromLast = considerFromLast
 		}
+		Loaded.Monitors.ExpectedDestinations.Incremental = parsed.Monitors.ExpectedDestinations.Incremental
 		Loaded.Monitors.ExpectedDestinations.PrefixList = parsed.Monitors.ExpectedDestinations.PrefixList
 		Loaded.Monitors.ExpectedDestinations.MatchRegex = parsed.Monitors.ExpectedDestinations.MatchRegex
 		Loaded.Monitors.ExpectedDestinations.IgnoreRegex = parsed.Monitors.ExpectedDestinations.IgnoreRegex
+		Loaded.Monitors.ExpectedDestinations.GroupBy = parsed.Monitors.ExpectedDestinations.GroupBy
+		Loaded.Monitors.ExpectedDestinations.GroupThresholds = parsed.Monitors.ExpectedDestinations.GroupThresholds
 	}
 
 	if parsed.Monitors.ExpectedDestinations == nil {
@@ -193,6 +238,8 @@ func loadFromParsed() error {
 		}
 		Loaded.Monitors.ExpectedDestinations.ExecuteInterval = executeInterval
 		Loaded.Monitors.ExpectedDestinations.HitThreshold = parsed.Monitors.ExpectedDestinations.HitThreshold
+		Loaded.Monitors.ExpectedDestinations.EventDriven = parsed.Monitors.ExpectedDestinations.EventDriven
+		Loaded.Monitors.ExpectedDestinations.Softswitches = parsed.Monitors.ExpectedDestinations.Softswitches
 		Loaded.Monitors.ExpectedDestinations.MinimumNumberLength = parsed.Monitors.ExpectedDestinations.MinimumNumberLength
 		Loaded.Monitors.ExpectedDestinations.ActionChainName = parsed.Monitors.ExpectedDestinations.ActionChainName
 		if considerFromLast, err := time.ParseDuration(parsed.Monitors.ExpectedDestinations.ConsiderCDRsFromLast); err != nil {
@@ -208,9 +255,12 @@ func loadFromParsed() error {
 		} else {
 			Loaded.Monitors.ExpectedDestinations.ConsiderCDRsFromLast = considerFromLast
 		}
+		Loaded.Monitors.ExpectedDestinations.Incremental = parsed.Monitors.ExpectedDestinations.Incremental
 		Loaded.Monitors.ExpectedDestinations.PrefixList = parsed.Monitors.ExpectedDestinations.PrefixList
 		Loaded.Monitors.ExpectedDestinations.MatchRegex = parsed.Monitors.ExpectedDestinations.MatchRegex
 		Loaded.Monitors.ExpectedDestinations.IgnoreRegex = parsed.Monitors.ExpectedDestinations.IgnoreRegex
+		Loaded.Monitors.ExpectedDestinations.GroupBy = parsed.Monitors.ExpectedDestinations.GroupBy
+		Loaded.Monitors.ExpectedDestinations.GroupThresholds = parsed.Monitors.ExpectedDestinations.GroupThresholds
 	}
 
 	if parsed.Monitors.SmallDurationCalls == nil {
@@ -223,6 +273,8 @@ func loadFromParsed() error {
 		}
 		Loaded.Monitors.SmallDurationCalls.ExecuteInterval = executeInterval
 		Loaded.Monitors.SmallDurationCalls.HitThreshold = parsed.Monitors.SmallDurationCalls.HitThreshold
+		Loaded.Monitors.SmallDurationCalls.EventDriven = parsed.Monitors.SmallDurationCalls.EventDriven
+		Loaded.Monitors.SmallDurationCalls.Softswitches = parsed.Monitors.SmallDurationCalls.Softswitches
 		Loaded.Monitors.SmallDurationCalls.MinimumNumberLength = parsed.Monitors.SmallDurationCalls.MinimumNumberLength
 		Loaded.Monitors.SmallDurationCalls.ActionChainName = parsed.Monitors.SmallDurationCalls.ActionChainName
 		if considerFromLast, err := time.ParseDuration(parsed.Monitors.SmallDurationCalls.ConsiderCDRsFromLast); err != nil {
@@ -238,6 +290,7 @@ func loadFromParsed() error {
 		} else {
 			Loaded.Monitors.SmallDurationCalls.ConsiderCDRsFromLast = considerFromLast
 		}
+		Loaded.Monitors.SmallDurationCalls.Incremental = parsed.Monitors.SmallDurationCalls.Incremental
 		durationThreshold, err := time.ParseDuration(parsed.Monitors.SmallDurationCalls.DurationThreshold)
 		if err != nil {
 			return fmt.Errorf("error converting string to time.Duration on Load, this should not happen... ever")
@@ -245,6 +298,81 @@ func loadFromParsed() error {
 		Loaded.Monitors.SmallDurationCalls.DurationThreshold = durationThreshold
 		Loaded.Monitors.SmallDurationCalls.MatchRegex = parsed.Monitors.SmallDurationCalls.MatchRegex
 		Loaded.Monitors.SmallDurationCalls.IgnoreRegex = parsed.Monitors.SmallDurationCalls.IgnoreRegex
+		Loaded.Monitors.SmallDurationCalls.GroupBy = parsed.Monitors.SmallDurationCalls.GroupBy
+		Loaded.Monitors.SmallDurationCalls.GroupThresholds = parsed.Monitors.SmallDurationCalls.GroupThresholds
+	}
+
+	if parsed.Monitors.TollCost == nil {
+		Loaded.Monitors.TollCost.Enabled = false
+	} else {
+		Loaded.Monitors.TollCost.Enabled = parsed.Monitors.TollCost.Enabled
+		executeInterval, err := time.ParseDuration(parsed.Monitors.TollCost.ExecuteInterval)
+		if err != nil {
+			return fmt.Errorf("error converting string to time.Duration on Load, this should not happen... ever")
+		}
+		Loaded.Monitors.TollCost.ExecuteInterval = executeInterval
+		Loaded.Monitors.TollCost.EventDriven = parsed.Monitors.TollCost.EventDriven
+		Loaded.Monitors.TollCost.Softswitches = parsed.Monitors.TollCost.Softswitches
+		Loaded.Monitors.TollCost.MinimumNumberLength = parsed.Monitors.TollCost.MinimumNumberLength
+		Loaded.Monitors.TollCost.ActionChainName = parsed.Monitors.TollCost.ActionChainName
+		considerFromLast, err := time.ParseDuration(parsed.Monitors.TollCost.ConsiderCDRsFromLast)
+		if err != nil {
+			return fmt.Errorf("error converting string to time.Duration on Load, this should not happen... ever")
+		}
+		Loaded.Monitors.TollCost.ConsiderCDRsFromLast = considerFromLast
+		Loaded.Monitors.TollCost.Incremental = parsed.Monitors.TollCost.Incremental
+		Loaded.Monitors.TollCost.RateTableFile = parsed.Monitors.TollCost.RateTableFile
+		Loaded.Monitors.TollCost.WarningBudget = parsed.Monitors.TollCost.WarningBudget
+		Loaded.Monitors.TollCost.AlarmBudget = parsed.Monitors.TollCost.AlarmBudget
+		if Loaded.Monitors.TollCost.AlarmBudget <= 0 {
+			return fmt.Errorf("the alarm budget of toll cost has to be above 0")
+		}
+		// NOTE: A "warning_budget" of 0 is no warning
+		if Loaded.Monitors.TollCost.WarningBudget >= Loaded.Monitors.TollCost.AlarmBudget {
+			return fmt.Errorf("the warning budget of toll cost has to be below its alarm budget")
+		}
+		// NOTE: Without "group_by" it's what the Softswitch spends
+		Loaded.Monitors.TollCost.GroupBy = parsed.Monitors.TollCost.GroupBy
+		if len(Loaded.Monitors.TollCost.GroupBy) == 0 {
+			Loaded.Monitors.TollCost.GroupBy = []string{"*softswitch"}
+		}
+	}
+
+	if parsed.Monitors.CallVelocity == nil {
+		Loaded.Monitors.CallVelocity.Enabled = false
+	} else {
+		Loaded.Monitors.CallVelocity.Enabled = parsed.Monitors.CallVelocity.Enabled
+		executeInterval, err := time.ParseDuration(parsed.Monitors.CallVelocity.ExecuteInterval)
+		if err != nil {
+			return fmt.Errorf("error converting string to time.Duration on Load, this should not happen... ever")
+		}
+		Loaded.Monitors.CallVelocity.ExecuteInterval = executeInterval
+		Loaded.Monitors.CallVelocity.EventDriven = parsed.Monitors.CallVelocity.EventDriven
+		Loaded.Monitors.CallVelocity.Softswitches = parsed.Monitors.CallVelocity.Softswitches
+		Loaded.Monitors.CallVelocity.MinimumNumberLength = parsed.Monitors.CallVelocity.MinimumNumberLength
+		Loaded.Monitors.CallVelocity.ActionChainName = parsed.Monitors.CallVelocity.ActionChainName
+		Loaded.Monitors.CallVelocity.Incremental = parsed.Monitors.CallVelocity.Incremental
+		Loaded.Monitors.CallVelocity.Windows = nil
+		for length, hitThreshold := range parsed.Monitors.CallVelocity.Windows {
+			windowLength, err := time.ParseDuration(length)
+			if err != nil {
+				return fmt.Errorf("error converting string to time.Duration on Load, this should not happen... ever")
+			}
+			if windowLength <= 0 {
+				return fmt.Errorf("the windows of call velocity have to be longer than 0")
+			}
+			Loaded.Monitors.CallVelocity.Windows = append(Loaded.Monitors.CallVelocity.Windows, CallVelocityWindow{Length: windowLength, HitThreshold: hitThreshold})
+		}
+		if len(Loaded.Monitors.CallVelocity.Windows) == 0 {
+			return fmt.Errorf("call velocity has no windows")
+		}
+		sort.Sort(callVelocityWindowsByLength(Loaded.Monitors.CallVelocity.Windows))
+		Loaded.Monitors.CallVelocity.ConsiderCDRsFromLast = Loaded.Monitors.CallVelocity.Windows[len(Loaded.Monitors.CallVelocity.Windows)-1].Length
+		// NOTE: Without "group_by" it's the attempts of each extension
+		Loaded.Monitors.CallVelocity.GroupBy = parsed.Monitors.CallVelocity.GroupBy
+		if len(Loaded.Monitors.CallVelocity.GroupBy) == 0 {
+			Loaded.Monitors.CallVelocity.GroupBy = []string{"*src"}
+		}
 	}
 
 	// * Actions
@@ -304,6 +432,18 @@ func loadFromParsed() error {
 		}
 	}
 
+	if Loaded.Monitors.TollCost.Enabled == true {
+		existsForTollCost := false
+		for chainName := range *parsed.ActionChains {
+			if Loaded.Monitors.TollCost.ActionChainName == chainName {
+				existsForTollCost = true
+			}
+		}
+		if existsForTollCost == false {
+			return fmt.Errorf("action chain for Toll Cost not enabled")
+		}
+	}
+
 	if Loaded.Monitors.SimultaneousCalls.Enabled == true {
 		existsForSimultaneousCalls := false
 		for chainName := range *parsed.ActionChains {
@@ -314,6 +454,41 @@ func loadFromParsed() error {
 		if existsForSimultaneousCalls == false {
 			return fmt.Errorf("action chain for Simultaneous Calls not enabled")
 		}
+		// NOTE: SIP proxies only have accounting records, there are no channels to count
+		for _, loadedSoftswitch := range Loaded.Softswitches {
+			if (loadedSoftswitch.Type == "*kamailio" || loadedSoftswitch.Type == "*opensips") && Loaded.Monitors.SimultaneousCalls.IsBoundTo(loadedSoftswitch.Name) {
+				return fmt.Errorf("simultaneous calls can't be monitored on softswitch \"%s\" of type %s", loadedSoftswitch.Name, loadedSoftswitch.Type)
+			}
+		}
+	}
+
+	if Loaded.Monitors.CallVelocity.Enabled == true {
+		existsForCallVelocity := false
+		for chainName := range *parsed.ActionChains {
+			if Loaded.Monitors.CallVelocity.ActionChainName == chainName {
+				existsForCallVelocity = true
+			}
+		}
+		if existsForCallVelocity == false {
+			return fmt.Errorf("action chain for Call Velocity not enabled")
+		}
+	}
+
+	// NOTE: Softswitches the Monitors are bound to exist?
+	boundSoftswitches := map[string][]string{
+		"simultaneous calls":     Loaded.Monitors.SimultaneousCalls.Softswitches,
+		"dangerous destinations": Loaded.Monitors.DangerousDestinations.Softswitches,
+		"expected destinations":  Loaded.Monitors.ExpectedDestinations.Softswitches,
+		"small duration calls":   Loaded.Monitors.SmallDurationCalls.Softswitches,
+		"toll cost":              Loaded.Monitors.TollCost.Softswitches,
+		"call velocity":          Loaded.Monitors.CallVelocity.Softswitches,
+	}
+	for monitorName, softswitchNames := range boundSoftswitches {
+		for _, softswitchName := range softswitchNames {
+			if _, found := Loaded.GetSoftswitch(softswitchName); found == false {
+				return fmt.Errorf("softswitch \"%s\" of %s not configured", softswitchName, monitorName)
+			}
+		}
 	}
 
 	// * Action Chains
@@ -344,6 +519,22 @@ func loadFromParsed() error {
 		return fmt.Errorf("some configured action in chain is not enabled")
 	}
 
+	// * Number Ranges
+	if parsed.NumberRanges == nil {
+		Loaded.NumberRanges.Enabled = false
+	} else {
+		Loaded.NumberRanges.Enabled = true
+		Loaded.NumberRanges.Files = parsed.NumberRanges.Files
+		Loaded.NumberRanges.ReloadInterval = DefaultNumberRangesReloadInterval
+		if parsed.NumberRanges.ReloadInterval != "" {
+			reloadInterval, err := time.ParseDuration(parsed.NumberRanges.ReloadInterval)
+			if err != nil {
+				return fmt.Errorf("error converting string to time.Duration on Load, this should not happen... ever")
+			}
+			Loaded.NumberRanges.ReloadInterval = reloadInterval
+		}
+	}
+
 	// * Data Groups
 	Loaded.DataGroups = *parsed.DataGroups
 	// NOTE: All DataGroups used in Chains have the information for the specified Action?
@@ -381,32 +572,242 @@ func loadFromParsed() error {
 
 }
 
+// loadSoftswitch ...
+func loadSoftswitch(parsedSoftswitch *softswitchJSON) (Softswitch, error) {
+
+	var loaded Softswitch
+
+	loaded.Name = parsedSoftswitch.Name
+	loaded.Type = parsedSoftswitch.Type
+	loaded.Version = parsedSoftswitch.Version
+	loaded.CDRsSource.Type = parsedSoftswitch.CDRsSource.Type
+	loaded.CDRsSource.DBMS = parsedSoftswitch.CDRsSource.DBMS
+	loaded.CDRsSource.UserName = parsedSoftswitch.CDRsSource.UserName
+	loaded.CDRsSource.UserPassword = parsedSoftswitch.CDRsSource.UserPassword
+	loaded.CDRsSource.DatabaseName = parsedSoftswitch.CDRsSource.DatabaseName
+	loaded.CDRsSource.TableName = parsedSoftswitch.CDRsSource.TableName
+	loaded.CDRsSource.Host = parsedSoftswitch.CDRsSource.Host
+	loaded.CDRsSource.Port = parsedSoftswitch.CDRsSource.Port
+	loaded.CDRsSource.Socket = parsedSoftswitch.CDRsSource.Socket
+	if parsedSoftswitch.CDRsSource.TLS == nil {
+		loaded.CDRsSource.TLS.Enabled = false
+	} else {
+		loaded.CDRsSource.TLS.Enabled = parsedSoftswitch.CDRsSource.TLS.Enabled
+		loaded.CDRsSource.TLS.CAFile = parsedSoftswitch.CDRsSource.TLS.CAFile
+		loaded.CDRsSource.TLS.CertFile = parsedSoftswitch.CDRsSource.TLS.CertFile
+		loaded.CDRsSource.TLS.KeyFile = parsedSoftswitch.CDRsSource.TLS.KeyFile
+		loaded.CDRsSource.TLS.SkipVerify = parsedSoftswitch.CDRsSource.TLS.SkipVerify
+		if (loaded.CDRsSource.TLS.CertFile == "") != (loaded.CDRsSource.TLS.KeyFile == "") {
+			return loaded, fmt.Errorf("cdrs source tls requires both cert_file and key_file or none of them")
+		}
+	}
+	if parsedSoftswitch.CDRsSource.Timeout != "" {
+		timeout, err := time.ParseDuration(parsedSoftswitch.CDRsSource.Timeout)
+		if err != nil {
+			return loaded, fmt.Errorf("error converting string to time.Duration on Load, this should not happen... ever")
+		}
+		loaded.CDRsSource.Timeout = timeout
+	}
+	loaded.CDRsSource.MaxOpenConnections = parsedSoftswitch.CDRsSource.MaxOpenConnections
+	loaded.CDRsSource.MaxIdleConnections = parsedSoftswitch.CDRsSource.MaxIdleConnections
+	if parsedSoftswitch.CDRsSource.ConnectionMaxLifetime != "" {
+		connectionMaxLifetime, err := time.ParseDuration(parsedSoftswitch.CDRsSource.ConnectionMaxLifetime)
+		if err != nil {
+			return loaded, fmt.Errorf("error converting string to time.Duration on Load, this should not happen... ever")
+		}
+		loaded.CDRsSource.ConnectionMaxLifetime = connectionMaxLifetime
+	}
+	loaded.CDRsSource.DSNOptions = parsedSoftswitch.CDRsSource.DSNOptions
+	loaded.CDRsSource.ColumnMap = parsedSoftswitch.CDRsSource.ColumnMap
+	loaded.CDRsSource.MissedCallsTableName = parsedSoftswitch.CDRsSource.MissedCallsTableName
+	loaded.CDRsSource.CDRsTableName = parsedSoftswitch.CDRsSource.CDRsTableName
+	loaded.CDRsSource.FilePath = parsedSoftswitch.CDRsSource.FilePath
+	loaded.CDRsSource.UseGMTime = parsedSoftswitch.CDRsSource.UseGMTime
+	if parsedSoftswitch.CDRsSource.Retention != "" {
+		retention, err := time.ParseDuration(parsedSoftswitch.CDRsSource.Retention)
+		if err != nil {
+			return loaded, fmt.Errorf("error converting string to time.Duration on Load, this should not happen... ever")
+		}
+		loaded.CDRsSource.Retention = retention
+	}
+	loaded.CDRsSource.ListenAddress = parsedSoftswitch.CDRsSource.ListenAddress
+	loaded.CDRsSource.Path = parsedSoftswitch.CDRsSource.Path
+	loaded.CDRsSource.Token = parsedSoftswitch.CDRsSource.Token
+	loaded.CDRsSource.Format = parsedSoftswitch.CDRsSource.Format
+	loaded.CDRsSource.FieldMap = parsedSoftswitch.CDRsSource.FieldMap
+	if loaded.CDRsSource.Type == "*http" && loaded.CDRsSource.TLS.Enabled && loaded.CDRsSource.TLS.CertFile == "" {
+		return loaded, fmt.Errorf("cdrs source of type *http requires cert_file and key_file when tls is enabled")
+	}
+	if parsedSoftswitch.CDRsCache == nil {
+		loaded.CDRsCache.Enabled = false
+	} else {
+		loaded.CDRsCache.Enabled = parsedSoftswitch.CDRsCache.Enabled
+		if parsedSoftswitch.CDRsCache.RefreshInterval != "" {
+			refreshInterval, err := time.ParseDuration(parsedSoftswitch.CDRsCache.RefreshInterval)
+			if err != nil {
+				return loaded, fmt.Errorf("error converting string to time.Duration on Load, this should not happen... ever")
+			}
+			loaded.CDRsCache.RefreshInterval = refreshInterval
+		}
+	}
+	if parsedSoftswitch.LiveCallsSource == nil {
+		loaded.LiveCallsSource.Type = "*cli"
+	} else {
+		loaded.LiveCallsSource.Type = parsedSoftswitch.LiveCallsSource.Type
+		loaded.LiveCallsSource.Host = parsedSoftswitch.LiveCallsSource.Host
+		loaded.LiveCallsSource.Port = parsedSoftswitch.LiveCallsSource.Port
+		loaded.LiveCallsSource.UserName = parsedSoftswitch.LiveCallsSource.UserName
+		loaded.LiveCallsSource.Secret = parsedSoftswitch.LiveCallsSource.Secret
+		loaded.LiveCallsSource.Password = parsedSoftswitch.LiveCallsSource.Password
+		loaded.LiveCallsSource.Events = parsedSoftswitch.LiveCallsSource.Events
+		if parsedSoftswitch.LiveCallsSource.Timeout != "" {
+			timeout, err := time.ParseDuration(parsedSoftswitch.LiveCallsSource.Timeout)
+			if err != nil {
+				return loaded, fmt.Errorf("error converting string to time.Duration on Load, this should not happen... ever")
+			}
+			loaded.LiveCallsSource.Timeout = timeout
+		}
+		if parsedSoftswitch.LiveCallsSource.MaximumBackoff != "" {
+			maximumBackoff, err := time.ParseDuration(parsedSoftswitch.LiveCallsSource.MaximumBackoff)
+			if err != nil {
+				return loaded, fmt.Errorf("error converting string to time.Duration on Load, this should not happen... ever")
+			}
+			loaded.LiveCallsSource.MaximumBackoff = maximumBackoff
+		}
+	}
+	// NOTE: The Event Socket CDRs Source is fed by the Event Socket Live Calls Source, it can't be there without it
+	if loaded.CDRsSource.Type == "*event_socket" && loaded.LiveCallsSource.Type != "*event_socket" {
+		return loaded, fmt.Errorf("cdrs source of type *event_socket requires a live calls source of type *event_socket")
+	}
+	if parsedSoftswitch.NumberingPlan == nil {
+		loaded.NumberingPlan.Enabled = false
+	} else {
+		loaded.NumberingPlan.Enabled = true
+		loaded.NumberingPlan.InternationalPrefixes = parsedSoftswitch.NumberingPlan.InternationalPrefixes
+		loaded.NumberingPlan.NationalPrefix = parsedSoftswitch.NumberingPlan.NationalPrefix
+		loaded.NumberingPlan.TrunkPrefixes = parsedSoftswitch.NumberingPlan.TrunkPrefixes
+		loaded.NumberingPlan.CountryCode = parsedSoftswitch.NumberingPlan.CountryCode
+		loaded.NumberingPlan.MinimumNationalLength = parsedSoftswitch.NumberingPlan.MinimumNationalLength
+	}
+
+	return loaded, nil
+
+}
+
 type loadedValues struct {
 	General      general
-	Softswitch   softswitch
+	Softswitches []Softswitch
 	Monitors     monitors
 	Actions      actions
 	ActionChains actionChains
 	DataGroups   dataGroups
+	NumberRanges numberRanges
+}
+
+// GetSoftswitch ...
+func (loaded *loadedValues) GetSoftswitch(name string) (*Softswitch, bool) {
+
+	for index := range loaded.Softswitches {
+		if loaded.Softswitches[index].Name == name {
+			return &loaded.Softswitches[index], true
+		}
+	}
+
+	return nil, false
+
 }
 
 type general struct {
 	Hostname string
 }
 
-type softswitch struct {
-	Type       string
-	Version    string
-	CDRsSource cdrsSource
+type numberRanges struct {
+	Enabled        bool
+	Files          []string
+	ReloadInterval time.Duration
 }
 
-type cdrsSource map[string]string
+// Softswitch ...
+type Softswitch struct {
+	Name            string
+	Type            string
+	Version         string
+	CDRsSource      cdrsSource
+	CDRsCache       cdrsCache
+	LiveCallsSource liveCallsSource
+	NumberingPlan   numberingPlan
+}
+
+type numberingPlan struct {
+	Enabled               bool
+	InternationalPrefixes []string
+	NationalPrefix        string
+	TrunkPrefixes         []string
+	CountryCode           string
+	MinimumNationalLength uint32
+}
+
+type liveCallsSource struct {
+	Type           string
+	Host           string
+	Port           uint32
+	UserName       string
+	Secret         string
+	Password       string
+	Timeout        time.Duration
+	MaximumBackoff time.Duration
+	Events         bool
+}
+
+type cdrsCache struct {
+	Enabled         bool
+	RefreshInterval time.Duration
+}
+
+type cdrsSource struct {
+	Type                  string
+	DBMS                  string
+	UserName              string
+	UserPassword          string
+	DatabaseName          string
+	TableName             string
+	Host                  string
+	Port                  uint32
+	Socket                string
+	TLS                   cdrsSourceTLS
+	Timeout               time.Duration
+	MaxOpenConnections    uint32
+	MaxIdleConnections    uint32
+	ConnectionMaxLifetime time.Duration
+	DSNOptions            map[string]string
+	ColumnMap             map[string]string
+	MissedCallsTableName  string
+	CDRsTableName         string
+	FilePath              string
+	UseGMTime             bool
+	Retention             time.Duration
+	ListenAddress         string
+	Path                  string
+	Token                 string
+	Format                string
+	FieldMap              map[string]string
+}
+
+type cdrsSourceTLS struct {
+	Enabled    bool
+	CAFile     string
+	CertFile   string
+	KeyFile    string
+	SkipVerify bool
+}
 
 type monitors struct {
 	SimultaneousCalls     MonitorSimultaneousCalls
 	DangerousDestinations MonitorDangerousDestinations
 	ExpectedDestinations  MonitorExpectedDestinations
 	SmallDurationCalls    MonitorSmallDurationCalls
+	TollCost              MonitorTollCost
+	CallVelocity          MonitorCallVelocity
 }
 
 type monitorBase struct {
@@ -415,6 +816,25 @@ type monitorBase struct {
 	HitThreshold        uint32
 	MinimumNumberLength uint32
 	ActionChainName     string
+	EventDriven         bool
+	Softswitches        []string
+}
+
+// IsBoundTo Monitors are bound to the Softswitches in their "softswitches" or, if there's none, to all of them
+func (monitor monitorBase) IsBoundTo(softswitchName string) bool {
+
+	if len(monitor.Softswitches) == 0 {
+		return true
+	}
+
+	for _, name := range monitor.Softswitches {
+		if name == softswitchName {
+			return true
+		}
+	}
+
+	return false
+
 }
 
 // MonitorSimultaneousCalls ...
@@ -425,28 +845,80 @@ type MonitorSimultaneousCalls struct {
 // MonitorDangerousDestinations ...
 type MonitorDangerousDestinations struct {
 	monitorBase
-	ConsiderCDRsFromLast time.Duration
-	PrefixList           []string
-	MatchRegex           string
-	IgnoreRegex          string
+	ConsiderCDRsFromLast    time.Duration
+	Incremental             bool
+	PrefixList              []string
+	MatchRegex              string
+	IgnoreRegex             string
+	NumberRangesMinimumRisk int
+	GroupBy                 []string
+	GroupThresholds         map[string]uint32
 }
 
 // MonitorExpectedDestinations ...
 type MonitorExpectedDestinations struct {
 	monitorBase
 	ConsiderCDRsFromLast time.Duration
+	Incremental          bool
 	PrefixList           []string
 	MatchRegex           string
 	IgnoreRegex          string
+	GroupBy              []string
+	GroupThresholds      map[string]uint32
 }
 
 // MonitorSmallDurationCalls ...
 type MonitorSmallDurationCalls struct {
 	monitorBase
 	ConsiderCDRsFromLast time.Duration
+	Incremental          bool
 	DurationThreshold    time.Duration
 	MatchRegex           string
 	IgnoreRegex          string
+	GroupBy              []string
+	GroupThresholds      map[string]uint32
+}
+
+// MonitorTollCost ...
+type MonitorTollCost struct {
+	monitorBase
+	ConsiderCDRsFromLast time.Duration
+	Incremental          bool
+	RateTableFile        string
+	WarningBudget        float64
+	AlarmBudget          float64
+	GroupBy              []string
+}
+
+// MonitorCallVelocity ...
+type MonitorCallVelocity struct {
+	monitorBase
+	// NOTE: The longest of the Windows
+	ConsiderCDRsFromLast time.Duration
+	Incremental          bool
+	// NOTE: Shortest first
+	Windows []CallVelocityWindow
+	GroupBy []string
+}
+
+// CallVelocityWindow How many call attempts a group can make in the last Length before it's a burst
+type CallVelocityWindow struct {
+	Length       time.Duration
+	HitThreshold uint32
+}
+
+type callVelocityWindowsByLength []CallVelocityWindow
+
+func (windows callVelocityWindowsByLength) Len() int {
+	return len(windows)
+}
+
+func (windows callVelocityWindowsByLength) Swap(i, j int) {
+	windows[i], windows[j] = windows[j], windows[i]
+}
+
+func (windows callVelocityWindowsByLength) Less(i, j int) bool {
+	return windows[i].Length < windows[j].Length
 }
 
 type actions struct {
@@ -43,12 +43,19 @@ func parseFromURL(reader io.Reader) error {
 }
 
 type parsedValues struct {
-	General      *generalJSON    `json:"general"`
-	Softswitch   *softswitchJSON `json:"softswitch"`
-	Monitors     *monitorsJSON   `json:"monitors"`
-	Actions      *actionsJSON    `json:"actions"`
-	ActionChains *actionChains   `json:"action_chains"`
-	DataGroups   *dataGroups     `json:"data_groups"`
+	General      *generalJSON      `json:"general"`
+	Softswitch   *softswitchJSON   `json:"softswitch"`
+	Softswitches []*softswitchJSON `json:"softswitches"`
+	Monitors     *monitorsJSON     `json:"monitors"`
+	Actions      *actionsJSON      `json:"actions"`
+	ActionChains *actionChains     `json:"action_chains"`
+	DataGroups   *dataGroups       `json:"data_groups"`
+	NumberRanges *numberRangesJSON `json:"number_ranges"`
+}
+
+type numberRangesJSON struct {
+	Files          []string `json:"files"`
+	ReloadInterval string   `json:"reload_interval"`
 }
 
 type generalJSON struct {
@@ -56,9 +63,75 @@ type generalJSON struct {
 }
 
 type softswitchJSON struct {
-	Type       string
-	Version    string
-	CDRsSource *cdrsSource `json:"cdrs_source"`
+	Name            string `json:"name"`
+	Type            string
+	Version         string
+	CDRsSource      *cdrsSourceJSON      `json:"cdrs_source"`
+	CDRsCache       *cdrsCacheJSON       `json:"cdrs_cache"`
+	LiveCallsSource *liveCallsSourceJSON `json:"live_calls_source"`
+	NumberingPlan   *numberingPlanJSON   `json:"numbering_plan"`
+}
+
+type numberingPlanJSON struct {
+	InternationalPrefixes []string `json:"international_prefixes"`
+	NationalPrefix        string   `json:"national_prefix"`
+	TrunkPrefixes         []string `json:"trunk_prefixes"`
+	CountryCode           string   `json:"country_code"`
+	MinimumNationalLength uint32   `json:"minimum_national_length"`
+}
+
+type liveCallsSourceJSON struct {
+	Type           string
+	Host           string `json:"host"`
+	Port           uint32 `json:"port"`
+	UserName       string `json:"user_name"`
+	Secret         string `json:"secret"`
+	Password       string `json:"password"`
+	Timeout        string `json:"timeout"`
+	MaximumBackoff string `json:"maximum_backoff"`
+	Events         bool   `json:"events"`
+}
+
+type cdrsCacheJSON struct {
+	Enabled         bool
+	RefreshInterval string `json:"refresh_interval"`
+}
+
+type cdrsSourceJSON struct {
+	Type                  string
+	DBMS                  string             `json:"dbms"`
+	UserName              string             `json:"user_name"`
+	UserPassword          string             `json:"user_password"`
+	DatabaseName          string             `json:"database_name"`
+	TableName             string             `json:"table_name"`
+	Host                  string             `json:"host"`
+	Port                  uint32             `json:"port"`
+	Socket                string             `json:"socket"`
+	TLS                   *cdrsSourceTLSJSON `json:"tls"`
+	Timeout               string             `json:"timeout"`
+	MaxOpenConnections    uint32             `json:"max_open_connections"`
+	MaxIdleConnections    uint32             `json:"max_idle_connections"`
+	ConnectionMaxLifetime string             `json:"connection_max_lifetime"`
+	DSNOptions            map[string]string  `json:"dsn_options"`
+	ColumnMap             map[string]string  `json:"column_map"`
+	MissedCallsTableName  string             `json:"missed_calls_table_name"`
+	CDRsTableName         string             `json:"cdrs_table_name"`
+	FilePath              string             `json:"file_path"`
+	UseGMTime             bool               `json:"usegmtime"`
+	Retention             string             `json:"retention"`
+	ListenAddress         string             `json:"listen_address"`
+	Path                  string             `json:"path"`
+	Token                 string             `json:"token"`
+	Format                string             `json:"format"`
+	FieldMap              map[string]string  `json:"field_map"`
+}
+
+type cdrsSourceTLSJSON struct {
+	Enabled    bool
+	CAFile     string `json:"ca_file"`
+	CertFile   string `json:"cert_file"`
+	KeyFile    string `json:"key_file"`
+	SkipVerify bool   `json:"skip_verify"`
 }
 
 type monitorsJSON struct {
@@ -66,14 +139,18 @@ type monitorsJSON struct {
 	DangerousDestinations *monitorDangerousDestinationsJSON `json:"dangerous_destinations"`
 	ExpectedDestinations  *monitorExpectedDestinationsJSON  `json:"expected_destinations"`
 	SmallDurationCalls    *monitorSmallDurationCallsJSON    `json:"small_duration_calls"`
+	TollCost              *monitorTollCostJSON              `json:"toll_cost"`
+	CallVelocity          *monitorCallVelocityJSON          `json:"call_velocity"`
 }
 
 type monitorBaseJSON struct {
 	Enabled             bool
-	ExecuteInterval     string `json:"execute_interval"`
-	HitThreshold        uint32 `json:"hit_threshold"`
-	MinimumNumberLength uint32 `json:"minimum_number_length"`
-	ActionChainName     string `json:"action_chain_name"`
+	ExecuteInterval     string   `json:"execute_interval"`
+	HitThreshold        uint32   `json:"hit_threshold"`
+	MinimumNumberLength uint32   `json:"minimum_number_length"`
+	ActionChainName     string   `json:"action_chain_name"`
+	EventDriven         bool     `json:"event_driven"`
+	Softswitches        []string `json:"softswitches"`
 }
 
 type monitorSimultaneousCallsJSON struct {
@@ -82,26 +159,53 @@ type monitorSimultaneousCallsJSON struct {
 
 type monitorDangerousDestinationsJSON struct {
 	monitorBaseJSON
-	ConsiderCDRsFromLast string   `json:"consider_cdrs_from_last"`
-	PrefixList           []string `json:"prefix_list"`
-	MatchRegex           string   `json:"match_regex"`
-	IgnoreRegex          string   `json:"ignore_regex"`
+	ConsiderCDRsFromLast    string            `json:"consider_cdrs_from_last"`
+	Incremental             bool              `json:"incremental"`
+	PrefixList              []string          `json:"prefix_list"`
+	MatchRegex              string            `json:"match_regex"`
+	IgnoreRegex             string            `json:"ignore_regex"`
+	NumberRangesMinimumRisk string            `json:"number_ranges_minimum_risk"`
+	GroupBy                 []string          `json:"group_by"`
+	GroupThresholds         map[string]uint32 `json:"group_thresholds"`
 }
 
 type monitorExpectedDestinationsJSON struct {
 	monitorBaseJSON
-	ConsiderCDRsFromLast string   `json:"consider_cdrs_from_last"`
-	PrefixList           []string `json:"prefix_list"`
-	MatchRegex           string   `json:"match_regex"`
-	IgnoreRegex          string   `json:"ignore_regex"`
+	ConsiderCDRsFromLast string            `json:"consider_cdrs_from_last"`
+	Incremental          bool              `json:"incremental"`
+	PrefixList           []string          `json:"prefix_list"`
+	MatchRegex           string            `json:"match_regex"`
+	IgnoreRegex          string            `json:"ignore_regex"`
+	GroupBy              []string          `json:"group_by"`
+	GroupThresholds      map[string]uint32 `json:"group_thresholds"`
 }
 
 type monitorSmallDurationCallsJSON struct {
 	monitorBaseJSON
-	ConsiderCDRsFromLast string `json:"consider_cdrs_from_last"`
-	DurationThreshold    string `json:"duration_threshold"`
-	MatchRegex           string `json:"match_regex"`
-	IgnoreRegex          string `json:"ignore_regex"`
+	ConsiderCDRsFromLast string            `json:"consider_cdrs_from_last"`
+	Incremental          bool              `json:"incremental"`
+	DurationThreshold    string            `json:"duration_threshold"`
+	MatchRegex           string            `json:"match_regex"`
+	IgnoreRegex          string            `json:"ignore_regex"`
+	GroupBy              []string          `json:"group_by"`
+	GroupThresholds      map[string]uint32 `json:"group_thresholds"`
+}
+
+type monitorTollCostJSON struct {
+	monitorBaseJSON
+	ConsiderCDRsFromLast string   `json:"consider_cdrs_from_last"`
+	Incremental          bool     `json:"incremental"`
+	RateTableFile        string   `json:"rate_table_file"`
+	WarningBudget        float64  `json:"warning_budget"`
+	AlarmBudget          float64  `json:"alarm_budget"`
+	GroupBy              []string `json:"group_by"`
+}
+
+type monitorCallVelocityJSON struct {
+	monitorBaseJSON
+	Incremental bool              `json:"incremental"`
+	Windows     map[string]uint32 `json:"windows"`
+	GroupBy     []string          `json:"group_by"`
 }
 
 type actionsJSON struct {
@@ -15,6 +15,204 @@ import (
 	v "github.com/gima/govalid/v1"
 )
 
+var databaseTLSSchema = v.Object(
+	v.ObjKV("enabled", v.Boolean()),
+	v.ObjKV("ca_file", v.Optional(v.String())),
+	v.ObjKV("cert_file", v.Optional(v.String())),
+	v.ObjKV("key_file", v.Optional(v.String())),
+	v.ObjKV("skip_verify", v.Optional(v.Boolean())),
+)
+
+var cdrColumnMapSchema = v.Object(
+	v.ObjKeys(v.Or(
+		v.String(v.StrIs("calldate")),
+		v.String(v.StrIs("clid")),
+		v.String(v.StrIs("src")),
+		v.String(v.StrIs("dst")),
+		v.String(v.StrIs("dcontext")),
+		v.String(v.StrIs("channel")),
+		v.String(v.StrIs("dstchannel")),
+		v.String(v.StrIs("lastapp")),
+		v.String(v.StrIs("lastdata")),
+		v.String(v.StrIs("duration")),
+		v.String(v.StrIs("billsec")),
+		v.String(v.StrIs("disposition")),
+		v.String(v.StrIs("amaflags")),
+		v.String(v.StrIs("accountcode")),
+		v.String(v.StrIs("uniqueid")),
+		v.String(v.StrIs("userfield")),
+	)),
+	v.ObjValues(v.String()),
+)
+
+var cdrsSourceDatabaseSchema = newCDRsSourceDatabaseSchema("*database", false, true)
+
+// NOTE: The table is "cel" if not set and there's no "column_map", the "cel" table's columns are fixed
+var cdrsSourceCELSchema = newCDRsSourceDatabaseSchema("*cel", true, false)
+
+// NOTE: "table_name" defaults to "acc" and the other accounting tables are only read if set
+var cdrsSourceAccountingSchema = newCDRsSourceDatabaseSchema("*accounting", true, true,
+	v.ObjKV("missed_calls_table_name", v.Optional(v.String())),
+	v.ObjKV("cdrs_table_name", v.Optional(v.String())),
+)
+
+var cdrsSourceCSVFileSchema = v.Object(
+	v.ObjKV("type", v.String(v.StrIs("*csv_file"))),
+	v.ObjKV("file_path", v.String(v.StrMin(1))),
+	v.ObjKV("usegmtime", v.Optional(v.Boolean())),
+)
+
+var cdrsSourceEventSocketSchema = v.Object(
+	v.ObjKV("type", v.String(v.StrIs("*event_socket"))),
+	v.ObjKV("retention", v.Optional(v.Function(validatorParseableDuration))),
+)
+
+var cdrsSourceHTTPSchema = v.Object(
+	v.ObjKV("type", v.String(v.StrIs("*http"))),
+	v.ObjKV("listen_address", v.String(v.StrMin(1))),
+	v.ObjKV("path", v.Optional(v.String(v.StrMin(1)))),
+	v.ObjKV("token", v.String(v.StrMin(1))),
+	v.ObjKV("tls", v.Optional(v.Object(
+		v.ObjKV("enabled", v.Boolean()),
+		v.ObjKV("cert_file", v.Optional(v.String())),
+		v.ObjKV("key_file", v.Optional(v.String())),
+	))),
+	v.ObjKV("retention", v.Optional(v.Function(validatorParseableDuration))),
+)
+
+var cdrsSourceStreamSchema = v.Object(
+	v.ObjKV("type", v.String(v.StrIs("*stream"))),
+	v.ObjKV("file_path", v.String(v.StrMin(1))),
+	v.ObjKV("format", v.Or(v.String(v.StrIs("*json")), v.String(v.StrIs("*csv")))),
+	v.ObjKV("field_map", v.Optional(cdrColumnMapSchema)),
+	v.ObjKV("retention", v.Optional(v.Function(validatorParseableDuration))),
+)
+
+var cdrsCacheSchema = v.Object(
+	v.ObjKV("enabled", v.Boolean()),
+	v.ObjKV("refresh_interval", v.Optional(v.Function(validatorParseableDuration))),
+)
+
+var liveCallsSourceCLISchema = v.Object(
+	v.ObjKV("type", v.String(v.StrIs("*cli"))),
+)
+
+var liveCallsSourceAMISchema = v.Object(
+	v.ObjKV("type", v.String(v.StrIs("*ami"))),
+	v.ObjKV("host", v.Optional(v.String())),
+	v.ObjKV("port", v.Optional(v.Number(v.NumMin(1.0), v.NumMax(65535.0)))),
+	v.ObjKV("user_name", v.String(v.StrMin(1))),
+	v.ObjKV("secret", v.String()),
+	v.ObjKV("timeout", v.Optional(v.Function(validatorParseableDuration))),
+	v.ObjKV("maximum_backoff", v.Optional(v.Function(validatorParseableDuration))),
+	v.ObjKV("events", v.Optional(v.Boolean())),
+)
+
+var liveCallsSourceEventSocketSchema = v.Object(
+	v.ObjKV("type", v.String(v.StrIs("*event_socket"))),
+	v.ObjKV("host", v.Optional(v.String())),
+	v.ObjKV("port", v.Optional(v.Number(v.NumMin(1.0), v.NumMax(65535.0)))),
+	v.ObjKV("password", v.Optional(v.String())),
+	v.ObjKV("timeout", v.Optional(v.Function(validatorParseableDuration))),
+	v.ObjKV("maximum_backoff", v.Optional(v.Function(validatorParseableDuration))),
+)
+
+// NOTE: What the CDR based monitors count Hits by, "group_thresholds" are the "hit_threshold" of some of those groups, by their values
+// separated by "|" (e.g. "1000|244" when grouped by "*src" and "*prefix")
+var groupBySchema = v.Array(v.ArrMin(1), v.ArrEach(v.Or(
+	v.String(v.StrIs("*prefix")),
+	v.String(v.StrIs("*src")),
+	v.String(v.StrIs("*accountcode")),
+	v.String(v.StrIs("*channel_peer")),
+	v.String(v.StrIs("*dcontext")),
+	v.String(v.StrIs("*softswitch")),
+)))
+
+var groupThresholdsSchema = v.Object(
+	v.ObjKeys(v.String()),
+	v.ObjValues(v.Number(v.NumMin(1.0))),
+)
+
+var numberingPlanSchema = v.Object(
+	v.ObjKV("international_prefixes", v.Array(v.ArrEach(v.String(v.StrRegExp("^[0-9]+$"))))),
+	v.ObjKV("national_prefix", v.Optional(v.String(v.StrRegExp("^[0-9]*$")))),
+	v.ObjKV("trunk_prefixes", v.Optional(v.Array(v.ArrEach(v.String(v.StrRegExp("^[0-9]+$")))))),
+	v.ObjKV("country_code", v.String(v.StrRegExp("^[1-9][0-9]{0,2}$"))),
+	v.ObjKV("minimum_national_length", v.Optional(v.Number(v.NumMin(0.0)))),
+)
+
+// newCDRsSourceDatabaseSchema Returns the schema of a CDRs Source of "sourceType" that reads from a Database, they all connect the same way
+// and differ in whether "table_name" has a default (so it can be left out), whether "column_map" can be used (not for tables whose columns
+// are fixed) and in the "extraFields" of the Source
+func newCDRsSourceDatabaseSchema(sourceType string, hasDefaultTableName bool, hasColumnMap bool, extraFields ...v.ObjectOpt) v.Validator {
+
+	tableNameSchema := v.String()
+	if hasDefaultTableName {
+		tableNameSchema = v.Optional(v.String())
+	}
+
+	fields := []v.ObjectOpt{
+		v.ObjKV("type", v.String(v.StrIs(sourceType))),
+		v.ObjKV("dbms", v.Or(v.String(v.StrIs("*mysql")), v.String(v.StrIs("*postgresql")), v.String(v.StrIs("*sqlite")))),
+		v.ObjKV("user_name", v.String()),
+		v.ObjKV("user_password", v.String()),
+		v.ObjKV("database_name", v.String()),
+		v.ObjKV("table_name", tableNameSchema),
+		v.ObjKV("host", v.Optional(v.String())),
+		v.ObjKV("port", v.Optional(v.Number(v.NumMin(1.0), v.NumMax(65535.0)))),
+		v.ObjKV("socket", v.Optional(v.String())),
+		v.ObjKV("tls", v.Optional(databaseTLSSchema)),
+		v.ObjKV("timeout", v.Optional(v.Function(validatorParseableDuration))),
+		v.ObjKV("max_open_connections", v.Optional(v.Number(v.NumMin(0.0)))),
+		v.ObjKV("max_idle_connections", v.Optional(v.Number(v.NumMin(0.0)))),
+		v.ObjKV("connection_max_lifetime", v.Optional(v.Function(validatorParseableDuration))),
+		v.ObjKV("dsn_options", v.Optional(v.Object(
+			v.ObjKeys(v.String()),
+			v.ObjValues(v.String()),
+		))),
+		v.ObjKV("usegmtime", v.Optional(v.Boolean())),
+	}
+
+	if hasColumnMap {
+		fields = append(fields, v.ObjKV("column_map", v.Optional(cdrColumnMapSchema)))
+	}
+
+	return v.Object(append(fields, extraFields...)...)
+
+}
+
+// softswitchSchema Softswitches in "softswitches" have to have a "name", the one in "softswitch" doesn't
+func softswitchSchema(name v.Validator) v.Validator {
+	return v.Or(
+		v.Object(
+			v.ObjKV("name", name),
+			v.ObjKV("type", v.String(v.StrIs("*asterisk"))),
+			v.ObjKV("version", v.String()),
+			v.ObjKV("cdrs_source", v.Or(cdrsSourceDatabaseSchema, cdrsSourceCELSchema, cdrsSourceCSVFileSchema, cdrsSourceHTTPSchema, cdrsSourceStreamSchema)),
+			v.ObjKV("cdrs_cache", v.Optional(cdrsCacheSchema)),
+			v.ObjKV("numbering_plan", v.Optional(numberingPlanSchema)),
+			v.ObjKV("live_calls_source", v.Optional(v.Or(liveCallsSourceCLISchema, liveCallsSourceAMISchema))),
+		),
+		v.Object(
+			v.ObjKV("name", name),
+			v.ObjKV("type", v.Or(v.String(v.StrIs("*kamailio")), v.String(v.StrIs("*opensips")))),
+			v.ObjKV("version", v.String()),
+			v.ObjKV("cdrs_source", v.Or(cdrsSourceAccountingSchema, cdrsSourceHTTPSchema, cdrsSourceStreamSchema)),
+			v.ObjKV("cdrs_cache", v.Optional(cdrsCacheSchema)),
+			v.ObjKV("numbering_plan", v.Optional(numberingPlanSchema)),
+		),
+		v.Object(
+			v.ObjKV("name", name),
+			v.ObjKV("type", v.String(v.StrIs("*freeswitch"))),
+			v.ObjKV("version", v.String()),
+			v.ObjKV("cdrs_source", v.Or(cdrsSourceDatabaseSchema, cdrsSourceEventSocketSchema, cdrsSourceHTTPSchema, cdrsSourceStreamSchema)),
+			v.ObjKV("cdrs_cache", v.Optional(cdrsCacheSchema)),
+			v.ObjKV("numbering_plan", v.Optional(numberingPlanSchema)),
+			v.ObjKV("live_calls_source", v.Optional(v.Or(liveCallsSourceCLISchema, liveCallsSourceEventSocketSchema))),
+		),
+	)
+}
+
 var configSchema = v.Object(
 
 	// +INFO: https://github.com/gima/govalid
@@ -23,29 +221,8 @@ var configSchema = v.Object(
 		v.ObjKV("hostname", v.String(v.StrMin(5))),
 	)),
 
-	v.ObjKV("softswitch", v.Object(
-		v.ObjKV("type", v.String(v.StrIs("*asterisk"))),
-		v.ObjKV("version", v.String()),
-		v.ObjKV("cdrs_source", v.Object(
-			v.ObjKV("type", v.String(v.StrIs("*database"))),
-			v.ObjKV("dbms", v.String()),
-			v.ObjKV("user_name", v.String()),
-			v.ObjKV("user_password", v.String()),
-			v.ObjKV("database_name", v.String()),
-			v.ObjKV("table_name", v.String()),
-		)),
-	)),
-
-	// NOTE: Example of using this lib to make different possible combinations of fields on a section, when "live_calls_data_source" can be freeswitch the options list may be different...
-	// v.ObjKV("live_calls_data_source", v.Or(
-	// 	v.Object(
-	// 		v.ObjKV("type", v.Or(v.String(v.StrIs("*asterisk")))),
-	// 		v.ObjKV("version", v.String())),
-	// 	v.Object(
-	// 		v.ObjKV("type", v.Or(v.String(v.StrIs("*freeswitch")))),
-	// 		v.ObjKV("sversion", v.String()),
-	// 	),
-	// )),
+	v.ObjKV("softswitch", v.Optional(softswitchSchema(v.Optional(v.String(v.StrMin(1)))))),
+	v.ObjKV("softswitches", v.Optional(v.Array(v.ArrEach(softswitchSchema(v.String(v.StrMin(1))))))),
 
 	v.ObjKV("monitors", v.Optional(v.Object(
 		v.ObjKV("simultaneous_calls", v.Object(
@@ -54,6 +231,8 @@ var configSchema = v.Object(
 			v.ObjKV("hit_threshold", v.Number(v.NumMin(1.0))),
 			v.ObjKV("minimum_number_length", v.Number(v.NumMin(1.0))),
 			v.ObjKV("action_chain_name", v.String()),
+			v.ObjKV("event_driven", v.Optional(v.Boolean())),
+			v.ObjKV("softswitches", v.Optional(v.Array(v.ArrEach(v.String(v.StrMin(1)))))),
 		)),
 
 		v.ObjKV("dangerous_destinations", v.Optional(v.Object(
@@ -62,11 +241,17 @@ var configSchema = v.Object(
 			v.ObjKV("hit_threshold", v.Number(v.NumMin(1.0))),
 			v.ObjKV("minimum_number_length", v.Number(v.NumMin(1.0))),
 			v.ObjKV("action_chain_name", v.String()),
+			v.ObjKV("event_driven", v.Optional(v.Boolean())),
+			v.ObjKV("softswitches", v.Optional(v.Array(v.ArrEach(v.String(v.StrMin(1)))))),
 
 			v.ObjKV("consider_cdrs_from_last", v.Function(validatorParseableDurationOrInt)),
+			v.ObjKV("incremental", v.Optional(v.Boolean())),
 			v.ObjKV("prefix_list", v.Array(v.ArrEach(v.String()))),
 			v.ObjKV("match_regex", v.Function(validatorCompilableRegex)),
 			v.ObjKV("ignore_regex", v.Function(validatorCompilableRegex)),
+			v.ObjKV("group_by", v.Optional(groupBySchema)),
+			v.ObjKV("group_thresholds", v.Optional(groupThresholdsSchema)),
+			v.ObjKV("number_ranges_minimum_risk", v.Optional(v.Or(v.String(v.StrIs("low")), v.String(v.StrIs("medium")), v.String(v.StrIs("high"))))),
 		))),
 
 		v.ObjKV("expected_destinations", v.Optional(v.Object(
@@ -75,11 +260,16 @@ var configSchema = v.Object(
 			v.ObjKV("hit_threshold", v.Number(v.NumMin(1.0))),
 			v.ObjKV("minimum_number_length", v.Number(v.NumMin(1.0))),
 			v.ObjKV("action_chain_name", v.String()),
+			v.ObjKV("event_driven", v.Optional(v.Boolean())),
+			v.ObjKV("softswitches", v.Optional(v.Array(v.ArrEach(v.String(v.StrMin(1)))))),
 
 			v.ObjKV("consider_cdrs_from_last", v.Function(validatorParseableDurationOrInt)),
+			v.ObjKV("incremental", v.Optional(v.Boolean())),
 			v.ObjKV("prefix_list", v.Array(v.ArrEach(v.String()))),
 			v.ObjKV("match_regex", v.Function(validatorCompilableRegex)),
 			v.ObjKV("ignore_regex", v.Function(validatorCompilableRegex)),
+			v.ObjKV("group_by", v.Optional(groupBySchema)),
+			v.ObjKV("group_thresholds", v.Optional(groupThresholdsSchema)),
 		))),
 
 		v.ObjKV("small_duration_calls", v.Optional(v.Object(
@@ -88,13 +278,50 @@ var configSchema = v.Object(
 			v.ObjKV("hit_threshold", v.Number(v.NumMin(1.0))),
 			v.ObjKV("minimum_number_length", v.Number(v.NumMin(1.0))),
 			v.ObjKV("action_chain_name", v.String()),
+			v.ObjKV("event_driven", v.Optional(v.Boolean())),
+			v.ObjKV("softswitches", v.Optional(v.Array(v.ArrEach(v.String(v.StrMin(1)))))),
 
 			v.ObjKV("consider_cdrs_from_last", v.Function(validatorParseableDurationOrInt)),
+			v.ObjKV("incremental", v.Optional(v.Boolean())),
 			v.ObjKV("duration_threshold", v.Function(validatorParseableDuration)),
 			v.ObjKV("match_regex", v.Function(validatorCompilableRegex)),
-			v.ObjKV("ignore_regex", v.Function(validatorCompilableRegex))),
+			v.ObjKV("ignore_regex", v.Function(validatorCompilableRegex)),
+			v.ObjKV("group_by", v.Optional(groupBySchema)),
+			v.ObjKV("group_thresholds", v.Optional(groupThresholdsSchema)),
+		))),
+
+		v.ObjKV("toll_cost", v.Optional(v.Object(
+			v.ObjKV("enabled", v.Boolean()),
+			v.ObjKV("execute_interval", v.Function(validatorParseableDuration)),
+			v.ObjKV("minimum_number_length", v.Optional(v.Number(v.NumMin(0.0)))),
+			v.ObjKV("action_chain_name", v.String()),
+			v.ObjKV("event_driven", v.Optional(v.Boolean())),
+			v.ObjKV("softswitches", v.Optional(v.Array(v.ArrEach(v.String(v.StrMin(1)))))),
+
+			v.ObjKV("consider_cdrs_from_last", v.Function(validatorParseableDuration)),
+			v.ObjKV("incremental", v.Optional(v.Boolean())),
+			v.ObjKV("rate_table_file", v.String(v.StrMin(1))),
+			v.ObjKV("warning_budget", v.Optional(v.Number(v.NumMin(0.0)))),
+			v.ObjKV("alarm_budget", v.Number(v.NumMin(0.0))),
+			v.ObjKV("group_by", v.Optional(groupBySchema)),
 		))),
-	)),
+
+		v.ObjKV("call_velocity", v.Optional(v.Object(
+			v.ObjKV("enabled", v.Boolean()),
+			v.ObjKV("execute_interval", v.Function(validatorParseableDuration)),
+			v.ObjKV("minimum_number_length", v.Optional(v.Number(v.NumMin(0.0)))),
+			v.ObjKV("action_chain_name", v.String()),
+			v.ObjKV("event_driven", v.Optional(v.Boolean())),
+			v.ObjKV("softswitches", v.Optional(v.Array(v.ArrEach(v.String(v.StrMin(1)))))),
+
+			v.ObjKV("incremental", v.Optional(v.Boolean())),
+			v.ObjKV("windows", v.Object(
+				v.ObjKeys(v.Function(validatorParseableDuration)),
+				v.ObjValues(v.Number(v.NumMin(1.0))),
+			)),
+			v.ObjKV("group_by", v.Optional(groupBySchema)),
+		))),
+	))),
 
 	v.ObjKV("actions", v.Optional(v.Object(
 		v.ObjKV("email", v.Optional(v.Object(
@@ -121,6 +348,11 @@ var configSchema = v.Object(
 		)))),
 	))),
 
+	v.ObjKV("number_ranges", v.Optional(v.Object(
+		v.ObjKV("files", v.Array(v.ArrMin(1), v.ArrEach(v.String(v.StrMin(1))))),
+		v.ObjKV("reload_interval", v.Optional(v.Function(validatorParseableDuration))),
+	))),
+
 	v.ObjKV("data_groups", v.Optional(v.Object(
 		v.ObjKeys(v.String()),
 		v.ObjValues(v.Object(
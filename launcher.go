@@ -3,19 +3,26 @@ package main
 import (
 	"flag"
 	"os"
+	"strconv"
+	"syscall"
 	"time"
 
+	"crypto/tls"
 	"net/http"
+	"os/signal"
 	"path/filepath"
 
 	"github.com/andmar/fraudion/config"
 	"github.com/andmar/fraudion/monitors"
 	"github.com/andmar/fraudion/softswitches"
 	"github.com/andmar/fraudion/system"
+	"github.com/andmar/fraudion/utils"
 
 	"github.com/andmar/marlog"
 
 	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
 )
 
 const (
@@ -43,6 +50,7 @@ func main() {
 
 	// NOTE: What kind of "logs" will be available (that log to STDOUT)
 	log.SetStamp("ERROR", "*STDOUT")
+	log.SetStamp("WARNING", "*STDOUT")
 	log.SetStamp("DEBUG", "*STDOUT")
 	log.SetStamp("INFO", "*STDOUT")
 	log.SetStamp("VERBOSE", "*STDOUT")
@@ -151,47 +159,32 @@ func main() {
 		log.LogO("ERROR", "Can't proceed. :( There was an error loading configurations ("+err.Error()+").", marlog.OptionFatal)
 	}
 
-	// * Monitored Softswitch Setup
-	log.LogS("INFO", "Configuring the monitored Softswitch...")
-	switch config.Loaded.Softswitch.Type {
-	case softswitches.TypeAsterisk:
-
-		log.LogS("DEBUG", "Softswitch type Asterisk")
-
-		newSoftswitch := new(softswitches.Asterisk)
-		newSoftswitch.Version = config.Loaded.Softswitch.Version
-		newSoftswitch.CDRsSource = config.Loaded.Softswitch.CDRsSource
-
-		switch config.Loaded.Softswitch.CDRsSource["type"] {
-		case softswitches.CDRSourceDatabase:
-
-			log.LogS("DEBUG", "CDRs Source is Database, DBMS \""+config.Loaded.Softswitch.CDRsSource["dbms"]+"\"")
+	// * Number Ranges Setup
+	if config.Loaded.NumberRanges.Enabled == true {
+		setupNumberRanges()
+	}
 
-			newSource := new(softswitches.CDRsSourceDatabase)
-			newSource.DBMS = config.Loaded.Softswitch.CDRsSource["dbms"]
-			newSource.UserName = config.Loaded.Softswitch.CDRsSource["user_name"]
-			newSource.UserPassword = config.Loaded.Softswitch.CDRsSource["user_password"]
-			newSource.DatabaseName = config.Loaded.Softswitch.CDRsSource["database_name"]
-			newSource.TableName = config.Loaded.Softswitch.CDRsSource["table_name"]
+	// NOTE: "fraudion [flags] backtest [backtest flags]" replays CDRs from the past through the monitors instead of monitoring
+	if flag.Arg(0) == "backtest" {
+		runBacktest(flag.Args()[1:])
+		os.Exit(0)
+	}
 
-			if err := newSource.Connect(); err != nil {
-				log.LogO("ERROR", "Can't proceed. :( There was an Error (could not setup the Database connections pool)", marlog.OptionFatal)
-			}
+	// * Monitored Softswitches Setup
+	log.LogS("INFO", "Configuring the monitored Softswitches...")
+	for index := range config.Loaded.Softswitches {
 
-			newSoftswitch.CDRsSource = newSource
+		softswitchConfig := &config.Loaded.Softswitches[index]
 
-		default:
-			// NOTE: This should not happen in the future because it's going to be validated in the configuration parsing/loading phase
-			log.LogO("ERROR", "Can't proceed. :( There was an Error (unknown CDR Source type \""+config.Loaded.Softswitch.CDRsSource["type"]+"\" configured)", marlog.OptionFatal)
-		}
+		log.LogS("INFO", "Configuring Softswitch \""+softswitchConfig.Name+"\"...")
 
-		log.LogS("INFO", "Softswitch is set up...")
+		softswitches.Monitored[softswitchConfig.Name] = setupSoftswitch(softswitchConfig)
 
-		softswitches.Monitored = newSoftswitch
+	}
 
-	default:
-		// NOTE: This should not happen in the future because it's going to be validated in the configuration parsing/loading phase
-		log.LogO("ERROR", "Can't proceed. :( There was an Error (unknown Softswitch type \""+config.Loaded.Softswitch.Type+"\" configured)", marlog.OptionFatal)
+	if monitors.NumberRanges != nil {
+		go monitors.NumberRanges.Watch(config.Loaded.NumberRanges.ReloadInterval)
+		go reloadNumberRangesOnSIGHUP()
 	}
 
 	// * Config/Start Monitors
@@ -201,12 +194,21 @@ func main() {
 
 		log.LogS("DEBUG", "Monitor \"DangerousDestinations\" is Enabled")
 
-		monitor := new(monitors.DangerousDestinations)
-		monitor.Config = &config.Loaded.Monitors.DangerousDestinations
-		monitor.Softswitch = softswitches.Monitored
+		for name, monitored := range softswitches.Monitored {
 
-		log.LogS("INFO", "Starting execution of monitor \"DangerousDestinations\"...")
-		go monitor.Run()
+			if config.Loaded.Monitors.DangerousDestinations.IsBoundTo(name) == false {
+				continue
+			}
+
+			monitor := new(monitors.DangerousDestinations)
+			monitor.Config = &config.Loaded.Monitors.DangerousDestinations
+			monitor.Softswitch = monitored
+			monitor.SoftswitchName = name
+
+			log.LogS("INFO", "Starting execution of monitor \"DangerousDestinations\" on Softswitch \""+name+"\"...")
+			go monitor.Run()
+
+		}
 
 	}
 
@@ -214,12 +216,21 @@ func main() {
 
 		log.LogS("DEBUG", "Monitor \"SimultaneousCalls\" is Enabled")
 
-		monitor := new(monitors.SimultaneousCalls)
-		monitor.Config = &config.Loaded.Monitors.SimultaneousCalls
-		monitor.Softswitch = softswitches.Monitored
+		for name, monitored := range softswitches.Monitored {
+
+			if config.Loaded.Monitors.SimultaneousCalls.IsBoundTo(name) == false {
+				continue
+			}
+
+			monitor := new(monitors.SimultaneousCalls)
+			monitor.Config = &config.Loaded.Monitors.SimultaneousCalls
+			monitor.Softswitch = monitored
+			monitor.SoftswitchName = name
+
+			log.LogS("INFO", "Starting execution of monitor \"SimultaneousCalls\" on Softswitch \""+name+"\"...")
+			go monitor.Run()
 
-		log.LogS("INFO", "Starting execution of monitor \"SimultaneousCalls\"...")
-		go monitor.Run()
+		}
 
 	}
 
@@ -227,12 +238,21 @@ func main() {
 
 		log.LogS("DEBUG", "Monitor \"ExpectedDestinations\" is Enabled")
 
-		monitor := new(monitors.ExpectedDestinations)
-		monitor.Config = &config.Loaded.Monitors.ExpectedDestinations
-		monitor.Softswitch = softswitches.Monitored
+		for name, monitored := range softswitches.Monitored {
+
+			if config.Loaded.Monitors.ExpectedDestinations.IsBoundTo(name) == false {
+				continue
+			}
+
+			monitor := new(monitors.ExpectedDestinations)
+			monitor.Config = &config.Loaded.Monitors.ExpectedDestinations
+			monitor.Softswitch = monitored
+			monitor.SoftswitchName = name
 
-		log.LogS("INFO", "Starting execution of monitor \"ExpectedDestinations\"...")
-		go monitor.Run()
+			log.LogS("INFO", "Starting execution of monitor \"ExpectedDestinations\" on Softswitch \""+name+"\"...")
+			go monitor.Run()
+
+		}
 
 	}
 
@@ -240,12 +260,68 @@ func main() {
 
 		log.LogS("DEBUG", "Monitor \"SmallDurationCalls\" is Enabled")
 
-		monitor := new(monitors.SmallDurationCalls)
-		monitor.Config = &config.Loaded.Monitors.SmallDurationCalls
-		monitor.Softswitch = softswitches.Monitored
+		for name, monitored := range softswitches.Monitored {
+
+			if config.Loaded.Monitors.SmallDurationCalls.IsBoundTo(name) == false {
+				continue
+			}
 
-		log.LogS("INFO", "Starting execution of monitor \"SmallDurationCalls\"...")
-		go monitor.Run()
+			monitor := new(monitors.SmallDurationCalls)
+			monitor.Config = &config.Loaded.Monitors.SmallDurationCalls
+			monitor.Softswitch = monitored
+			monitor.SoftswitchName = name
+
+			log.LogS("INFO", "Starting execution of monitor \"SmallDurationCalls\" on Softswitch \""+name+"\"...")
+			go monitor.Run()
+
+		}
+
+	}
+
+	if config.Loaded.Monitors.TollCost.Enabled == true {
+
+		log.LogS("DEBUG", "Monitor \"TollCost\" is Enabled")
+
+		rateTable := setupRateTable()
+
+		for name, monitored := range softswitches.Monitored {
+
+			if config.Loaded.Monitors.TollCost.IsBoundTo(name) == false {
+				continue
+			}
+
+			monitor := new(monitors.TollCost)
+			monitor.Config = &config.Loaded.Monitors.TollCost
+			monitor.RateTable = rateTable
+			monitor.Softswitch = monitored
+			monitor.SoftswitchName = name
+
+			log.LogS("INFO", "Starting execution of monitor \"TollCost\" on Softswitch \""+name+"\"...")
+			go monitor.Run()
+
+		}
+
+	}
+
+	if config.Loaded.Monitors.CallVelocity.Enabled == true {
+
+		log.LogS("DEBUG", "Monitor \"CallVelocity\" is Enabled")
+
+		for name, monitored := range softswitches.Monitored {
+
+			if config.Loaded.Monitors.CallVelocity.IsBoundTo(name) == false {
+				continue
+			}
+
+			monitor := new(monitors.CallVelocity)
+			monitor.Config = &config.Loaded.Monitors.CallVelocity
+			monitor.Softswitch = monitored
+			monitor.SoftswitchName = name
+
+			log.LogS("INFO", "Starting execution of monitor \"CallVelocity\" on Softswitch \""+name+"\"...")
+			go monitor.Run()
+
+		}
 
 	}
 
@@ -258,3 +334,429 @@ func main() {
 	}
 
 }
+
+// setupNumberRanges Loads the configured number ranges for the monitors to match against (see monitors.NumberRanges)
+func setupNumberRanges() {
+
+	log := marlog.MarLog
+
+	log.LogS("INFO", "Loading the number ranges...")
+
+	numberRanges := utils.NewNumberRanges(config.Loaded.NumberRanges.Files)
+	if err := numberRanges.Load(); err != nil {
+		log.LogO("ERROR", "Can't proceed. :( There was an Error ("+err.Error()+")", marlog.OptionFatal)
+	}
+
+	monitors.NumberRanges = numberRanges
+
+}
+
+// setupRateTable Loads the rate table the "toll_cost" monitor estimates the cost of the calls with
+func setupRateTable() *utils.RateTable {
+
+	log := marlog.MarLog
+
+	log.LogS("INFO", "Loading the rate table...")
+
+	rateTable, err := utils.LoadRateTable(config.Loaded.Monitors.TollCost.RateTableFile)
+	if err != nil {
+		log.LogO("ERROR", "Can't proceed. :( There was an Error ("+err.Error()+")", marlog.OptionFatal)
+	}
+
+	log.LogS("INFO", "Loaded "+strconv.Itoa(rateTable.Count())+" rates in "+rateTable.Currency)
+
+	return rateTable
+
+}
+
+// reloadNumberRangesOnSIGHUP Loads the number ranges again each time Fraudion gets a SIGHUP, without waiting for them to be checked for
+// changes
+func reloadNumberRangesOnSIGHUP() {
+
+	log := marlog.MarLog
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	for range signals {
+
+		log.LogS("INFO", "Got a SIGHUP, loading the number ranges again...")
+
+		if err := monitors.NumberRanges.Load(); err != nil {
+			log.LogS("ERROR", "Could not load the number ranges again, keeping the ones that were loaded ("+err.Error()+")")
+		}
+
+	}
+
+}
+
+// setupSoftswitch Creates the configured Softswitch, in front of a shared CDRs cache if it's enabled
+func setupSoftswitch(softswitchConfig *config.Softswitch) softswitches.Softswitch {
+
+	log := marlog.MarLog
+
+	var monitored softswitches.Softswitch
+
+	switch softswitchConfig.Type {
+	case softswitches.TypeAsterisk:
+
+		log.LogS("DEBUG", "Softswitch type Asterisk")
+
+		newSoftswitch := new(softswitches.Asterisk)
+		newSoftswitch.Version = softswitchConfig.Version
+		newSoftswitch.CDRsSource = setupCDRsSource(softswitchConfig, softswitches.AsteriskCDRColumnMap)
+		newSoftswitch.LiveCallsSource = setupLiveCallsSource(softswitchConfig)
+
+		log.LogS("INFO", "Softswitch is set up...")
+
+		monitored = newSoftswitch
+
+	case softswitches.TypeFreeSwitch:
+
+		log.LogS("DEBUG", "Softswitch type FreeSwitch")
+
+		newSoftswitch := new(softswitches.FreeSwitch)
+		newSoftswitch.Version = softswitchConfig.Version
+		// NOTE: Without a Live Calls Source FreeSWITCH runs "fs_cli"
+		if softswitchConfig.LiveCallsSource.Type == softswitches.LiveCallSourceEventSocket {
+			newSoftswitch.LiveCallsSource = setupLiveCallsSource(softswitchConfig)
+		}
+		if softswitchConfig.CDRsSource.Type == softswitches.CDRSourceEventSocket {
+			log.LogS("DEBUG", "CDRs Source is the Event Socket")
+			newSoftswitch.CDRsSource = newSoftswitch.LiveCallsSource.(*softswitches.LiveCallsSourceESLEvents).CDRs
+		} else {
+			newSoftswitch.CDRsSource = setupCDRsSource(softswitchConfig, softswitches.FreeSwitchCDRColumnMap)
+		}
+
+		log.LogS("INFO", "Softswitch is set up...")
+
+		monitored = newSoftswitch
+
+	case softswitches.TypeKamailio, softswitches.TypeOpenSIPS:
+
+		log.LogS("DEBUG", "Softswitch type SIP proxy ("+softswitchConfig.Type+")")
+
+		defaultColumnMap := softswitches.KamailioCDRColumnMap
+		if softswitchConfig.Type == softswitches.TypeOpenSIPS {
+			defaultColumnMap = softswitches.OpenSIPSCDRColumnMap
+		}
+
+		newSoftswitch := new(softswitches.SIPProxy)
+		newSoftswitch.Version = softswitchConfig.Version
+		newSoftswitch.CDRsSource = setupCDRsSource(softswitchConfig, defaultColumnMap)
+
+		log.LogS("INFO", "Softswitch is set up...")
+
+		monitored = newSoftswitch
+
+	default:
+		// NOTE: This should not happen in the future because it's going to be validated in the configuration parsing/loading phase
+		log.LogO("ERROR", "Can't proceed. :( There was an Error (unknown Softswitch type \""+softswitchConfig.Type+"\" configured)", marlog.OptionFatal)
+	}
+
+	// NOTE: Numbers are normalized before they are cached, monitors only ever see them normalized
+	monitored = setupNumberNormalizer(softswitchConfig, monitored)
+
+	// NOTE: Shared CDRs Cache
+	if softswitchConfig.CDRsCache.Enabled == true {
+
+		window, refreshInterval := getCDRsCacheWindowAndInterval(softswitchConfig)
+
+		if window > 0 {
+
+			log.LogS("INFO", "Setting up the shared CDRs cache for the last \""+window.String()+"\" refreshed every \""+refreshInterval.String()+"\"...")
+
+			monitored = softswitches.NewCDRsCache(monitored, window, refreshInterval)
+
+		}
+
+	}
+
+	return monitored
+
+}
+
+// setupNumberNormalizer Puts "softswitch" behind a NumberNormalizer if it has a numbering plan
+func setupNumberNormalizer(softswitchConfig *config.Softswitch, softswitch softswitches.Softswitch) softswitches.Softswitch {
+
+	log := marlog.MarLog
+
+	if softswitchConfig.NumberingPlan.Enabled == false {
+		return softswitch
+	}
+
+	log.LogS("INFO", "Normalizing dialed numbers to E.164 with country code \""+softswitchConfig.NumberingPlan.CountryCode+"\"...")
+
+	plan := new(softswitches.NumberingPlan)
+	plan.InternationalPrefixes = softswitchConfig.NumberingPlan.InternationalPrefixes
+	plan.NationalPrefix = softswitchConfig.NumberingPlan.NationalPrefix
+	plan.TrunkPrefixes = softswitchConfig.NumberingPlan.TrunkPrefixes
+	plan.CountryCode = softswitchConfig.NumberingPlan.CountryCode
+	plan.MinimumNationalLength = softswitchConfig.NumberingPlan.MinimumNationalLength
+
+	return softswitches.NewNumberNormalizer(softswitch, plan)
+
+}
+
+// setupCDRsSource Creates the configured CDRs Source, "defaultColumnMap" is the Softswitch's CDR table layout for Database Sources
+func setupCDRsSource(softswitchConfig *config.Softswitch, defaultColumnMap map[string]string) softswitches.CDRsSource {
+
+	log := marlog.MarLog
+
+	switch softswitchConfig.CDRsSource.Type {
+	case softswitches.CDRSourceDatabase:
+
+		log.LogS("DEBUG", "CDRs Source is Database, DBMS \""+softswitchConfig.CDRsSource.DBMS+"\"")
+
+		newSource := new(softswitches.CDRsSourceDatabase)
+		setupCDRsSourceDatabase(softswitchConfig, newSource)
+		newSource.ColumnMap = softswitchConfig.CDRsSource.ColumnMap
+		newSource.DefaultColumnMap = defaultColumnMap
+
+		if err := newSource.Connect(); err != nil {
+			log.LogO("ERROR", "Can't proceed. :( There was an Error (could not setup the Database connections pool: "+err.Error()+")", marlog.OptionFatal)
+		}
+
+		return newSource
+
+	case softswitches.CDRSourceCEL:
+
+		log.LogS("DEBUG", "CDRs Source is CEL, DBMS \""+softswitchConfig.CDRsSource.DBMS+"\"")
+
+		newSource := new(softswitches.CDRsSourceCEL)
+		setupCDRsSourceDatabase(softswitchConfig, &newSource.CDRsSourceDatabase)
+
+		if err := newSource.Connect(); err != nil {
+			log.LogO("ERROR", "Can't proceed. :( There was an Error (could not setup the Database connections pool: "+err.Error()+")", marlog.OptionFatal)
+		}
+
+		return newSource
+
+	case softswitches.CDRSourceAccounting:
+
+		log.LogS("DEBUG", "CDRs Source is SIP Accounting, DBMS \""+softswitchConfig.CDRsSource.DBMS+"\"")
+
+		newSource := new(softswitches.CDRsSourceAccounting)
+		setupCDRsSourceDatabase(softswitchConfig, &newSource.CDRsSourceDatabase)
+		newSource.ColumnMap = softswitchConfig.CDRsSource.ColumnMap
+		newSource.DefaultColumnMap = defaultColumnMap
+		newSource.MissedCallsTableName = softswitchConfig.CDRsSource.MissedCallsTableName
+		newSource.CDRsTableName = softswitchConfig.CDRsSource.CDRsTableName
+
+		if err := newSource.Connect(); err != nil {
+			log.LogO("ERROR", "Can't proceed. :( There was an Error (could not setup the Database connections pool: "+err.Error()+")", marlog.OptionFatal)
+		}
+
+		return newSource
+
+	case softswitches.CDRSourceCSVFile:
+
+		log.LogS("DEBUG", "CDRs Source is CSV File \""+softswitchConfig.CDRsSource.FilePath+"\"")
+
+		newSource := new(softswitches.CDRsSourceCSVFile)
+		newSource.FilePath = softswitchConfig.CDRsSource.FilePath
+		newSource.UseGMTime = softswitchConfig.CDRsSource.UseGMTime
+
+		return newSource
+
+	case softswitches.CDRSourceHTTP:
+
+		log.LogS("DEBUG", "CDRs Source is HTTP, listening on \""+softswitchConfig.CDRsSource.ListenAddress+"\"")
+
+		retention := getCDRsRetention(softswitchConfig)
+		log.LogS("DEBUG", "Keeping pushed CDRs for \""+retention.String()+"\"")
+
+		newSource := softswitches.NewCDRsSourceHTTP(softswitchConfig.CDRsSource.ListenAddress, softswitchConfig.CDRsSource.Path, softswitchConfig.CDRsSource.Token, softswitches.NewCDRsSourceMemory(retention))
+
+		if softswitchConfig.CDRsSource.TLS.Enabled == true {
+			certificate, err := tls.LoadX509KeyPair(softswitchConfig.CDRsSource.TLS.CertFile, softswitchConfig.CDRsSource.TLS.KeyFile)
+			if err != nil {
+				log.LogO("ERROR", "Can't proceed. :( There was an Error (could not load the HTTP CDRs Source certificate: "+err.Error()+")", marlog.OptionFatal)
+			}
+			newSource.TLSConfig = &tls.Config{Certificates: []tls.Certificate{certificate}}
+		}
+
+		if err := newSource.Listen(); err != nil {
+			log.LogO("ERROR", "Can't proceed. :( There was an Error (could not start taking CDRs over HTTP: "+err.Error()+")", marlog.OptionFatal)
+		}
+
+		return newSource
+
+	case softswitches.CDRSourceStream:
+
+		log.LogS("DEBUG", "CDRs Source is Stream \""+softswitchConfig.CDRsSource.FilePath+"\" ("+softswitchConfig.CDRsSource.Format+")")
+
+		retention := getCDRsRetention(softswitchConfig)
+		log.LogS("DEBUG", "Keeping streamed CDRs for \""+retention.String()+"\"")
+
+		newSource := softswitches.NewCDRsSourceStream(softswitchConfig.CDRsSource.FilePath, softswitchConfig.CDRsSource.Format, softswitchConfig.CDRsSource.FieldMap, softswitches.NewCDRsSourceMemory(retention))
+		go newSource.Run()
+
+		return newSource
+
+	default:
+		// NOTE: This should not happen in the future because it's going to be validated in the configuration parsing/loading phase
+		log.LogO("ERROR", "Can't proceed. :( There was an Error (unknown CDR Source type \""+softswitchConfig.CDRsSource.Type+"\" configured)", marlog.OptionFatal)
+	}
+
+	return nil
+
+}
+
+// setupCDRsSourceDatabase Sets up the Database connection settings, which are the same for all Sources that read from a Database
+func setupCDRsSourceDatabase(softswitchConfig *config.Softswitch, newSource *softswitches.CDRsSourceDatabase) {
+
+	newSource.SoftswitchName = softswitchConfig.Name
+	newSource.DBMS = softswitchConfig.CDRsSource.DBMS
+	newSource.UserName = softswitchConfig.CDRsSource.UserName
+	newSource.UserPassword = softswitchConfig.CDRsSource.UserPassword
+	newSource.DatabaseName = softswitchConfig.CDRsSource.DatabaseName
+	newSource.TableName = softswitchConfig.CDRsSource.TableName
+	newSource.Host = softswitchConfig.CDRsSource.Host
+	newSource.Port = softswitchConfig.CDRsSource.Port
+	newSource.Socket = softswitchConfig.CDRsSource.Socket
+	newSource.TLS.Enabled = softswitchConfig.CDRsSource.TLS.Enabled
+	newSource.TLS.CAFile = softswitchConfig.CDRsSource.TLS.CAFile
+	newSource.TLS.CertFile = softswitchConfig.CDRsSource.TLS.CertFile
+	newSource.TLS.KeyFile = softswitchConfig.CDRsSource.TLS.KeyFile
+	newSource.TLS.SkipVerify = softswitchConfig.CDRsSource.TLS.SkipVerify
+	newSource.Timeout = softswitchConfig.CDRsSource.Timeout
+	newSource.MaxOpenConnections = softswitchConfig.CDRsSource.MaxOpenConnections
+	newSource.MaxIdleConnections = softswitchConfig.CDRsSource.MaxIdleConnections
+	newSource.ConnectionMaxLifetime = softswitchConfig.CDRsSource.ConnectionMaxLifetime
+	newSource.DSNOptions = softswitchConfig.CDRsSource.DSNOptions
+	newSource.UseGMTime = softswitchConfig.CDRsSource.UseGMTime
+
+}
+
+// setupLiveCallsSource Creates the configured Live Calls Source, the Asterisk CLI unless configured otherwise
+func setupLiveCallsSource(softswitchConfig *config.Softswitch) softswitches.LiveCallsSource {
+
+	log := marlog.MarLog
+
+	switch softswitchConfig.LiveCallsSource.Type {
+	case softswitches.LiveCallSourceCLI:
+
+		log.LogS("DEBUG", "Live Calls Source is the Asterisk CLI")
+
+		newSource := new(softswitches.LiveCallsSourceCLI)
+		newSource.Version = softswitchConfig.Version
+
+		return newSource
+
+	case softswitches.LiveCallSourceAMI:
+
+		log.LogS("DEBUG", "Live Calls Source is AMI, at \""+softswitchConfig.LiveCallsSource.Host+"\"")
+
+		newClient := new(softswitches.AMIClient)
+		newClient.Host = softswitchConfig.LiveCallsSource.Host
+		newClient.Port = softswitchConfig.LiveCallsSource.Port
+		newClient.UserName = softswitchConfig.LiveCallsSource.UserName
+		newClient.Secret = softswitchConfig.LiveCallsSource.Secret
+		newClient.Timeout = softswitchConfig.LiveCallsSource.Timeout
+		newClient.MaximumBackoff = softswitchConfig.LiveCallsSource.MaximumBackoff
+
+		if softswitchConfig.LiveCallsSource.Events == true {
+
+			log.LogS("DEBUG", "Following calls via AMI events")
+
+			newSource := softswitches.NewLiveCallsSourceAMIEvents(newClient)
+			go newSource.Run()
+
+			return newSource
+
+		}
+
+		newSource := new(softswitches.LiveCallsSourceAMI)
+		newSource.Client = newClient
+
+		return newSource
+
+	case softswitches.LiveCallSourceEventSocket:
+
+		log.LogS("DEBUG", "Live Calls Source is the Event Socket, at \""+softswitchConfig.LiveCallsSource.Host+"\"")
+
+		newClient := new(softswitches.ESLClient)
+		newClient.Host = softswitchConfig.LiveCallsSource.Host
+		newClient.Port = softswitchConfig.LiveCallsSource.Port
+		newClient.Password = softswitchConfig.LiveCallsSource.Password
+		newClient.Timeout = softswitchConfig.LiveCallsSource.Timeout
+		newClient.MaximumBackoff = softswitchConfig.LiveCallsSource.MaximumBackoff
+
+		var cdrs *softswitches.CDRsSourceMemory
+		if softswitchConfig.CDRsSource.Type == softswitches.CDRSourceEventSocket {
+			retention := getCDRsRetention(softswitchConfig)
+			log.LogS("DEBUG", "Keeping CDRs from Event Socket events for \""+retention.String()+"\"")
+			cdrs = softswitches.NewCDRsSourceMemory(retention)
+		}
+
+		newSource := softswitches.NewLiveCallsSourceESLEvents(newClient, cdrs)
+		go newSource.Run()
+
+		return newSource
+
+	default:
+		// NOTE: This should not happen in the future because it's going to be validated in the configuration parsing/loading phase
+		log.LogO("ERROR", "Can't proceed. :( There was an Error (unknown Live Calls Source type \""+softswitchConfig.LiveCallsSource.Type+"\" configured)", marlog.OptionFatal)
+	}
+
+	return nil
+
+}
+
+// getCDRsRetention Returns for how long the CDRs Sources that keep CDRs in memory keep them, as long as configured or else as long as the
+// CDR based monitors look back
+func getCDRsRetention(softswitchConfig *config.Softswitch) time.Duration {
+
+	if softswitchConfig.CDRsSource.Retention > 0 {
+		return softswitchConfig.CDRsSource.Retention
+	}
+
+	window, _ := getCDRsCacheWindowAndInterval(softswitchConfig)
+
+	return window
+
+}
+
+// getCDRsCacheWindowAndInterval Returns the widest time window the enabled CDR based monitors look at and, unless configured, the shortest
+// interval at which they execute, which is how often the cache has to be refreshed for none of them to see stale CDRs
+func getCDRsCacheWindowAndInterval(softswitchConfig *config.Softswitch) (time.Duration, time.Duration) {
+
+	var window, refreshInterval time.Duration
+
+	consider := func(considerCDRsFromLast time.Duration, executeInterval time.Duration) {
+		if considerCDRsFromLast > window {
+			window = considerCDRsFromLast
+		}
+		if refreshInterval == 0 || executeInterval < refreshInterval {
+			refreshInterval = executeInterval
+		}
+	}
+
+	if config.Loaded.Monitors.DangerousDestinations.Enabled == true && config.Loaded.Monitors.DangerousDestinations.IsBoundTo(softswitchConfig.Name) {
+		consider(config.Loaded.Monitors.DangerousDestinations.ConsiderCDRsFromLast, config.Loaded.Monitors.DangerousDestinations.ExecuteInterval)
+	}
+
+	if config.Loaded.Monitors.ExpectedDestinations.Enabled == true && config.Loaded.Monitors.ExpectedDestinations.IsBoundTo(softswitchConfig.Name) {
+		consider(config.Loaded.Monitors.ExpectedDestinations.ConsiderCDRsFromLast, config.Loaded.Monitors.ExpectedDestinations.ExecuteInterval)
+	}
+
+	if config.Loaded.Monitors.SmallDurationCalls.Enabled == true && config.Loaded.Monitors.SmallDurationCalls.IsBoundTo(softswitchConfig.Name) {
+		consider(config.Loaded.Monitors.SmallDurationCalls.ConsiderCDRsFromLast, config.Loaded.Monitors.SmallDurationCalls.ExecuteInterval)
+	}
+
+	if config.Loaded.Monitors.TollCost.Enabled == true && config.Loaded.Monitors.TollCost.IsBoundTo(softswitchConfig.Name) {
+		consider(config.Loaded.Monitors.TollCost.ConsiderCDRsFromLast, config.Loaded.Monitors.TollCost.ExecuteInterval)
+	}
+
+	if config.Loaded.Monitors.CallVelocity.Enabled == true && config.Loaded.Monitors.CallVelocity.IsBoundTo(softswitchConfig.Name) {
+		consider(config.Loaded.Monitors.CallVelocity.ConsiderCDRsFromLast, config.Loaded.Monitors.CallVelocity.ExecuteInterval)
+	}
+
+	if softswitchConfig.CDRsCache.RefreshInterval > 0 {
+		refreshInterval = softswitchConfig.CDRsCache.RefreshInterval
+	}
+
+	return window, refreshInterval
+
+}
@@ -0,0 +1,137 @@
+package monitors
+
+import (
+	"time"
+
+	"github.com/andmar/fraudion/softswitches"
+
+	"github.com/andmar/marlog"
+)
+
+// BacktestAlarm A tick at which a monitor would have been in alarm, with the Hits that were above its threshold and what they were
+// grouped by
+type BacktestAlarm struct {
+	Time       time.Time
+	Monitor    string
+	Softswitch string
+	Hits       map[string]*softswitches.Hits
+	GroupBy    []string
+}
+
+// Backtester A monitor that can be executed on CDRs from the past instead of on the current ones, the CDR based ones
+type Backtester interface {
+	Backtest(replay *softswitches.Replay, from time.Time, to time.Time) ([]*BacktestAlarm, error)
+}
+
+// Backtest Executes the monitor at each "execute_interval" from "from" to "to" on "replay" and returns when it would have been in alarm,
+// nothing is done about it (no action chains are executed)
+func (monitor *DangerousDestinations) Backtest(replay *softswitches.Replay, from time.Time, to time.Time) ([]*BacktestAlarm, error) {
+
+	monitor.Softswitch = replay
+
+	return backtest(replay, from, to, monitor.Config.ExecuteInterval, "DangerousDestinations", monitor.SoftswitchName, monitor.Config.GroupBy, func() (map[string]*softswitches.Hits, error) {
+
+		hits, err := monitor.getHits(nil, monitor.matches, monitor.Config.ConsiderCDRsFromLast, false, monitor.Config.GroupBy)
+		if err != nil {
+			return nil, err
+		}
+
+		return monitor.aboveThreshold(hits), nil
+
+	})
+
+}
+
+// Backtest ...
+func (monitor *ExpectedDestinations) Backtest(replay *softswitches.Replay, from time.Time, to time.Time) ([]*BacktestAlarm, error) {
+
+	monitor.Softswitch = replay
+
+	return backtest(replay, from, to, monitor.Config.ExecuteInterval, "ExpectedDestinations", monitor.SoftswitchName, monitor.Config.GroupBy, func() (map[string]*softswitches.Hits, error) {
+
+		hits, err := monitor.getHits(nil, monitor.matches, monitor.Config.ConsiderCDRsFromLast, false, monitor.Config.GroupBy)
+		if err != nil {
+			return nil, err
+		}
+
+		return hitsAboveThreshold(hits, monitor.Config.HitThreshold, monitor.Config.GroupThresholds), nil
+
+	})
+
+}
+
+// Backtest ...
+func (monitor *SmallDurationCalls) Backtest(replay *softswitches.Replay, from time.Time, to time.Time) ([]*BacktestAlarm, error) {
+
+	monitor.Softswitch = replay
+
+	return backtest(replay, from, to, monitor.Config.ExecuteInterval, "SmallDurationCalls", monitor.SoftswitchName, monitor.Config.GroupBy, func() (map[string]*softswitches.Hits, error) {
+
+		hits, err := monitor.getHits(nil, monitor.matches, monitor.Config.ConsiderCDRsFromLast, true, monitor.Config.GroupBy)
+		if err != nil {
+			return nil, err
+		}
+
+		return hitsAboveThreshold(hits, monitor.Config.HitThreshold, monitor.Config.GroupThresholds), nil
+
+	})
+
+}
+
+// Backtest ...
+func (monitor *TollCost) Backtest(replay *softswitches.Replay, from time.Time, to time.Time) ([]*BacktestAlarm, error) {
+
+	monitor.Softswitch = replay
+
+	return backtest(replay, from, to, monitor.Config.ExecuteInterval, "TollCost", monitor.SoftswitchName, monitor.Config.GroupBy, func() (map[string]*softswitches.Hits, error) {
+
+		hits, err := monitor.getHits(nil, monitor.matches, monitor.Config.ConsiderCDRsFromLast, true, monitor.Config.GroupBy)
+		if err != nil {
+			return nil, err
+		}
+
+		return monitor.costAbove(hits, monitor.Config.AlarmBudget), nil
+
+	})
+
+}
+
+// Backtest ...
+func (monitor *CallVelocity) Backtest(replay *softswitches.Replay, from time.Time, to time.Time) ([]*BacktestAlarm, error) {
+
+	monitor.Softswitch = replay
+
+	return backtest(replay, from, to, monitor.Config.ExecuteInterval, "CallVelocity", monitor.SoftswitchName, monitor.Config.GroupBy, func() (map[string]*softswitches.Hits, error) {
+		return monitor.bursts(make([]*softswitches.HitsWindow, len(monitor.Config.Windows)))
+	})
+
+}
+
+// backtest Moves "replay" along from "from" to "to", one "executeInterval" at a time, and checks with "aboveThreshold" at each of those
+// ticks, like a monitor's Run does, the first tick is one "executeInterval" after "from"
+func backtest(replay *softswitches.Replay, from time.Time, to time.Time, executeInterval time.Duration, monitorName string, softswitchName string, groupBy []string, aboveThreshold func() (map[string]*softswitches.Hits, error)) ([]*BacktestAlarm, error) {
+
+	log := marlog.MarLog
+
+	log.LogS("INFO", "Backtesting Monitor "+monitorName+" on Softswitch \""+softswitchName+"\" from "+from.String()+" to "+to.String()+"...")
+
+	var alarms []*BacktestAlarm
+
+	for tickTime := from.Add(executeInterval); !tickTime.After(to); tickTime = tickTime.Add(executeInterval) {
+
+		replay.Now = tickTime
+
+		hits, err := aboveThreshold()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(hits) > 0 {
+			alarms = append(alarms, &BacktestAlarm{Time: tickTime, Monitor: monitorName, Softswitch: softswitchName, Hits: hits, GroupBy: groupBy})
+		}
+
+	}
+
+	return alarms, nil
+
+}
@@ -0,0 +1,182 @@
+package monitors
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andmar/fraudion/config"
+	"github.com/andmar/fraudion/softswitches"
+	"github.com/andmar/fraudion/utils"
+
+	"github.com/andmar/marlog"
+)
+
+// Run ...
+func (monitor *CallVelocity) Run() {
+
+	log := marlog.MarLog
+
+	log.LogS("INFO", "Started Monitor CallVelocity on Softswitch \""+monitor.SoftswitchName+"\"!")
+
+	lengths := make([]time.Duration, len(monitor.Config.Windows))
+	for index, window := range monitor.Config.Windows {
+		lengths[index] = window.Length
+	}
+	hitsWindows := newHitsWindows(monitor.Config.Incremental, lengths...)
+
+	for tickTime := range ticks(monitor.Softswitch, monitor.Config.ExecuteInterval, monitor.Config.EventDriven, softswitches.CallEventCDR) {
+
+		log.LogS("INFO", "Monitor CallVelocity on Softswitch \""+monitor.SoftswitchName+"\" ticked at "+tickTime.String())
+
+		bursts, err := monitor.bursts(hitsWindows)
+		if err != nil {
+			log.LogS("ERROR: ", err.Error())
+		} else {
+
+			// NOTE: This block has to be here because we reset the value of monitor.State.RunMode below, this catches state changes
+			skipNonRecurrentActions := false
+			if monitor.State.RunMode != RunModeNormal {
+				skipNonRecurrentActions = true
+			}
+
+			// NOTE: Resets RunMode in each Tick so that the System can detect when it's out of an alarm situation
+			monitor.State.RunMode = RunModeNormal
+
+			if len(bursts) > 0 {
+				monitor.State.RunMode = RunModeInAlarm
+			}
+
+			runModeString := ""
+			switch monitor.State.RunMode {
+			case RunModeInAlarm:
+				runModeString = "Alarm"
+				log.LogS("DEBUG", "System is in Alarm")
+			default:
+				runModeString = "Normal"
+				log.LogS("DEBUG", "System detected nothing. :)")
+			}
+
+			log.LogS("INFO", "RunMode after call attempts check is "+runModeString)
+
+			if monitor.State.RunMode != RunModeNormal {
+
+				log.LogS("INFO", "Will execute action chain...")
+
+				runActionChain(monitor, skipNonRecurrentActions, bursts)
+
+			}
+
+		}
+
+	}
+
+}
+
+// bursts Returns the Hits, by group, of the groups that made more call attempts than allowed in any of the windows, those of the shortest
+// window they did it in, which is kept in State.BurstWindows. "hitsWindows" are the HitsWindows of the windows in incremental mode (nil
+// ones otherwise)
+func (monitor *CallVelocity) bursts(hitsWindows []*softswitches.HitsWindow) (map[string]*softswitches.Hits, error) {
+
+	log := marlog.MarLog
+
+	result := make(map[string]*softswitches.Hits)
+	monitor.State.BurstWindows = make(map[string]config.CallVelocityWindow)
+
+	// NOTE: Not in incremental mode the CDRs of the longest window are gone through once and counted in every window they are in, instead of
+	// querying the Softswitch once per window
+	var hitsInWindows []map[string]*softswitches.Hits
+	if len(hitsWindows) > 0 && hitsWindows[0] == nil {
+
+		windows := make([]time.Duration, len(monitor.Config.Windows))
+		for index, window := range monitor.Config.Windows {
+			windows[index] = window.Length
+		}
+
+		log.LogS("DEBUG", "Querying Softswitch for the call attempts in the past \""+windows[len(windows)-1].String()+"\" for all windows...")
+
+		var err error
+		hitsInWindows, err = softswitches.GetHitsInWindows(monitor.Softswitch, monitor.matches, windows, false, monitor.Config.GroupBy)
+		if err != nil {
+			return nil, err
+		}
+
+	}
+
+	for index, window := range monitor.Config.Windows {
+
+		var hits map[string]*softswitches.Hits
+		if hitsInWindows != nil {
+
+			hits = hitsInWindows[index]
+			for _, hit := range hits {
+				hit.Softswitch = monitor.SoftswitchName
+			}
+
+		} else {
+
+			log.LogS("DEBUG", "Querying Softswitch for the call attempts from the past \""+window.Length.String()+"\"...")
+
+			var err error
+			hits, err = monitor.getHits(hitsWindows[index], monitor.matches, window.Length, false, monitor.Config.GroupBy)
+			if err != nil {
+				return nil, err
+			}
+
+		}
+
+		log.LogS("INFO", "Checking if some call attempts in the past \""+window.Length.String()+"\" are above threshold \""+strconv.Itoa(int(window.HitThreshold))+"\"")
+
+		for group, hits := range hitsAboveThreshold(hits, window.HitThreshold, nil) {
+
+			if _, found := result[group]; found {
+				continue
+			}
+
+			result[group] = hits
+			monitor.State.BurstWindows[group] = window
+
+		}
+
+	}
+
+	return result, nil
+
+}
+
+// matches Returns the E.164 prefix (see utils.FindE164Range), if it has one, of "destination", any number at least MinimumNumberLength long
+// is a call attempt whether it was answered or not. "args" (the BillSec of the call) is not looked at for that reason, it's only there
+// because the Softswitch calls every monitor's "matches" the same way (see monitorBase.getHits)
+func (monitor *CallVelocity) matches(destination string, args ...uint32) (string, bool, error) {
+
+	if uint32(len(destination)) < monitor.Config.MinimumNumberLength {
+		return "", false, nil
+	}
+
+	if e164Range, found := utils.FindE164Range(destination); found {
+		return e164Range.Prefix, true, nil
+	}
+
+	return "", true, nil
+
+}
+
+// describeBursts Returns, one per line, how many call attempts each group in "hits" made in the window it was found in and to where
+func (monitor *CallVelocity) describeBursts(hits map[string]*softswitches.Hits) string {
+
+	var groups []string
+	for group := range hits {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	result := ""
+	for _, group := range groups {
+		window := monitor.State.BurstWindows[group]
+		result = result + DescribeGroup(hits[group], monitor.Config.GroupBy) + ": " + strconv.Itoa(int(hits[group].NumberOfHits)) + " call attempts in the past " + window.Length.String() + " (threshold " + strconv.Itoa(int(window.HitThreshold)) + ") to " + strings.Join(hits[group].DescribeDestinations(), ", ") + "\n"
+	}
+
+	return result
+
+}
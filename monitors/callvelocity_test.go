@@ -0,0 +1,133 @@
+package monitors
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andmar/fraudion/config"
+	"github.com/andmar/fraudion/softswitches"
+)
+
+// recordingSoftswitch Has the CDRs in cdrs and remembers from when it was asked for them on each GetCDRs
+type recordingSoftswitch struct {
+	cdrs  []*softswitches.CDR
+	asked []time.Time
+}
+
+func (softswitch *recordingSoftswitch) GetCDRsSource() softswitches.CDRsSource {
+	return nil
+}
+
+func (softswitch *recordingSoftswitch) GetCDRs(since time.Time) (softswitches.CDRsIterator, error) {
+
+	softswitch.asked = append(softswitch.asked, since)
+
+	cdrs := softswitches.NewCDRsSourceMemory(24 * time.Hour)
+	cdrs.Add(softswitch.cdrs...)
+
+	return cdrs.GetCDRs(since)
+
+}
+
+func (softswitch *recordingSoftswitch) GetHits(matches func(string, ...uint32) (string, bool, error), considerCDRsFromLast time.Duration, considerCallDuration bool, groupBy softswitches.GroupBy) (map[string]*softswitches.Hits, error) {
+
+	hits, err := softswitches.GetHitsInWindows(softswitch, matches, []time.Duration{considerCDRsFromLast}, considerCallDuration, groupBy)
+	if err != nil {
+		return nil, err
+	}
+
+	return hits[0], nil
+
+}
+
+func (softswitch *recordingSoftswitch) GetCurrentActiveCalls(minimumNumberLength uint32) (uint32, error) {
+	return 0, nil
+}
+
+// call Adds a CDR of a call from "src" to "dst" made "ago" that was billed "billSec" seconds
+func (softswitch *recordingSoftswitch) call(ago time.Duration, src string, dst string, billSec uint32) {
+	softswitch.cdrs = append(softswitch.cdrs, &softswitches.CDR{CallDate: time.Now().Add(-ago), Src: src, Dst: dst, DialedNumbers: []string{dst}, BillSec: billSec})
+}
+
+func TestCallVelocityBursts(t *testing.T) {
+
+	softswitch := new(recordingSoftswitch)
+
+	// NOTE: Above the thresholds of both windows, it's the shortest that's reported, most weren't answered
+	for index := 0; index < 5; index++ {
+		softswitch.call(time.Duration(10+index)*time.Second, "1000", "00244123456789", uint32(index/3*60))
+	}
+
+	// NOTE: Above the threshold of the longest window only
+	for index := 0; index < 5; index++ {
+		softswitch.call(time.Duration(2+index)*time.Minute, "2000", "00351212345678", 60)
+	}
+
+	// NOTE: None of them were answered, they are call attempts all the same
+	for index := 0; index < 3; index++ {
+		softswitch.call(time.Duration(20+index)*time.Second, "3000", "8816123456", 0)
+	}
+
+	// NOTE: Below both thresholds, and numbers that are too short are not call attempts
+	softswitch.call(30*time.Second, "4000", "00244123456789", 0)
+	softswitch.call(40*time.Second, "4000", "00244123456789", 0)
+	for index := 0; index < 5; index++ {
+		softswitch.call(time.Duration(50+index)*time.Second, "4000", "1001", 0)
+	}
+
+	monitorConfig := new(config.MonitorCallVelocity)
+	monitorConfig.Windows = []config.CallVelocityWindow{{Length: time.Minute, HitThreshold: 2}, {Length: 10 * time.Minute, HitThreshold: 4}}
+	monitorConfig.GroupBy = []string{"*src"}
+	monitorConfig.MinimumNumberLength = 5
+
+	monitor := &CallVelocity{Config: monitorConfig}
+	monitor.Softswitch = softswitch
+	monitor.SoftswitchName = "pbx"
+
+	expected := map[string]struct {
+		numberOfHits uint32
+		window       time.Duration
+	}{
+		"1000": {5, time.Minute},
+		"2000": {5, 10 * time.Minute},
+		"3000": {3, time.Minute},
+	}
+
+	for _, incremental := range []bool{false, true} {
+
+		softswitch.asked = nil
+
+		bursts, err := monitor.bursts(newHitsWindows(incremental, time.Minute, 10*time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// NOTE: Not in incremental mode the CDRs are gone through once for all windows
+		if !incremental && len(softswitch.asked) != 1 {
+			t.Errorf("expected the CDRs to be asked for once, they were asked for %d times", len(softswitch.asked))
+		}
+
+		if len(bursts) != len(expected) {
+			t.Fatalf("incremental %v: expected %d groups, got %d", incremental, len(expected), len(bursts))
+		}
+
+		for group, test := range expected {
+
+			hits, found := bursts[group]
+			if !found {
+				t.Fatalf("incremental %v: expected \"%s\" to have made too many call attempts", incremental, group)
+			}
+
+			if hits.NumberOfHits != test.numberOfHits || hits.Softswitch != "pbx" {
+				t.Errorf("incremental %v: expected %d call attempts of \"%s\" on \"pbx\", got %d on \"%s\"", incremental, test.numberOfHits, group, hits.NumberOfHits, hits.Softswitch)
+			}
+
+			if window := monitor.State.BurstWindows[group]; window.Length != test.window {
+				t.Errorf("incremental %v: expected \"%s\" to be reported with the window of %s, got %s", incremental, group, test.window, window.Length)
+			}
+
+		}
+
+	}
+
+}
@@ -5,7 +5,8 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
-	"time"
+
+	"github.com/andmar/fraudion/softswitches"
 
 	"github.com/andmar/marlog"
 )
@@ -15,52 +16,17 @@ func (monitor *DangerousDestinations) Run() {
 
 	log := marlog.MarLog
 
-	log.LogS("INFO", "Started Monitor DangerousDestinations!")
-
-	matches := func(destination string, args ...uint32) (string, bool, error) {
-		if uint32(len(destination)) >= monitor.Config.MinimumNumberLength {
-			for _, prefix := range monitor.Config.PrefixList {
-
-				matchStringWithTag := monitor.Config.MatchRegex
-				matchString := strings.Replace(matchStringWithTag, "__prefix__", prefix, 1)
-
-				foundMatch, err := regexp.MatchString(matchString, destination)
-				if err != nil {
-					log.LogS("ERROR", "an error  ("+err.Error()+") ocurred while trying to match a Prefix with regexp")
-					return "", false, err
-				}
-
-				matchStringWithTag = monitor.Config.IgnoreRegex
-				matchString = strings.Replace(matchStringWithTag, "__prefix__", prefix, 1)
-
-				foundIgnore, err := regexp.MatchString(matchString, destination)
-				if err != nil {
-					log.LogS("ERROR", "an error ("+err.Error()+") ocurrerd while trying to match (to ignore) a Prefix with regexp")
-					return "", false, err
-				}
-
-				if foundMatch == true && foundIgnore == false {
-					return prefix, true, nil
-				}
-
-				return "", false, nil
-
-			}
-		}
-
-		return "", false, nil
+	log.LogS("INFO", "Started Monitor DangerousDestinations on Softswitch \""+monitor.SoftswitchName+"\"!")
 
-	}
-
-	log.LogS("DEBUG", "Setting up time Ticker with interval \""+monitor.Config.ExecuteInterval.String()+"\"")
+	hitsWindow := newHitsWindows(monitor.Config.Incremental, monitor.Config.ConsiderCDRsFromLast)[0]
 
-	for tickTime := range time.NewTicker(monitor.Config.ExecuteInterval).C {
+	for tickTime := range ticks(monitor.Softswitch, monitor.Config.ExecuteInterval, monitor.Config.EventDriven, softswitches.CallEventCDR) {
 
-		log.LogS("INFO", "Monitor DangerousDestinations ticked at "+tickTime.String())
+		log.LogS("INFO", "Monitor DangerousDestinations on Softswitch \""+monitor.SoftswitchName+"\" ticked at "+tickTime.String())
 
 		log.LogS("DEBUG", "Querying Softswitch for Hits (matches in CDRs) from the past \""+monitor.Config.ConsiderCDRsFromLast.String()+"\"...")
 
-		hits, err := monitor.Softswitch.GetHits(matches, monitor.Config.ConsiderCDRsFromLast, false)
+		hits, err := monitor.getHits(hitsWindow, monitor.matches, monitor.Config.ConsiderCDRsFromLast, false, monitor.Config.GroupBy)
 		if err != nil {
 			log.LogS("ERROR: ", err.Error())
 		} else {
@@ -76,14 +42,8 @@ func (monitor *DangerousDestinations) Run() {
 
 			log.LogS("INFO", "Checking if some Hits are above threshold \""+strconv.Itoa(int(monitor.Config.HitThreshold))+"\"")
 
-			for _, v := range hits {
-
-				if v.NumberOfHits > monitor.Config.HitThreshold {
-					log.LogS("DEBUG", "Hits above threshold (first detected) \""+strconv.Itoa(int(monitor.Config.HitThreshold))+"\" on prefix "+v.Prefix+" found: "+fmt.Sprintf("%v", v.Destinations)+"!!")
-					monitor.State.RunMode = RunModeInAlarm
-					break
-				}
-
+			if len(monitor.aboveThreshold(hits)) > 0 {
+				monitor.State.RunMode = RunModeInAlarm
 			}
 
 			runModeString := ""
@@ -112,3 +72,68 @@ func (monitor *DangerousDestinations) Run() {
 	}
 
 }
+
+// aboveThreshold Returns the Hits above their threshold and the ones with calls to NumberRanges, one of those is already one too many
+func (monitor *DangerousDestinations) aboveThreshold(hits map[string]*softswitches.Hits) map[string]*softswitches.Hits {
+
+	log := marlog.MarLog
+
+	result := hitsAboveThreshold(hits, monitor.Config.HitThreshold, monitor.Config.GroupThresholds)
+
+	for group, v := range hits {
+		for _, destination := range v.Destinations {
+			if numberRange, found := lookupNumberRange(destination); found && numberRange.Risk >= monitor.Config.NumberRangesMinimumRisk {
+				log.LogS("DEBUG", "Hits on number range "+numberRange.Prefix+" (\""+numberRange.Label+"\") found on group "+group+": "+fmt.Sprintf("%v", v.Destinations)+"!!")
+				result[group] = v
+				break
+			}
+		}
+	}
+
+	return result
+
+}
+
+// matches Returns the prefix of the NumberRange "destination" is in, if its risk is at least NumberRangesMinimumRisk, or the prefix of
+// the first of PrefixList if "destination" matches MatchRegex and not IgnoreRegex with it in place of "__prefix__"
+func (monitor *DangerousDestinations) matches(destination string, args ...uint32) (string, bool, error) {
+
+	log := marlog.MarLog
+
+	if numberRange, found := lookupNumberRange(destination); found && numberRange.Risk >= monitor.Config.NumberRangesMinimumRisk {
+		return numberRange.Prefix, true, nil
+	}
+
+	if uint32(len(destination)) >= monitor.Config.MinimumNumberLength {
+		for _, prefix := range monitor.Config.PrefixList {
+
+			matchStringWithTag := monitor.Config.MatchRegex
+			matchString := strings.Replace(matchStringWithTag, "__prefix__", prefix, 1)
+
+			foundMatch, err := regexp.MatchString(matchString, destination)
+			if err != nil {
+				log.LogS("ERROR", "an error  ("+err.Error()+") ocurred while trying to match a Prefix with regexp")
+				return "", false, err
+			}
+
+			matchStringWithTag = monitor.Config.IgnoreRegex
+			matchString = strings.Replace(matchStringWithTag, "__prefix__", prefix, 1)
+
+			foundIgnore, err := regexp.MatchString(matchString, destination)
+			if err != nil {
+				log.LogS("ERROR", "an error ("+err.Error()+") ocurrerd while trying to match (to ignore) a Prefix with regexp")
+				return "", false, err
+			}
+
+			if foundMatch == true && foundIgnore == false {
+				return prefix, true, nil
+			}
+
+			return "", false, nil
+
+		}
+	}
+
+	return "", false, nil
+
+}
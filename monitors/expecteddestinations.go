@@ -1,12 +1,11 @@
 package monitors
 
 import (
-	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
-	"time"
 
+	"github.com/andmar/fraudion/softswitches"
 	"github.com/andmar/fraudion/utils"
 
 	"github.com/andmar/marlog"
@@ -17,58 +16,17 @@ func (monitor *ExpectedDestinations) Run() {
 
 	log := marlog.MarLog
 
-	log.LogS("INFO", "Started Monitor ExpectedDestinations!")
+	log.LogS("INFO", "Started Monitor ExpectedDestinations on Softswitch \""+monitor.SoftswitchName+"\"!")
 
-	matches := func(destination string, args ...uint32) (string, bool, error) {
-		if uint32(len(destination)) >= monitor.Config.MinimumNumberLength {
-			for _, prefix := range monitor.Config.PrefixList {
+	hitsWindow := newHitsWindows(monitor.Config.Incremental, monitor.Config.ConsiderCDRsFromLast)[0]
 
-				matchStringWithTag := monitor.Config.MatchRegex
-				matchString := strings.Replace(matchStringWithTag, "__prefix__", prefix, 1)
+	for tickTime := range ticks(monitor.Softswitch, monitor.Config.ExecuteInterval, monitor.Config.EventDriven, softswitches.CallEventCDR) {
 
-				foundMatch, err := regexp.MatchString(matchString, destination)
-				if err != nil {
-					log.LogS("ERROR", "an error  ("+err.Error()+") ocurred while trying to match a Prefix with regexp")
-					return "", false, err
-				}
-
-				matchStringWithTag = monitor.Config.IgnoreRegex
-				matchString = strings.Replace(matchStringWithTag, "__prefix__", prefix, 1)
-
-				foundIgnore, err := regexp.MatchString(matchString, destination)
-				if err != nil {
-					log.LogS("ERROR", "an error ("+err.Error()+") ocurrerd while trying to match (to ignore) a Prefix with regexp")
-					return "", false, err
-				}
-
-				if foundMatch == false && foundIgnore == false {
-
-					if hasPrefix, prefix := utils.FindIntlPrefix(destination); hasPrefix {
-						return prefix, true, nil
-					}
-
-					return destination, true, nil
-
-				}
-
-				return "", false, nil
-
-			}
-		}
-
-		return "", false, nil
-
-	}
-
-	log.LogS("DEBUG", "Setting up time Ticker with interval \""+monitor.Config.ExecuteInterval.String()+"\"")
-
-	for tickTime := range time.NewTicker(monitor.Config.ExecuteInterval).C {
-
-		log.LogS("INFO", "Monitor DangerousDestinations ticked at "+tickTime.String())
+		log.LogS("INFO", "Monitor ExpectedDestinations on Softswitch \""+monitor.SoftswitchName+"\" ticked at "+tickTime.String())
 
 		log.LogS("DEBUG", "Querying Softswitch for Hits (matches in CDRs) from the past \""+monitor.Config.ConsiderCDRsFromLast.String()+"\"...")
 
-		hits, err := monitor.Softswitch.GetHits(matches, monitor.Config.ConsiderCDRsFromLast, false)
+		hits, err := monitor.getHits(hitsWindow, monitor.matches, monitor.Config.ConsiderCDRsFromLast, false, monitor.Config.GroupBy)
 		if err != nil {
 			log.LogS("ERROR", err.Error())
 		} else {
@@ -84,14 +42,8 @@ func (monitor *ExpectedDestinations) Run() {
 
 			log.LogS("INFO", "Checking if some Hits are above threshold \""+strconv.Itoa(int(monitor.Config.HitThreshold))+"\"")
 
-			for _, v := range hits {
-
-				if v.NumberOfHits > monitor.Config.HitThreshold {
-					log.LogS("DEBUG", "Hits above threshold (first detected) \""+strconv.Itoa(int(monitor.Config.HitThreshold))+"\" on prefix "+v.Prefix+" found: "+fmt.Sprintf("%v", v.Destinations)+"!!")
-					monitor.State.RunMode = RunModeInAlarm
-					break
-				}
-
+			if len(hitsAboveThreshold(hits, monitor.Config.HitThreshold, monitor.Config.GroupThresholds)) > 0 {
+				monitor.State.RunMode = RunModeInAlarm
 			}
 
 			runModeString := ""
@@ -120,3 +72,49 @@ func (monitor *ExpectedDestinations) Run() {
 	}
 
 }
+
+// matches Returns the E.164 prefix (see utils.FindE164Range) of "destination" (or the whole number if it has none) if it matches neither MatchRegex nor
+// IgnoreRegex with the first of PrefixList in place of "__prefix__"
+func (monitor *ExpectedDestinations) matches(destination string, args ...uint32) (string, bool, error) {
+
+	log := marlog.MarLog
+
+	if uint32(len(destination)) >= monitor.Config.MinimumNumberLength {
+		for _, prefix := range monitor.Config.PrefixList {
+
+			matchStringWithTag := monitor.Config.MatchRegex
+			matchString := strings.Replace(matchStringWithTag, "__prefix__", prefix, 1)
+
+			foundMatch, err := regexp.MatchString(matchString, destination)
+			if err != nil {
+				log.LogS("ERROR", "an error  ("+err.Error()+") ocurred while trying to match a Prefix with regexp")
+				return "", false, err
+			}
+
+			matchStringWithTag = monitor.Config.IgnoreRegex
+			matchString = strings.Replace(matchStringWithTag, "__prefix__", prefix, 1)
+
+			foundIgnore, err := regexp.MatchString(matchString, destination)
+			if err != nil {
+				log.LogS("ERROR", "an error ("+err.Error()+") ocurrerd while trying to match (to ignore) a Prefix with regexp")
+				return "", false, err
+			}
+
+			if foundMatch == false && foundIgnore == false {
+
+				if e164Range, found := utils.FindE164Range(destination); found {
+					return e164Range.Prefix, true, nil
+				}
+
+				return destination, true, nil
+
+			}
+
+			return "", false, nil
+
+		}
+	}
+
+	return "", false, nil
+
+}
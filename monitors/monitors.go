@@ -2,6 +2,8 @@ package monitors
 
 import (
 	"fmt"
+	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -11,6 +13,7 @@ import (
 
 	"github.com/andmar/fraudion/config"
 	"github.com/andmar/fraudion/softswitches"
+	"github.com/andmar/fraudion/utils"
 	"github.com/andmar/marlog"
 
 	"github.com/SlyMarbo/gmail"
@@ -29,14 +32,74 @@ const (
 	ActionLocalCommands = "*local_commands"
 )
 
+// NumberRanges The number ranges known to be used for fraud (e.g. IRSF), nil if there's none configured
+var NumberRanges *utils.NumberRanges
+
+// lookupNumberRange ...
+func lookupNumberRange(number string) (*utils.NumberRange, bool) {
+
+	if NumberRanges == nil {
+		return nil, false
+	}
+
+	return NumberRanges.Lookup(number)
+
+}
+
+// DescribePrefix Returns "prefix" with its country (see utils.DescribeE164Prefix) and, if it's one of NumberRanges, its label and risk
+// level (e.g. "37259 (Estonia EE, Europe) [IRSF range, risk high]")
+func DescribePrefix(prefix string) string {
+
+	description := utils.DescribeE164Prefix(prefix)
+
+	if numberRange, found := lookupNumberRange(prefix); found && numberRange.Prefix == prefix {
+		description = description + " [" + numberRange.Label + ", risk " + utils.RiskString(numberRange.Risk) + "]"
+	}
+
+	return description
+
+}
+
+// DescribeGroup Returns the group of "hits" as the value of each of its fields (see softswitches.GroupBy), the prefix described by
+// DescribePrefix (e.g. "src 1000, prefix 244 (Angola AO, Africa)")
+func DescribeGroup(hits *softswitches.Hits, groupBy []string) string {
+
+	if len(groupBy) == 0 {
+		groupBy = []string{softswitches.GroupByPrefix}
+	}
+
+	var fields []string
+	for _, field := range groupBy {
+		value := hits.GroupValues[field]
+		switch field {
+		case softswitches.GroupByPrefix:
+			value = DescribePrefix(value)
+		case softswitches.GroupBySoftswitch:
+			value = "\"" + hits.Softswitch + "\""
+		}
+		fields = append(fields, strings.TrimPrefix(field, "*")+" "+value)
+	}
+
+	return strings.Join(fields, ", ")
+
+}
+
 // Monitor ...
 type Monitor interface {
 	Run()
 }
 
+// actionChainMonitor A Monitor that runs an action chain (see runActionChain) when it finds something on the Softswitch it monitors
+type actionChainMonitor interface {
+	Monitor
+	// NOTE: Returns the name of the action chain and the name of the Softswitch
+	actionChainNames() (string, string)
+}
+
 // monitorBase ...
 type monitorBase struct {
-	Softswitch softswitches.Softswitch
+	Softswitch     softswitches.Softswitch
+	SoftswitchName string
 }
 
 // DangerousDestinations ...
@@ -67,6 +130,45 @@ type SmallDurationCalls struct {
 	State  StateSmallDurationCalls
 }
 
+// TollCost ...
+type TollCost struct {
+	monitorBase
+	Config    *config.MonitorTollCost
+	State     StateTollCost
+	RateTable *utils.RateTable
+}
+
+// CallVelocity ...
+type CallVelocity struct {
+	monitorBase
+	Config *config.MonitorCallVelocity
+	State  StateCallVelocity
+}
+
+func (monitor *DangerousDestinations) actionChainNames() (string, string) {
+	return monitor.Config.ActionChainName, monitor.SoftswitchName
+}
+
+func (monitor *SimultaneousCalls) actionChainNames() (string, string) {
+	return monitor.Config.ActionChainName, monitor.SoftswitchName
+}
+
+func (monitor *ExpectedDestinations) actionChainNames() (string, string) {
+	return monitor.Config.ActionChainName, monitor.SoftswitchName
+}
+
+func (monitor *SmallDurationCalls) actionChainNames() (string, string) {
+	return monitor.Config.ActionChainName, monitor.SoftswitchName
+}
+
+func (monitor *TollCost) actionChainNames() (string, string) {
+	return monitor.Config.ActionChainName, monitor.SoftswitchName
+}
+
+func (monitor *CallVelocity) actionChainNames() (string, string) {
+	return monitor.Config.ActionChainName, monitor.SoftswitchName
+}
+
 type stateBase struct {
 	LastActionChainRunTime time.Time
 	ActionChainRunCount    uint32
@@ -93,32 +195,145 @@ type StateSmallDurationCalls struct {
 	stateBase
 }
 
-var runActionChainmutex = &sync.Mutex{}
+// StateTollCost ...
+type StateTollCost struct {
+	stateBase
+	// NOTE: The prefixes (see TollCost.matches) of the destinations without a Rate that were already logged, each is logged only once
+	UnratedPrefixes map[string]bool
+}
 
-func runActionChain(monitor Monitor, skipNonRecurrentActions bool, data interface{}) error {
+// StateCallVelocity ...
+type StateCallVelocity struct {
+	stateBase
+	// NOTE: The window each group in alarm made too many call attempts in, by group
+	BurstWindows map[string]config.CallVelocityWindow
+}
 
-	runActionChainmutex.Lock()
+// ticks Returns a channel that receives the time every "executeInterval" and, if "eventDriven", also as soon as one of the CallEvents of
+// "callEventTypes" happens on "softswitch" (see softswitches.SubscribeCallEvents). Ticks that come while the monitor is still executing
+// are dropped, it's going to look at the latest state when it's done anyway
+func ticks(softswitch softswitches.Softswitch, executeInterval time.Duration, eventDriven bool, callEventTypes ...int) <-chan time.Time {
 
 	log := marlog.MarLog
 
-	// TODO: This solution is a little bit weird, seems wrong...
-	// NOTE: Only one will work...
-	monitorDangerousDestinations, okDD := monitor.(*DangerousDestinations)
-	monitorSimultaneousCalls, okSC := monitor.(*SimultaneousCalls)
-	monitorExpectedDestinations, okED := monitor.(*ExpectedDestinations)
-	if !okDD && !okSC && !okED {
-		return fmt.Errorf("unable to detect monitor that tried to run the action chain")
+	log.LogS("DEBUG", "Setting up time Ticker with interval \""+executeInterval.String()+"\"")
+
+	ticker := time.NewTicker(executeInterval)
+
+	var callEvents <-chan softswitches.CallEvent
+	if eventDriven {
+		callEvents = softswitches.SubscribeCallEvents(softswitch, callEventTypes...)
+		if callEvents == nil {
+			log.LogS("ERROR", "Event driven execution is enabled but the Softswitch has no call events (see \"live_calls_source\"), only ticking")
+		} else {
+			log.LogS("DEBUG", "Also ticking on call events")
+		}
 	}
 
-	var actionChainName string
+	result := make(chan time.Time, 1)
+
+	go func() {
 
-	if okDD {
-		actionChainName = monitorDangerousDestinations.Config.ActionChainName
-	} else if okSC {
-		actionChainName = monitorSimultaneousCalls.Config.ActionChainName
+		for {
+
+			var tickTime time.Time
+			select {
+			case tickTime = <-ticker.C:
+			case <-callEvents:
+				tickTime = time.Now()
+			}
+
+			select {
+			case result <- tickTime:
+			default:
+			}
+
+		}
+
+	}()
+
+	return result
+
+}
+
+// newHitsWindows Returns a HitsWindow for each of the window "lengths" if the monitor is "incremental", nils (the Hits are queried from the
+// Softswitch each time) otherwise
+func newHitsWindows(incremental bool, lengths ...time.Duration) []*softswitches.HitsWindow {
+
+	log := marlog.MarLog
+
+	hitsWindows := make([]*softswitches.HitsWindow, len(lengths))
+
+	// NOTE: In incremental mode only new CDRs are read on each tick and each window keeps its Hits up to date as CDRs enter/leave it
+	if incremental {
+		log.LogS("DEBUG", "Incremental mode is enabled")
+		for index, length := range lengths {
+			hitsWindows[index] = softswitches.NewHitsWindow(length)
+		}
+	}
+
+	return hitsWindows
+
+}
+
+// getHits Gets the Hits from the Softswitch, from "hitsWindow" if the monitor is incremental, with the name of the Softswitch on them so
+// that whoever gets them knows where they come from
+func (monitor *monitorBase) getHits(hitsWindow *softswitches.HitsWindow, matches func(string, ...uint32) (string, bool, error), considerCDRsFromLast time.Duration, considerCallDuration bool, groupBy []string) (map[string]*softswitches.Hits, error) {
+
+	var hits map[string]*softswitches.Hits
+	var err error
+	if hitsWindow != nil {
+		hits, err = hitsWindow.Update(monitor.Softswitch, matches, considerCallDuration, groupBy)
 	} else {
-		actionChainName = monitorExpectedDestinations.Config.ActionChainName
+		hits, err = monitor.Softswitch.GetHits(matches, considerCDRsFromLast, considerCallDuration, groupBy)
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hit := range hits {
+		hit.Softswitch = monitor.SoftswitchName
+	}
+
+	return hits, nil
+
+}
+
+// hitsAboveThreshold Returns the Hits, by group, that are above "hitThreshold" or, for the groups in "groupThresholds", above theirs
+func hitsAboveThreshold(hits map[string]*softswitches.Hits, hitThreshold uint32, groupThresholds map[string]uint32) map[string]*softswitches.Hits {
+
+	log := marlog.MarLog
+
+	result := make(map[string]*softswitches.Hits)
+
+	for group, v := range hits {
+
+		threshold := hitThreshold
+		if groupThreshold, found := groupThresholds[group]; found {
+			threshold = groupThreshold
+		}
+
+		if v.NumberOfHits > threshold {
+			log.LogS("DEBUG", "Hits above threshold \""+strconv.Itoa(int(threshold))+"\" on group "+v.Group+" found: "+fmt.Sprintf("%v", v.Destinations)+"!!")
+			result[group] = v
+		}
+
+	}
+
+	return result
+
+}
+
+var runActionChainmutex = &sync.Mutex{}
+
+func runActionChain(monitor actionChainMonitor, skipNonRecurrentActions bool, data interface{}) error {
+
+	runActionChainmutex.Lock()
+	defer runActionChainmutex.Unlock()
+
+	log := marlog.MarLog
+
+	actionChainName, softswitchName := monitor.actionChainNames()
 
 	log.LogS("DEBUG", "ActionChain to execute has name \""+actionChainName+"\"")
 
@@ -145,10 +360,11 @@ func runActionChain(monitor Monitor, skipNonRecurrentActions bool, data interfac
 
 					log.LogS("INFO", "Executing e-mail action...")
 
-					subject := "ALERT @ " + config.Loaded.General.Hostname + ": "
+					subject := "ALERT @ " + config.Loaded.General.Hostname + " (" + softswitchName + "): "
 					body := ""
 
-					if okDD {
+					switch monitor := monitor.(type) {
+					case *DangerousDestinations:
 
 						dataAsserted, ok := data.(map[string]*softswitches.Hits)
 						if !ok {
@@ -156,17 +372,16 @@ func runActionChain(monitor Monitor, skipNonRecurrentActions bool, data interfac
 						} else {
 
 							prefixes := ""
-							for key := range dataAsserted {
-								prefixes = prefixes + key + ", "
+							for _, hits := range dataAsserted {
+								prefixes = prefixes + DescribeGroup(hits, monitor.Config.GroupBy) + ": " + strings.Join(hits.DescribeDestinations(), ", ") + "\n"
 							}
-							prefixes = strings.TrimSuffix(prefixes, ", ")
 
 							subject = subject + "Dangerous Destinations!"
-							body = "Suspicious calls to:\n\n" + prefixes
+							body = "Suspicious calls on \"" + softswitchName + "\" to:\n\n" + prefixes
 
 						}
 
-					} else if okSC {
+					case *SimultaneousCalls:
 
 						dataAsserted, ok := data.(uint32)
 						if !ok {
@@ -174,11 +389,43 @@ func runActionChain(monitor Monitor, skipNonRecurrentActions bool, data interfac
 						} else {
 
 							subject = subject + "Simultaneous Calls"
-							body = "Currently active calls:\n\n" + strconv.Itoa(int(dataAsserted))
+							body = "Currently active calls on \"" + softswitchName + "\":\n\n" + strconv.Itoa(int(dataAsserted))
 
 						}
 
-					} else {
+					case *TollCost:
+
+						dataAsserted, ok := data.(map[string]*softswitches.Hits)
+						if !ok {
+							log.LogS("ERROR", "could not convert data to e-mail action usable object")
+						} else {
+
+							budget := monitor.Config.AlarmBudget
+							level := "Alarm!"
+							if monitor.State.RunMode == RunModeInWarning {
+								budget = monitor.Config.WarningBudget
+								level = "Warning"
+							}
+
+							subject = subject + "Toll Cost " + level
+
+							body = "Estimated spend on \"" + softswitchName + "\" above " + formatCost(budget) + " " + monitor.RateTable.Currency + ":\n\n" + monitor.describeSpend(dataAsserted)
+
+						}
+
+					case *CallVelocity:
+
+						dataAsserted, ok := data.(map[string]*softswitches.Hits)
+						if !ok {
+							log.LogS("ERROR", "could not convert data to e-mail action usable object")
+						} else {
+
+							subject = subject + "Call Velocity!"
+							body = "Bursts of call attempts on \"" + softswitchName + "\":\n\n" + monitor.describeBursts(dataAsserted)
+
+						}
+
+					case *SmallDurationCalls:
 
 						dataAsserted, ok := data.(map[string]*softswitches.Hits)
 						if !ok {
@@ -186,13 +433,29 @@ func runActionChain(monitor Monitor, skipNonRecurrentActions bool, data interfac
 						} else {
 
 							prefixes := ""
-							for key := range dataAsserted {
-								prefixes = prefixes + key + ", "
+							for _, hits := range dataAsserted {
+								prefixes = prefixes + DescribeGroup(hits, monitor.Config.GroupBy) + ": " + strings.Join(hits.DescribeDestinations(), ", ") + "\n"
+							}
+
+							subject = subject + "Small Duration Calls!"
+							body = "Suspicious short calls on \"" + softswitchName + "\" to:\n\n" + prefixes
+
+						}
+
+					case *ExpectedDestinations:
+
+						dataAsserted, ok := data.(map[string]*softswitches.Hits)
+						if !ok {
+							log.LogS("ERROR", "could not convert data to e-mail action usable object")
+						} else {
+
+							prefixes := ""
+							for _, hits := range dataAsserted {
+								prefixes = prefixes + DescribeGroup(hits, monitor.Config.GroupBy) + ": " + strings.Join(hits.DescribeDestinations(), ", ") + "\n"
 							}
-							prefixes = strings.TrimSuffix(prefixes, ", ")
 
 							subject = subject + "Expected Destinations!"
-							body = "Suspicious calls to:\n\n" + prefixes
+							body = "Suspicious calls on \"" + softswitchName + "\" to:\n\n" + prefixes
 
 						}
 
@@ -235,6 +498,17 @@ func runActionChain(monitor Monitor, skipNonRecurrentActions bool, data interfac
 						log.LogS("DEBUG", "Executing: "+dataGroups[dataGroupName].CommandName+" with arguments: "+dataGroups[dataGroupName].CommandArguments)
 
 						command := exec.Command(dataGroups[dataGroupName].CommandName, dataGroups[dataGroupName].CommandArguments)
+						// NOTE: So that the command can act on what is compromised (e.g. block an extension), the keys of the groups in alarm are
+						// in FRAUDION_GROUPS, one per line
+						command.Env = append(os.Environ(), "FRAUDION_SOFTSWITCH="+softswitchName)
+						if dataAsserted, ok := data.(map[string]*softswitches.Hits); ok {
+							var groups []string
+							for group := range dataAsserted {
+								groups = append(groups, group)
+							}
+							sort.Strings(groups)
+							command.Env = append(command.Env, "FRAUDION_GROUPS="+strings.Join(groups, "\n"))
+						}
 
 						err := command.Run()
 						if err != nil {
@@ -254,7 +528,5 @@ func runActionChain(monitor Monitor, skipNonRecurrentActions bool, data interfac
 
 	}
 
-	runActionChainmutex.Unlock()
-
 	return nil
 }
@@ -2,7 +2,8 @@ package monitors
 
 import (
 	"strconv"
-	"time"
+
+	"github.com/andmar/fraudion/softswitches"
 
 	"github.com/andmar/marlog"
 )
@@ -12,13 +13,11 @@ func (monitor *SimultaneousCalls) Run() {
 
 	log := marlog.MarLog
 
-	log.LogS("INFO", "Started Monitor SimultaneousCalls!")
-
-	log.LogS("DEBUG", "Setting up time Ticker with interval \""+monitor.Config.ExecuteInterval.String()+"\"")
+	log.LogS("INFO", "Started Monitor SimultaneousCalls on Softswitch \""+monitor.SoftswitchName+"\"!")
 
-	for tickTime := range time.NewTicker(monitor.Config.ExecuteInterval).C {
+	for tickTime := range ticks(monitor.Softswitch, monitor.Config.ExecuteInterval, monitor.Config.EventDriven, softswitches.CallEventDialed, softswitches.CallEventEnded) {
 
-		log.LogS("INFO", "Monitor SimultaneousCalls ticked at "+tickTime.String())
+		log.LogS("INFO", "Monitor SimultaneousCalls on Softswitch \""+monitor.SoftswitchName+"\" ticked at "+tickTime.String())
 
 		log.LogS("DEBUG", "Querying Softswitch for Current Active Calls...")
 
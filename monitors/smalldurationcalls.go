@@ -1,12 +1,12 @@
 package monitors
 
 import (
-	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/andmar/fraudion/softswitches"
 	"github.com/andmar/fraudion/utils"
 
 	"github.com/andmar/marlog"
@@ -17,63 +17,17 @@ func (monitor *SmallDurationCalls) Run() {
 
 	log := marlog.MarLog
 
-	log.LogS("INFO", "Started Monitor SmallDurationCalls!")
+	log.LogS("INFO", "Started Monitor SmallDurationCalls on Softswitch \""+monitor.SoftswitchName+"\"!")
 
-	matches := func(destination string, args ...uint32) (string, bool, error) {
+	hitsWindow := newHitsWindows(monitor.Config.Incremental, monitor.Config.ConsiderCDRsFromLast)[0]
 
-		if uint32(len(destination)) >= monitor.Config.MinimumNumberLength {
+	for tickTime := range ticks(monitor.Softswitch, monitor.Config.ExecuteInterval, monitor.Config.EventDriven, softswitches.CallEventCDR) {
 
-			hasPrefix, prefix := utils.FindIntlPrefix(destination)
-
-			if !hasPrefix {
-				return "", false, nil
-			}
-
-			matchStringWithTag := monitor.Config.MatchRegex
-			matchString := strings.Replace(matchStringWithTag, "__prefix__", prefix, 1)
-
-			foundMatch, err := regexp.MatchString(matchString, destination)
-			if err != nil {
-				log.LogS("ERROR", "an error  ("+err.Error()+") ocurred while trying to match a Prefix with regexp")
-				return "", false, err
-			}
-
-			matchStringWithTag = monitor.Config.IgnoreRegex
-			matchString = strings.Replace(matchStringWithTag, "__prefix__", prefix, 1)
-
-			foundIgnore, err := regexp.MatchString(matchString, destination)
-			if err != nil {
-				log.LogS("ERROR", "an error ("+err.Error()+") ocurrerd while trying to match (to ignore) a Prefix with regexp")
-				return "", false, err
-			}
-
-			callDuration, err := time.ParseDuration(strconv.FormatInt(int64(args[0]), 10) + "s")
-			if err != nil {
-				log.LogS("ERROR", "an error ("+err.Error()+") ocurrerd while trying to parse a duration")
-				return "", false, err
-			}
-
-			if foundMatch == true && callDuration.Seconds() < monitor.Config.DurationThreshold.Seconds() && foundIgnore == false {
-				return prefix, true, nil
-			}
-
-			return "", false, nil
-
-		}
-
-		return "", false, nil
-
-	}
-
-	log.LogS("DEBUG", "Setting up time Ticker with interval \""+monitor.Config.ExecuteInterval.String()+"\"")
-
-	for tickTime := range time.NewTicker(monitor.Config.ExecuteInterval).C {
-
-		log.LogS("INFO", "Monitor SmallDurationCalls ticked at "+tickTime.String())
+		log.LogS("INFO", "Monitor SmallDurationCalls on Softswitch \""+monitor.SoftswitchName+"\" ticked at "+tickTime.String())
 
 		log.LogS("DEBUG", "Querying Softswitch for Hits (matches in CDRs) from the past \""+monitor.Config.ConsiderCDRsFromLast.String()+"\"...")
 
-		hits, err := monitor.Softswitch.GetHits(matches, monitor.Config.ConsiderCDRsFromLast, true)
+		hits, err := monitor.getHits(hitsWindow, monitor.matches, monitor.Config.ConsiderCDRsFromLast, true, monitor.Config.GroupBy)
 		if err != nil {
 			log.LogS("ERROR: ", err.Error())
 		} else {
@@ -89,14 +43,8 @@ func (monitor *SmallDurationCalls) Run() {
 
 			log.LogS("INFO", "Checking if some Hits are above threshold \""+strconv.Itoa(int(monitor.Config.HitThreshold))+"\"")
 
-			for _, v := range hits {
-
-				if v.NumberOfHits > monitor.Config.HitThreshold {
-					log.LogS("DEBUG", "Hits above threshold (first detected) \""+strconv.Itoa(int(monitor.Config.HitThreshold))+"\" on prefix "+v.Prefix+" found: "+fmt.Sprintf("%v", v.Destinations)+"!!")
-					monitor.State.RunMode = RunModeInAlarm
-					break
-				}
-
+			if len(hitsAboveThreshold(hits, monitor.Config.HitThreshold, monitor.Config.GroupThresholds)) > 0 {
+				monitor.State.RunMode = RunModeInAlarm
 			}
 
 			runModeString := ""
@@ -125,3 +73,55 @@ func (monitor *SmallDurationCalls) Run() {
 	}
 
 }
+
+// matches Returns the E.164 prefix (see utils.FindE164Range) of "destination" if it matches MatchRegex and not IgnoreRegex with it in place of
+// "__prefix__" and the call lasted (args[0], its BillSec) less than DurationThreshold
+func (monitor *SmallDurationCalls) matches(destination string, args ...uint32) (string, bool, error) {
+
+	log := marlog.MarLog
+
+	if uint32(len(destination)) >= monitor.Config.MinimumNumberLength {
+
+		e164Range, found := utils.FindE164Range(destination)
+
+		if !found {
+			return "", false, nil
+		}
+
+		prefix := e164Range.Prefix
+
+		matchStringWithTag := monitor.Config.MatchRegex
+		matchString := strings.Replace(matchStringWithTag, "__prefix__", prefix, 1)
+
+		foundMatch, err := regexp.MatchString(matchString, destination)
+		if err != nil {
+			log.LogS("ERROR", "an error  ("+err.Error()+") ocurred while trying to match a Prefix with regexp")
+			return "", false, err
+		}
+
+		matchStringWithTag = monitor.Config.IgnoreRegex
+		matchString = strings.Replace(matchStringWithTag, "__prefix__", prefix, 1)
+
+		foundIgnore, err := regexp.MatchString(matchString, destination)
+		if err != nil {
+			log.LogS("ERROR", "an error ("+err.Error()+") ocurrerd while trying to match (to ignore) a Prefix with regexp")
+			return "", false, err
+		}
+
+		callDuration, err := time.ParseDuration(strconv.FormatInt(int64(args[0]), 10) + "s")
+		if err != nil {
+			log.LogS("ERROR", "an error ("+err.Error()+") ocurrerd while trying to parse a duration")
+			return "", false, err
+		}
+
+		if foundMatch == true && callDuration.Seconds() < monitor.Config.DurationThreshold.Seconds() && foundIgnore == false {
+			return prefix, true, nil
+		}
+
+		return "", false, nil
+
+	}
+
+	return "", false, nil
+
+}
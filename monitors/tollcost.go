@@ -0,0 +1,188 @@
+package monitors
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/andmar/fraudion/softswitches"
+	"github.com/andmar/fraudion/utils"
+
+	"github.com/andmar/marlog"
+)
+
+// Run ...
+func (monitor *TollCost) Run() {
+
+	log := marlog.MarLog
+
+	log.LogS("INFO", "Started Monitor TollCost on Softswitch \""+monitor.SoftswitchName+"\"!")
+
+	hitsWindow := newHitsWindows(monitor.Config.Incremental, monitor.Config.ConsiderCDRsFromLast)[0]
+
+	for tickTime := range ticks(monitor.Softswitch, monitor.Config.ExecuteInterval, monitor.Config.EventDriven, softswitches.CallEventCDR) {
+
+		log.LogS("INFO", "Monitor TollCost on Softswitch \""+monitor.SoftswitchName+"\" ticked at "+tickTime.String())
+
+		log.LogS("DEBUG", "Querying Softswitch for the calls from the past \""+monitor.Config.ConsiderCDRsFromLast.String()+"\" that have a rate...")
+
+		hits, err := monitor.getHits(hitsWindow, monitor.matches, monitor.Config.ConsiderCDRsFromLast, true, monitor.Config.GroupBy)
+		if err != nil {
+			log.LogS("ERROR: ", err.Error())
+		} else {
+
+			previousRunMode := monitor.State.RunMode
+
+			log.LogS("INFO", "Checking if some spend is above the budgets (warning \""+formatCost(monitor.Config.WarningBudget)+"\", alarm \""+formatCost(monitor.Config.AlarmBudget)+"\") in "+monitor.RateTable.Currency)
+
+			overBudget, runMode := monitor.overBudget(hits)
+			monitor.State.RunMode = runMode
+
+			// NOTE: Unlike the other monitors, going from warning to alarm is news, non recurrent actions are only skipped while the
+			// RunMode is the same
+			skipNonRecurrentActions := previousRunMode == monitor.State.RunMode
+
+			runModeString := ""
+			switch monitor.State.RunMode {
+			case RunModeInWarning:
+				runModeString = "Warning"
+				log.LogS("DEBUG", "System is in Warning")
+			case RunModeInAlarm:
+				runModeString = "Alarm"
+				log.LogS("DEBUG", "System is in Alarm")
+			default:
+				runModeString = "Normal"
+				log.LogS("DEBUG", "System detected nothing. :)")
+			}
+
+			log.LogS("INFO", "RunMode after spend check is "+runModeString)
+
+			if monitor.State.RunMode != RunModeNormal {
+
+				log.LogS("INFO", "Will execute action chain...")
+
+				runActionChain(monitor, skipNonRecurrentActions, overBudget)
+
+			}
+
+		}
+
+	}
+
+}
+
+// matches Returns the prefix of the Rate of "destination", calls to numbers without one and calls that were not answered cost nothing that
+// Fraudion knows of. Destinations without a Rate are logged, once per E.164 prefix (see utils.FindE164Range), since they are usually
+// numbers in national format on a Softswitch without a "numbering_plan" rather than numbers that are free to call
+func (monitor *TollCost) matches(destination string, args ...uint32) (string, bool, error) {
+
+	log := marlog.MarLog
+
+	if uint32(len(destination)) < monitor.Config.MinimumNumberLength {
+		return "", false, nil
+	}
+
+	if len(args) > 0 && args[0] == 0 {
+		return "", false, nil
+	}
+
+	rate, found := monitor.RateTable.Lookup(destination)
+	if !found {
+
+		unratedPrefix := destination
+		if e164Range, found := utils.FindE164Range(destination); found {
+			unratedPrefix = e164Range.Prefix
+		}
+
+		if monitor.State.UnratedPrefixes == nil {
+			monitor.State.UnratedPrefixes = make(map[string]bool)
+		}
+
+		if monitor.State.UnratedPrefixes[unratedPrefix] == false {
+			monitor.State.UnratedPrefixes[unratedPrefix] = true
+			log.LogS("WARNING", "There's no rate for \""+destination+"\" (prefix \""+unratedPrefix+"\") on Softswitch \""+monitor.SoftswitchName+"\", calls to it cost nothing, if it's not in international format check the Softswitch's \"numbering_plan\"")
+		}
+
+		return "", false, nil
+
+	}
+
+	return rate.Prefix, true, nil
+
+}
+
+// Cost Returns the estimated cost of the calls in "hits", in the currency of the RateTable
+func (monitor *TollCost) Cost(hits *softswitches.Hits) float64 {
+
+	cost := 0.0
+
+	for index, destination := range hits.Destinations {
+		if rate, found := monitor.RateTable.Lookup(destination); found && index < len(hits.BillSecs) {
+			cost += rate.Cost(hits.BillSecs[index])
+		}
+	}
+
+	return cost
+
+}
+
+// costAbove Returns the Hits, by group, that cost more than "budget"
+func (monitor *TollCost) costAbove(hits map[string]*softswitches.Hits, budget float64) map[string]*softswitches.Hits {
+
+	log := marlog.MarLog
+
+	result := make(map[string]*softswitches.Hits)
+
+	for group, v := range hits {
+
+		if cost := monitor.Cost(v); cost > budget {
+			log.LogS("DEBUG", "Spend of "+formatCost(cost)+" "+monitor.RateTable.Currency+" above budget \""+formatCost(budget)+"\" on group "+v.Group+" found!!")
+			result[group] = v
+		}
+
+	}
+
+	return result
+
+}
+
+// overBudget Returns the Hits, by group, above the alarm budget and RunModeInAlarm or, if there are none, the ones above the warning budget
+// and RunModeInWarning, RunModeNormal if there are none of either
+func (monitor *TollCost) overBudget(hits map[string]*softswitches.Hits) (map[string]*softswitches.Hits, int) {
+
+	if overBudget := monitor.costAbove(hits, monitor.Config.AlarmBudget); len(overBudget) > 0 {
+		return overBudget, RunModeInAlarm
+	}
+
+	// NOTE: A "warning_budget" of 0 is no warning
+	if monitor.Config.WarningBudget > 0 {
+		if overBudget := monitor.costAbove(hits, monitor.Config.WarningBudget); len(overBudget) > 0 {
+			return overBudget, RunModeInWarning
+		}
+	}
+
+	return make(map[string]*softswitches.Hits), RunModeNormal
+
+}
+
+// describeSpend Returns, one per line, the spend of each group in "hits" and the calls that made it
+func (monitor *TollCost) describeSpend(hits map[string]*softswitches.Hits) string {
+
+	var groups []string
+	for group := range hits {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	result := ""
+	for _, group := range groups {
+		result = result + DescribeGroup(hits[group], monitor.Config.GroupBy) + ": " + formatCost(monitor.Cost(hits[group])) + " " + monitor.RateTable.Currency + " in " + strconv.Itoa(int(hits[group].NumberOfHits)) + " calls\n"
+	}
+
+	return result
+
+}
+
+// formatCost ...
+func formatCost(cost float64) string {
+	return strconv.FormatFloat(cost, 'f', 2, 64)
+}
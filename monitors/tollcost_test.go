@@ -0,0 +1,147 @@
+package monitors
+
+import (
+	"math"
+	"os"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/andmar/fraudion/config"
+	"github.com/andmar/fraudion/softswitches"
+	"github.com/andmar/fraudion/utils"
+)
+
+// newTollCost Returns a TollCost with the rates in "rates", a rate table as in utils.LoadRateTable
+func newTollCost(t *testing.T, rates string, warningBudget float64, alarmBudget float64) *TollCost {
+
+	file, err := ioutil.TempFile("", "fraudion")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove(file.Name())
+
+	file.WriteString(rates)
+	file.Close()
+
+	rateTable, err := utils.LoadRateTable(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	monitor := &TollCost{Config: &config.MonitorTollCost{WarningBudget: warningBudget, AlarmBudget: alarmBudget, GroupBy: []string{"*softswitch"}}, RateTable: rateTable}
+	monitor.SoftswitchName = "pbx"
+
+	return monitor
+
+}
+
+func TestTollCostMatches(t *testing.T) {
+
+	monitor := newTollCost(t, "881,6.00,0.50,EUR\n351,0.01,0,EUR\n3519,0.06,0,EUR\n", 10, 20)
+	monitor.Config.MinimumNumberLength = 5
+
+	tests := []struct {
+		destination string
+		billSec     uint32
+		prefix      string
+	}{
+		{"351212345678", 60, "351"},
+		{"351912345678", 60, "3519"},
+		{"8816123456", 1, "881"},
+		// NOTE: Not answered, it cost nothing
+		{"8816123456", 0, ""},
+		{"3519", 60, ""},
+		{"244123456789", 60, ""},
+	}
+
+	for _, test := range tests {
+		prefix, found, err := monitor.matches(test.destination, test.billSec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found != (test.prefix != "") || prefix != test.prefix {
+			t.Errorf("%s (%ds): expected the prefix \"%s\", got \"%s\"", test.destination, test.billSec, test.prefix, prefix)
+		}
+	}
+
+}
+
+func TestTollCostUnratedPrefixes(t *testing.T) {
+
+	monitor := newTollCost(t, "351,0.01,0,EUR\n", 10, 20)
+
+	// NOTE: Each is logged once, by its E.164 prefix or, if it has none, as it is
+	for _, destination := range []string{"244123456789", "244987654321", "99912345", "244123456789"} {
+		if _, found, _ := monitor.matches(destination, 60); found {
+			t.Fatalf("%s: expected no rate", destination)
+		}
+	}
+
+	if len(monitor.State.UnratedPrefixes) != 2 || !monitor.State.UnratedPrefixes["244"] || !monitor.State.UnratedPrefixes["99912345"] {
+		t.Fatalf("expected \"244\" and \"99912345\" to have been logged, got %v", monitor.State.UnratedPrefixes)
+	}
+
+	// NOTE: Unanswered calls cost nothing either way, they are not logged
+	monitor.matches("8816123456", 0)
+	if monitor.State.UnratedPrefixes["881"] {
+		t.Error("expected unanswered calls not to be logged")
+	}
+
+}
+
+func TestTollCostBudgets(t *testing.T) {
+
+	monitor := newTollCost(t, "881,6.00,0.50,EUR\n351,0.01,0,EUR\n", 10, 20)
+
+	hits := func(destinations []string, billSecs ...uint32) *softswitches.Hits {
+		return &softswitches.Hits{Softswitch: "pbx", Destinations: destinations, BillSecs: billSecs, NumberOfHits: uint32(len(destinations))}
+	}
+
+	// NOTE: 0.50 + 6.00 * 90 / 60 and 0.01 * 600 / 60, no rate costs nothing
+	satellite := hits([]string{"8816123456", "351212345678", "244123456789"}, 90, 600, 600)
+	if cost := monitor.Cost(satellite); math.Abs(cost-9.6) > 1e-9 {
+		t.Fatalf("expected 9.60, got %f", cost)
+	}
+
+	tests := []struct {
+		hits     *softswitches.Hits
+		expected int
+	}{
+		{satellite, RunModeNormal},
+		{hits([]string{"8816123456", "8816123456"}, 90, 30), RunModeInWarning},
+		{hits([]string{"8816123456", "8816123456"}, 90, 120), RunModeInAlarm},
+		// NOTE: Unanswered, the connection fee is not charged
+		{hits([]string{"8816123456", "8816123456", "8816123456"}, 0, 0, 90), RunModeNormal},
+	}
+
+	for index, test := range tests {
+
+		overBudget, runMode := monitor.overBudget(map[string]*softswitches.Hits{"pbx": test.hits})
+		if runMode != test.expected {
+			t.Errorf("%d: expected the run mode %d, got %d (%s)", index, test.expected, runMode, formatCost(monitor.Cost(test.hits)))
+		}
+
+		if (runMode == RunModeNormal) != (len(overBudget) == 0) {
+			t.Errorf("%d: expected the group to be over budget only if it's not normal, got %v", index, overBudget)
+		}
+
+	}
+
+	// NOTE: A "warning_budget" of 0 is no warning
+	monitor.Config.WarningBudget = 0
+	if _, runMode := monitor.overBudget(map[string]*softswitches.Hits{"pbx": tests[1].hits}); runMode != RunModeNormal {
+		t.Errorf("expected no warning, got the run mode %d", runMode)
+	}
+
+	// NOTE: Only the groups above the budget
+	above := monitor.costAbove(map[string]*softswitches.Hits{"a": tests[2].hits, "b": satellite}, 5)
+	if len(above) != 2 {
+		t.Fatalf("expected both groups to be above 5, got %d", len(above))
+	}
+	if above = monitor.costAbove(map[string]*softswitches.Hits{"a": tests[2].hits, "b": satellite}, 10); len(above) != 1 || above["a"] == nil {
+		t.Fatalf("expected only \"a\" to be above 10, got %v", above)
+	}
+
+}
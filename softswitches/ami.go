@@ -0,0 +1,466 @@
+package softswitches
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andmar/marlog"
+)
+
+const (
+	// AMIDefaultPort ...
+	AMIDefaultPort = 5038
+	// AMIDefaultTimeout ...
+	AMIDefaultTimeout = 10 * time.Second
+)
+
+const (
+	amiBannerPrefix = "Asterisk Call Manager"
+)
+
+// AMIMessage An Asterisk Manager Interface packet (action, response or event), the "Key: Value" lines sent up to an empty line
+type AMIMessage map[string]string
+
+// Get Returns the value of "key" ignoring case since, depending on the Asterisk version, the same key is sent as e.g. "Uniqueid" or "UniqueID"
+func (message AMIMessage) Get(key string) string {
+
+	if value, found := message[key]; found {
+		return value
+	}
+
+	for messageKey, value := range message {
+		if strings.EqualFold(messageKey, key) {
+			return value
+		}
+	}
+
+	return ""
+
+}
+
+// AMIClient Talks to Asterisk over the Asterisk Manager Interface, it connects and logs in when an Action is sent and, if the connection is
+// lost, reconnects on the next one but only after waiting a backoff that doubles on each failed attempt (up to MaximumBackoff) so that an
+// Asterisk that is down is not hammered every time a monitor executes
+type AMIClient struct {
+	Host           string
+	Port           uint32
+	UserName       string
+	Secret         string
+	Timeout        time.Duration
+	MaximumBackoff time.Duration
+	// NOTE: net.DialTimeout if nil, it's here so that the client can be pointed at something that is not a real Asterisk (e.g. a fake AMI server)
+	Dial func(network string, address string, timeout time.Duration) (net.Conn, error)
+
+	mutex      sync.Mutex
+	connection net.Conn
+	reader     *bufio.Reader
+	actionID   uint64
+	backoff    reconnectBackoff
+}
+
+// Action Sends "action" and returns Asterisk's response to it and, if the response says an event list follows (e.g. CoreShowChannels),
+// the events in that list
+func (client *AMIClient) Action(action AMIMessage) (AMIMessage, []AMIMessage, error) {
+
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	if client.connection == nil {
+		// NOTE: Events are turned off, only the responses to our Actions (and the event lists that come with them) are wanted
+		if err := client.connect("off"); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	response, events, err := client.action(action)
+	if err != nil {
+		// NOTE: If something went wrong in the middle of a conversation there's no way of knowing where the next message starts
+		if _, isAMIError := err.(*AMIError); !isAMIError {
+			client.disconnect()
+		}
+		return nil, nil, err
+	}
+
+	return response, events, nil
+
+}
+
+// Listen Logs in asking for the "events" (e.g. "call,cdr") classes, sends "actions" without waiting for their responses and then passes
+// every event received to "handle" until the connection is lost, which is when it returns. Events from event lists that come with the
+// responses to "actions" are passed to "handle" like the others. If connecting failed before, it waits for the backoff instead of
+// returning. A client that Listens must not be used for anything else
+func (client *AMIClient) Listen(events string, actions []AMIMessage, handle func(event AMIMessage)) error {
+
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	time.Sleep(client.backoff.wait())
+
+	if client.connection == nil {
+		if err := client.connect(events); err != nil {
+			return err
+		}
+	}
+
+	defer client.disconnect()
+
+	client.connection.SetDeadline(time.Now().Add(client.getTimeout()))
+
+	for _, action := range actions {
+		if err := client.writeMessage(client.newAction(action)); err != nil {
+			return err
+		}
+	}
+
+	// NOTE: There may be no events for a long time (e.g. at night), that doesn't mean the connection is gone
+	client.connection.SetDeadline(time.Time{})
+
+	for {
+
+		received, err := client.readMessage()
+		if err != nil {
+			// NOTE: Reconnecting right away to an Asterisk that keeps dropping us would be a busy loop
+			client.backoff.failed(client.MaximumBackoff)
+			return err
+		}
+
+		if received.Get("Event") == "" {
+			continue
+		}
+
+		handle(received)
+
+	}
+
+}
+
+// CoreShowChannels Returns the channels currently up in Asterisk
+func (client *AMIClient) CoreShowChannels() ([]*ActiveChannel, error) {
+
+	_, events, err := client.Action(AMIMessage{"Action": "CoreShowChannels"})
+	if err != nil {
+		return nil, err
+	}
+
+	var channels []*ActiveChannel
+	for _, event := range events {
+		if event.Get("Event") != "CoreShowChannel" {
+			continue
+		}
+		channels = append(channels, newActiveChannelFromAMIEvent(event))
+	}
+
+	return channels, nil
+
+}
+
+// Close ...
+func (client *AMIClient) Close() error {
+
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	if client.connection == nil {
+		return nil
+	}
+
+	// NOTE: Logging off is just being polite, the connection is closed anyway
+	client.connection.SetDeadline(time.Now().Add(client.getTimeout()))
+	client.writeMessage(AMIMessage{"Action": "Logoff"})
+	client.disconnect()
+
+	return nil
+
+}
+
+// AMIError Asterisk answered an Action with "Response: Error", unlike other errors the connection is still good after this
+type AMIError struct {
+	Action  string
+	Message string
+}
+
+func (err *AMIError) Error() string {
+	return "AMI action \"" + err.Action + "\" failed (" + err.Message + ")"
+}
+
+func (client *AMIClient) connect(events string) error {
+
+	log := marlog.MarLog
+
+	if wait := client.backoff.wait(); wait > 0 {
+		return fmt.Errorf("could not connect to AMI, not trying again for %s", wait.String())
+	}
+
+	if err := client.login(events); err != nil {
+
+		client.disconnect()
+
+		wait := client.backoff.failed(client.MaximumBackoff)
+
+		log.LogS("ERROR", "Could not connect to AMI at \""+client.getAddress()+"\", attempt "+strconv.Itoa(int(client.backoff.failures))+", next one in "+wait.String())
+
+		return err
+
+	}
+
+	client.backoff.succeeded()
+
+	log.LogS("DEBUG", "Connected to AMI at \""+client.getAddress()+"\"")
+
+	return nil
+
+}
+
+func (client *AMIClient) login(events string) error {
+
+	dial := client.Dial
+	if dial == nil {
+		dial = net.DialTimeout
+	}
+
+	connection, err := dial("tcp", client.getAddress(), client.getTimeout())
+	if err != nil {
+		return fmt.Errorf("could not connect to AMI (" + err.Error() + ")")
+	}
+
+	client.connection = connection
+	client.reader = bufio.NewReader(connection)
+
+	client.connection.SetDeadline(time.Now().Add(client.getTimeout()))
+
+	banner, err := client.reader.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("could not read the AMI banner (" + err.Error() + ")")
+	}
+
+	if !strings.HasPrefix(banner, amiBannerPrefix) {
+		return fmt.Errorf("unexpected AMI banner \"%s\"", strings.TrimSpace(banner))
+	}
+
+	if _, _, err := client.action(AMIMessage{"Action": "Login", "Username": client.UserName, "Secret": client.Secret, "Events": events}); err != nil {
+		return fmt.Errorf("could not log in to AMI (" + err.Error() + ")")
+	}
+
+	return nil
+
+}
+
+func (client *AMIClient) disconnect() {
+
+	if client.connection != nil {
+		client.connection.Close()
+	}
+
+	client.connection = nil
+	client.reader = nil
+
+}
+
+func (client *AMIClient) action(action AMIMessage) (AMIMessage, []AMIMessage, error) {
+
+	message := client.newAction(action)
+	actionID := message["ActionID"]
+
+	client.connection.SetDeadline(time.Now().Add(client.getTimeout()))
+
+	if err := client.writeMessage(message); err != nil {
+		return nil, nil, err
+	}
+
+	var response AMIMessage
+	for response == nil {
+
+		received, err := client.readMessage()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// NOTE: Anything else (e.g. an event that was on its way before "Events: off") is not for us
+		if received.Get("Response") != "" && received.Get("ActionID") == actionID {
+			response = received
+		}
+
+	}
+
+	if strings.EqualFold(response.Get("Response"), "Error") {
+		return nil, nil, &AMIError{Action: action["Action"], Message: response.Get("Message")}
+	}
+
+	if !strings.EqualFold(response.Get("EventList"), "start") {
+		return response, nil, nil
+	}
+
+	var events []AMIMessage
+	for {
+
+		received, err := client.readMessage()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if received.Get("ActionID") != actionID {
+			continue
+		}
+
+		if strings.EqualFold(received.Get("EventList"), "Complete") {
+			break
+		}
+
+		events = append(events, received)
+
+	}
+
+	return response, events, nil
+
+}
+
+// newAction Copies "action" adding a new ActionID, which is how responses and event lists are told apart from everything else
+func (client *AMIClient) newAction(action AMIMessage) AMIMessage {
+
+	client.actionID++
+
+	message := make(AMIMessage, len(action)+1)
+	for key, value := range action {
+		message[key] = value
+	}
+	message["ActionID"] = strconv.FormatUint(client.actionID, 10)
+
+	return message
+
+}
+
+func (client *AMIClient) writeMessage(message AMIMessage) error {
+
+	// NOTE: "Action" has to be the first line, the order of the others doesn't matter but it's nice to have it always the same
+	keys := make([]string, 0, len(message))
+	for key := range message {
+		if key != "Action" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	packet := "Action: " + message["Action"] + "\r\n"
+	for _, key := range keys {
+		packet += key + ": " + message[key] + "\r\n"
+	}
+	packet += "\r\n"
+
+	if _, err := io.WriteString(client.connection, packet); err != nil {
+		return fmt.Errorf("could not write to AMI (" + err.Error() + ")")
+	}
+
+	return nil
+
+}
+
+func (client *AMIClient) readMessage() (AMIMessage, error) {
+
+	message := make(AMIMessage)
+
+	for {
+
+		line, err := client.reader.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("could not read from AMI (" + err.Error() + ")")
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			// NOTE: Empty lines between messages are not a message
+			if len(message) == 0 {
+				continue
+			}
+			return message, nil
+		}
+
+		// NOTE: Lines without a key (e.g. the output of "Response: Follows") are ignored
+		separatorIndex := strings.Index(line, ":")
+		if separatorIndex == -1 {
+			continue
+		}
+
+		message[line[:separatorIndex]] = strings.TrimSpace(line[separatorIndex+1:])
+
+	}
+
+}
+
+func (client *AMIClient) getAddress() string {
+
+	host := client.Host
+	if host == "" {
+		host = "localhost"
+	}
+
+	port := client.Port
+	if port == 0 {
+		port = AMIDefaultPort
+	}
+
+	return net.JoinHostPort(host, strconv.FormatUint(uint64(port), 10))
+
+}
+
+func (client *AMIClient) getTimeout() time.Duration {
+
+	if client.Timeout > 0 {
+		return client.Timeout
+	}
+
+	return AMIDefaultTimeout
+
+}
+
+func newActiveChannelFromAMIEvent(event AMIMessage) *ActiveChannel {
+
+	channel := new(ActiveChannel)
+	channel.Channel = event.Get("Channel")
+	channel.Context = event.Get("Context")
+	channel.Extension = event.Get("Exten")
+	channel.State = event.Get("ChannelStateDesc")
+	channel.Application = event.Get("Application")
+	channel.Data = event.Get("ApplicationData")
+	channel.CallerID = event.Get("CallerIDNum")
+	channel.AccountCode = event.Get("AccountCode")
+	channel.Duration = parseAMIDuration(event.Get("Duration"))
+	channel.UniqueID = event.Get("Uniqueid")
+
+	// NOTE: Up to Asterisk 11 the peer channel is there, from 13 on channels are in bridges and only the bridge is
+	channel.BridgedTo = event.Get("BridgedChannel")
+	if channel.BridgedTo == "" {
+		channel.BridgedTo = event.Get("BridgeId")
+	}
+
+	return channel
+
+}
+
+// parseAMIDuration Durations in CoreShowChannel events are "HH:MM:SS"
+func parseAMIDuration(value string) time.Duration {
+
+	parts := strings.Split(value, ":")
+	if len(parts) != 3 {
+		return 0
+	}
+
+	var duration time.Duration
+	for index, unit := range []time.Duration{time.Hour, time.Minute, time.Second} {
+		amount, err := strconv.Atoi(parts[index])
+		if err != nil {
+			return 0
+		}
+		duration += time.Duration(amount) * unit
+	}
+
+	return duration
+
+}
@@ -0,0 +1,347 @@
+package softswitches
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeAMI Speaks enough of the Asterisk Manager Interface for AMIClient: a banner, Login, CoreShowChannels (answered with the events in
+// channels) and Logoff
+type fakeAMI struct {
+	listener net.Listener
+	secret   string
+	channels []string
+	// NOTE: When set, what's sent to it is written, as it comes, after the CoreShowChannels list (as the events AMIClient.Listen gets), an
+	// empty one drops the connection
+	events chan string
+	// NOTE: When above 0, connections are dropped (without an answer) on the action after this many
+	dropAfter int
+
+	mutex  sync.Mutex
+	logins int
+}
+
+func newFakeAMI(t *testing.T, address string, secret string) *fakeAMI {
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &fakeAMI{listener: listener, secret: secret}
+	go server.serve()
+
+	return server
+
+}
+
+func (server *fakeAMI) serve() {
+
+	for {
+		connection, err := server.listener.Accept()
+		if err != nil {
+			return
+		}
+		go server.handle(connection)
+	}
+
+}
+
+func (server *fakeAMI) handle(connection net.Conn) {
+
+	defer connection.Close()
+
+	reader := bufio.NewReader(connection)
+	write := func(lines ...string) {
+		connection.Write([]byte(strings.Join(lines, "\r\n") + "\r\n\r\n"))
+	}
+
+	connection.Write([]byte("Asterisk Call Manager/2.10.3\r\n"))
+
+	for actions := 0; ; actions++ {
+
+		action := make(map[string]string)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			if line == "" {
+				break
+			}
+			if index := strings.Index(line, ": "); index != -1 {
+				action[line[:index]] = line[index+2:]
+			}
+		}
+
+		if server.dropAfter > 0 && actions > server.dropAfter {
+			return
+		}
+
+		actionID := "ActionID: " + action["ActionID"]
+
+		switch action["Action"] {
+		case "Login":
+			if action["Secret"] != server.secret {
+				write("Response: Error", actionID, "Message: Authentication failed")
+				return
+			}
+			server.mutex.Lock()
+			server.logins++
+			server.mutex.Unlock()
+			write("Response: Success", actionID, "Message: Authentication accepted")
+		case "CoreShowChannels":
+			// NOTE: Events that are not part of the list can come in the middle of it
+			write("Event: FullyBooted", "Privilege: system,all", "Status: Fully Booted")
+			write("Response: Success", actionID, "EventList: start", "Message: Channels will follow")
+			server.mutex.Lock()
+			channels := server.channels
+			server.mutex.Unlock()
+			for _, channel := range channels {
+				write("Event: CoreShowChannel", actionID, channel)
+			}
+			write("Event: CoreShowChannelsComplete", actionID, "EventList: Complete", "ListItems: "+strconv.Itoa(len(channels)))
+			if server.events != nil {
+				for event := range server.events {
+					if event == "" {
+						return
+					}
+					write(event)
+				}
+			}
+		case "Logoff":
+			write("Response: Goodbye", actionID, "Message: Thanks for all the fish.")
+			return
+		default:
+			write("Response: Error", actionID, "Message: Invalid/unknown command")
+		}
+
+	}
+
+}
+
+func (server *fakeAMI) address() (string, uint32) {
+
+	address := server.listener.Addr().(*net.TCPAddr)
+
+	return address.IP.String(), uint32(address.Port)
+
+}
+
+func (server *fakeAMI) setChannels(channels ...string) {
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.channels = channels
+
+}
+
+func (server *fakeAMI) getLogins() int {
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	return server.logins
+
+}
+
+func newTestAMIClient(server *fakeAMI, secret string) *AMIClient {
+
+	host, port := server.address()
+
+	return &AMIClient{Host: host, Port: port, UserName: "fraudion", Secret: secret, Timeout: time.Second, MaximumBackoff: 2 * time.Second}
+
+}
+
+func TestAMIClientLogin(t *testing.T) {
+
+	server := newFakeAMI(t, "127.0.0.1:0", "secret")
+	defer server.listener.Close()
+
+	client := newTestAMIClient(server, "secret")
+	defer client.Close()
+
+	if _, _, err := client.Action(AMIMessage{"Action": "CoreShowChannels"}); err != nil {
+		t.Fatalf("expected the action to succeed, got %v", err)
+	}
+
+	if server.getLogins() != 1 {
+		t.Fatalf("expected 1 login, got %d", server.getLogins())
+	}
+
+	// NOTE: The connection is kept for the next actions
+	if _, _, err := client.Action(AMIMessage{"Action": "CoreShowChannels"}); err != nil {
+		t.Fatalf("expected the action to succeed, got %v", err)
+	}
+
+	if server.getLogins() != 1 {
+		t.Fatalf("expected the connection to be reused, got %d logins", server.getLogins())
+	}
+
+	// NOTE: An error response doesn't lose the connection
+	_, _, err := client.Action(AMIMessage{"Action": "NoSuchAction"})
+	if _, isAMIError := err.(*AMIError); !isAMIError {
+		t.Fatalf("expected an AMIError, got %v", err)
+	}
+
+	if client.connection == nil {
+		t.Fatal("expected the connection to be kept after an error response")
+	}
+
+}
+
+func TestAMIClientLoginFailed(t *testing.T) {
+
+	server := newFakeAMI(t, "127.0.0.1:0", "secret")
+	defer server.listener.Close()
+
+	client := newTestAMIClient(server, "wrong")
+	defer client.Close()
+
+	_, _, err := client.Action(AMIMessage{"Action": "CoreShowChannels"})
+	if err == nil || !strings.Contains(err.Error(), "Authentication failed") {
+		t.Fatalf("expected the login to fail, got %v", err)
+	}
+
+	if client.connection != nil {
+		t.Fatal("expected no connection after a failed login")
+	}
+
+	// NOTE: No new attempt is made until the backoff is over
+	_, _, err = client.Action(AMIMessage{"Action": "CoreShowChannels"})
+	if err == nil || !strings.Contains(err.Error(), "not trying again") {
+		t.Fatalf("expected to be backing off, got %v", err)
+	}
+
+}
+
+func TestAMIClientCoreShowChannels(t *testing.T) {
+
+	server := newFakeAMI(t, "127.0.0.1:0", "secret")
+	defer server.listener.Close()
+
+	server.channels = []string{
+		"Channel: SIP/1000-00000001\r\nContext: from-internal\r\nExten: 00244123456789\r\nChannelStateDesc: Up\r\nApplication: Dial\r\nApplicationData: SIP/trunk/00244123456789,60\r\nCallerIDNum: 1000\r\nAccountCode: acme\r\nDuration: 00:01:05\r\nBridgeId: 0b3f7c2e\r\nUniqueid: 1469786400.1",
+		"Channel: SIP/trunk-00000002\r\nContext: from-trunk\r\nExten: \r\nChannelStateDesc: Up\r\nApplication: AppDial\r\nApplicationData: (Outgoing Line)\r\nCallerIDNum: 00244123456789\r\nDuration: 00:01:00\r\nBridgeId: 0b3f7c2e\r\nUniqueid: 1469786400.2",
+	}
+
+	client := newTestAMIClient(server, "secret")
+	defer client.Close()
+
+	channels, err := client.CoreShowChannels()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(channels) != 2 {
+		t.Fatalf("expected 2 channels, got %d", len(channels))
+	}
+
+	expected := ActiveChannel{
+		Channel:     "SIP/1000-00000001",
+		Context:     "from-internal",
+		Extension:   "00244123456789",
+		State:       "Up",
+		Application: "Dial",
+		Data:        "SIP/trunk/00244123456789,60",
+		CallerID:    "1000",
+		AccountCode: "acme",
+		Duration:    65 * time.Second,
+		BridgedTo:   "0b3f7c2e",
+		UniqueID:    "1469786400.1",
+	}
+	if *channels[0] != expected {
+		t.Fatalf("expected %+v, got %+v", expected, *channels[0])
+	}
+
+	// NOTE: Only the dialing channel is a live call, the other one is its outgoing leg
+	asterisk := &Asterisk{LiveCallsSource: &LiveCallsSourceAMI{Client: client}}
+
+	activeCalls, err := asterisk.GetCurrentActiveCalls(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if activeCalls != 1 {
+		t.Fatalf("expected 1 active call, got %d", activeCalls)
+	}
+
+}
+
+func TestAMIClientReconnect(t *testing.T) {
+
+	server := newFakeAMI(t, "127.0.0.1:0", "secret")
+	server.dropAfter = 1
+
+	client := newTestAMIClient(server, "secret")
+	defer client.Close()
+
+	if _, err := client.CoreShowChannels(); err != nil {
+		t.Fatal(err)
+	}
+
+	// NOTE: The server drops the connection, the client notices on the next action and logs in again on the one after it
+	if _, err := client.CoreShowChannels(); err == nil {
+		t.Fatal("expected an error when the connection is dropped")
+	}
+
+	if _, err := client.CoreShowChannels(); err != nil {
+		t.Fatalf("expected to reconnect, got %v", err)
+	}
+
+	if server.getLogins() != 2 {
+		t.Fatalf("expected 2 logins, got %d", server.getLogins())
+	}
+
+	// NOTE: While the server is down the client backs off instead of trying to connect on every action
+	address := server.listener.Addr().String()
+	server.listener.Close()
+	client.disconnect()
+
+	if _, err := client.CoreShowChannels(); err == nil {
+		t.Fatal("expected an error while the server is down")
+	}
+
+	if _, err := client.CoreShowChannels(); err == nil || !strings.Contains(err.Error(), "not trying again") {
+		t.Fatalf("expected to be backing off, got %v", err)
+	}
+
+	server = newFakeAMI(t, address, "secret")
+	defer server.listener.Close()
+
+	time.Sleep(client.backoff.wait())
+
+	if _, err := client.CoreShowChannels(); err != nil {
+		t.Fatalf("expected to reconnect after the backoff, got %v", err)
+	}
+
+	if client.backoff.failures != 0 {
+		t.Fatalf("expected the backoff to be reset, got %d failures", client.backoff.failures)
+	}
+
+}
+
+func TestReconnectBackoff(t *testing.T) {
+
+	backoff := reconnectBackoff{}
+
+	for _, expected := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if wait := backoff.failed(5 * time.Second); wait != expected {
+			t.Fatalf("expected to wait %s after %d failures, got %s", expected, backoff.failures, wait)
+		}
+	}
+
+	backoff.succeeded()
+
+	if wait := backoff.wait(); wait != 0 {
+		t.Fatalf("expected no wait after a success, got %s", wait)
+	}
+
+}
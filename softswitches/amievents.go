@@ -0,0 +1,239 @@
+package softswitches
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andmar/marlog"
+)
+
+const (
+	// NOTE: "call" has Newchannel, Newstate, DialBegin (Dial up to Asterisk 11) and Hangup, "cdr" has Cdr (needs cdr_manager enabled)
+	amiCallEvents = "call,cdr"
+)
+
+// LiveCallsSourceAMIEvents Keeps a table of the channels that are up, built from the events of a persistent AMI connection (see
+// AMIClient.Listen), so it's up to date without having to ask Asterisk and it can tell subscribers about calls as they happen (see
+// CallEventsSource). Each time it (re)connects the table is rebuilt from a CoreShowChannels
+type LiveCallsSourceAMIEvents struct {
+	Client *AMIClient
+	callEventsPublisher
+	mutex    sync.Mutex
+	channels map[string]*trackedChannel
+	synced   bool
+}
+
+type trackedChannel struct {
+	channel   *ActiveChannel
+	startedAt time.Time
+}
+
+// NewLiveCallsSourceAMIEvents ...
+func NewLiveCallsSourceAMIEvents(client *AMIClient) *LiveCallsSourceAMIEvents {
+
+	source := new(LiveCallsSourceAMIEvents)
+	source.Client = client
+	source.channels = make(map[string]*trackedChannel)
+
+	return source
+
+}
+
+// Run Listens to AMI events forever, reconnecting whenever the connection is lost
+func (source *LiveCallsSourceAMIEvents) Run() {
+
+	log := marlog.MarLog
+
+	for {
+
+		source.mutex.Lock()
+		source.channels = make(map[string]*trackedChannel)
+		source.synced = false
+		source.mutex.Unlock()
+
+		err := source.Client.Listen(amiCallEvents, []AMIMessage{{"Action": "CoreShowChannels"}}, source.handle)
+
+		log.LogS("ERROR", "Lost the AMI events connection ("+err.Error()+"), reconnecting...")
+
+	}
+
+}
+
+// GetActiveChannels ...
+func (source *LiveCallsSourceAMIEvents) GetActiveChannels() ([]*ActiveChannel, error) {
+
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	// NOTE: Until the CoreShowChannels list is in, the table only has the calls that started after connecting
+	if !source.synced {
+		return nil, fmt.Errorf("could not get the channels, not in sync with AMI events")
+	}
+
+	now := time.Now()
+
+	channels := make([]*ActiveChannel, 0, len(source.channels))
+	for _, tracked := range source.channels {
+		channel := *tracked.channel
+		channel.Duration = now.Sub(tracked.startedAt)
+		channels = append(channels, &channel)
+	}
+
+	return channels, nil
+
+}
+
+// SubscribeCallEvents ...
+func (source *LiveCallsSourceAMIEvents) SubscribeCallEvents(types ...int) <-chan CallEvent {
+	return source.subscribe(types...)
+}
+
+func (source *LiveCallsSourceAMIEvents) handle(event AMIMessage) {
+
+	log := marlog.MarLog
+
+	source.mutex.Lock()
+
+	var callEvent *CallEvent
+
+	switch event.Get("Event") {
+	case "CoreShowChannel":
+
+		channel := newActiveChannelFromAMIEvent(event)
+		source.channels[channel.UniqueID] = &trackedChannel{channel: channel, startedAt: time.Now().Add(-channel.Duration)}
+
+	case "CoreShowChannelsComplete":
+
+		source.synced = true
+		log.LogS("DEBUG", "In sync with AMI events, "+strconv.Itoa(len(source.channels))+" channels up")
+
+	case "Newchannel":
+
+		channel := new(ActiveChannel)
+		channel.Channel = event.Get("Channel")
+		channel.Context = event.Get("Context")
+		channel.Extension = event.Get("Exten")
+		channel.State = event.Get("ChannelStateDesc")
+		channel.CallerID = event.Get("CallerIDNum")
+		channel.AccountCode = event.Get("AccountCode")
+		channel.UniqueID = event.Get("Uniqueid")
+
+		source.channels[channel.UniqueID] = &trackedChannel{channel: channel, startedAt: time.Now()}
+		callEvent = &CallEvent{Type: CallEventStarted, Channel: channel}
+
+	case "Newstate":
+
+		if tracked, found := source.channels[event.Get("Uniqueid")]; found {
+			tracked.channel.State = event.Get("ChannelStateDesc")
+		}
+
+	case "DialBegin", "Dial":
+
+		// NOTE: Up to Asterisk 11 it's a "Dial" event with "SubEvent: Begin" (or "End") and the destination is in "Destination"
+		if event.Get("Event") == "Dial" && event.Get("SubEvent") != "Begin" {
+			break
+		}
+
+		tracked, found := source.channels[event.Get("Uniqueid")]
+		if !found {
+			break
+		}
+
+		destinationChannel := event.Get("DestChannel")
+		if destinationChannel == "" {
+			destinationChannel = event.Get("Destination")
+		}
+
+		// NOTE: "DialString" doesn't have the technology (e.g. "trunk/1234" for "SIP/trunk/1234"), that's in the destination channel
+		data := event.Get("DialString")
+		if separatorIndex := strings.Index(destinationChannel, "/"); separatorIndex != -1 {
+			data = destinationChannel[:separatorIndex+1] + data
+		}
+
+		// NOTE: Each leg of a forked Dial has its own event
+		tracked.channel.Application = "Dial"
+		if tracked.channel.Data == "" {
+			tracked.channel.Data = data
+		} else {
+			tracked.channel.Data += "&" + data
+		}
+		tracked.channel.BridgedTo = destinationChannel
+
+		callEvent = &CallEvent{Type: CallEventDialed, Channel: tracked.channel}
+
+	case "Hangup":
+
+		tracked, found := source.channels[event.Get("Uniqueid")]
+		if !found {
+			break
+		}
+
+		delete(source.channels, event.Get("Uniqueid"))
+		callEvent = &CallEvent{Type: CallEventEnded, Channel: tracked.channel}
+
+	case "Cdr":
+
+		cdr, err := newCDRFromAMIEvent(event)
+		if err != nil {
+			log.LogS("ERROR", "Could not convert a Cdr event to a CDR ("+err.Error()+")")
+			break
+		}
+
+		callEvent = &CallEvent{Type: CallEventCDR, CDR: cdr}
+
+	}
+
+	source.mutex.Unlock()
+
+	// NOTE: Channels in events are the ones in the table, subscribers get a copy so they don't race with the next events
+	if callEvent != nil {
+		if callEvent.Channel != nil {
+			channel := *callEvent.Channel
+			callEvent.Channel = &channel
+		}
+		source.publish(*callEvent)
+	}
+
+}
+
+func newCDRFromAMIEvent(event AMIMessage) (*CDR, error) {
+
+	callDate, err := parseCDRTime(event.Get("StartTime"))
+	if err != nil {
+		return nil, err
+	}
+
+	duration, err := parseCDRSeconds(event.Get("Duration"))
+	if err != nil {
+		return nil, err
+	}
+
+	billSec, err := parseCDRSeconds(event.Get("BillableSeconds"))
+	if err != nil {
+		return nil, err
+	}
+
+	cdr := new(CDR)
+	cdr.CallDate = callDate
+	cdr.CLID = event.Get("CallerID")
+	cdr.Src = event.Get("Source")
+	cdr.Dst = event.Get("Destination")
+	cdr.DContext = event.Get("DestinationContext")
+	cdr.Channel = event.Get("Channel")
+	cdr.DstChannel = event.Get("DestinationChannel")
+	cdr.LastApp = event.Get("LastApplication")
+	cdr.LastData = event.Get("LastData")
+	cdr.Duration = duration
+	cdr.BillSec = billSec
+	cdr.Disposition = event.Get("Disposition")
+	cdr.AMAFlags = event.Get("AMAFlags")
+	cdr.AccountCode = event.Get("AccountCode")
+	cdr.UniqueID = event.Get("UniqueID")
+	cdr.UserField = event.Get("UserField")
+
+	return cdr, nil
+
+}
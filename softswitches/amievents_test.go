@@ -0,0 +1,205 @@
+package softswitches
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitForChannels Waits for "source" to be in sync and have exactly the channels with the "expected" Uniqueids up
+func waitForChannels(t *testing.T, source *LiveCallsSourceAMIEvents, expected ...string) []*ActiveChannel {
+
+	sort.Strings(expected)
+
+	var uniqueIDs []string
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+
+		channels, err := source.GetActiveChannels()
+		if err == nil {
+
+			uniqueIDs = make([]string, 0, len(channels))
+			for _, channel := range channels {
+				uniqueIDs = append(uniqueIDs, channel.UniqueID)
+			}
+			sort.Strings(uniqueIDs)
+
+			if strings.Join(uniqueIDs, ",") == strings.Join(expected, ",") {
+				return channels
+			}
+
+		}
+
+		time.Sleep(10 * time.Millisecond)
+
+	}
+
+	t.Fatalf("expected the channels %v to be up, got %v", expected, uniqueIDs)
+
+	return nil
+
+}
+
+func nextCallEvent(t *testing.T, events <-chan CallEvent, expectedType int) CallEvent {
+
+	select {
+	case event := <-events:
+		if event.Type != expectedType {
+			t.Fatalf("expected a call event of type %d, got %+v", expectedType, event)
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected a call event of type %d", expectedType)
+	}
+
+	return CallEvent{}
+
+}
+
+func TestLiveCallsSourceAMIEvents(t *testing.T) {
+
+	tests := []struct {
+		version   string
+		dialBegin string
+		dialEnd   string
+	}{
+		// NOTE: Up to Asterisk 11 both are "Dial" events, the destination is in "Destination"
+		{
+			"1.8",
+			"Event: Dial\r\nPrivilege: call,all\r\nSubEvent: Begin\r\nChannel: SIP/1000-00000002\r\nDestination: SIP/trunk-00000003\r\nUniqueID: 1469786460.2\r\nDestUniqueID: 1469786460.3\r\nDialstring: trunk/00244123456789",
+			"Event: Dial\r\nPrivilege: call,all\r\nSubEvent: End\r\nChannel: SIP/1000-00000002\r\nUniqueID: 1469786460.2\r\nDialStatus: ANSWER",
+		},
+		{
+			"13",
+			"Event: DialBegin\r\nPrivilege: call,all\r\nChannel: SIP/1000-00000002\r\nUniqueid: 1469786460.2\r\nDestChannel: SIP/trunk-00000003\r\nDestUniqueid: 1469786460.3\r\nDialString: trunk/00244123456789",
+			"Event: DialEnd\r\nPrivilege: call,all\r\nChannel: SIP/1000-00000002\r\nUniqueid: 1469786460.2\r\nDestChannel: SIP/trunk-00000003\r\nDialStatus: ANSWER",
+		},
+	}
+
+	for _, test := range tests {
+		testLiveCallsSourceAMIEvents(t, test.version, test.dialBegin, test.dialEnd)
+	}
+
+}
+
+func testLiveCallsSourceAMIEvents(t *testing.T, version string, dialBegin string, dialEnd string) {
+
+	server := newFakeAMI(t, "127.0.0.1:0", "secret")
+	defer server.listener.Close()
+
+	server.events = make(chan string, 10)
+	defer func() { server.events <- "" }()
+
+	server.setChannels("Channel: SIP/1001-00000001\r\nContext: from-internal\r\nExten: 00351212345678\r\nChannelStateDesc: Up\r\nApplication: Dial\r\nApplicationData: SIP/trunk/00351212345678,60\r\nCallerIDNum: 1001\r\nDuration: 00:00:30\r\nUniqueid: 1469786400.1")
+
+	source := NewLiveCallsSourceAMIEvents(newTestAMIClient(server, "secret"))
+	asterisk := &Asterisk{LiveCallsSource: source}
+
+	events := SubscribeCallEvents(asterisk, CallEventStarted, CallEventDialed, CallEventEnded, CallEventCDR)
+
+	go source.Run()
+
+	waitForChannels(t, source, "1469786400.1")
+
+	server.events <- "Event: Newchannel\r\nPrivilege: call,all\r\nChannel: SIP/1000-00000002\r\nChannelStateDesc: Ring\r\nCallerIDNum: 1000\r\nAccountCode: acme\r\nContext: from-internal\r\nExten: 00244123456789\r\nUniqueid: 1469786460.2"
+
+	event := nextCallEvent(t, events, CallEventStarted)
+	if event.Channel.Channel != "SIP/1000-00000002" || event.Channel.Extension != "00244123456789" || event.Channel.AccountCode != "acme" {
+		t.Fatalf("%s: expected the new channel to be started, got %+v", version, event.Channel)
+	}
+
+	waitForChannels(t, source, "1469786400.1", "1469786460.2")
+
+	server.events <- dialBegin
+
+	event = nextCallEvent(t, events, CallEventDialed)
+	if event.Channel.Application != "Dial" || event.Channel.Data != "SIP/trunk/00244123456789" || event.Channel.BridgedTo != "SIP/trunk-00000003" {
+		t.Fatalf("%s: expected the channel to be dialing \"SIP/trunk/00244123456789\", got %+v", version, event.Channel)
+	}
+
+	// NOTE: The end of the Dial is not another dialed event, if it were it would be picked up instead of the ended one
+	server.events <- dialEnd
+	server.events <- "Event: Newstate\r\nPrivilege: call,all\r\nChannel: SIP/1000-00000002\r\nChannelStateDesc: Up\r\nUniqueid: 1469786460.2"
+	server.events <- "Event: Hangup\r\nPrivilege: call,all\r\nChannel: SIP/1000-00000002\r\nUniqueid: 1469786460.2\r\nCause: 16\r\nCause-txt: Normal Clearing"
+
+	event = nextCallEvent(t, events, CallEventEnded)
+	if event.Channel.UniqueID != "1469786460.2" || event.Channel.State != "Up" || event.Channel.Data != "SIP/trunk/00244123456789" {
+		t.Fatalf("%s: expected the channel that was up to be ended, got %+v", version, event.Channel)
+	}
+
+	channels := waitForChannels(t, source, "1469786400.1")
+	if channels[0].Duration < 30*time.Second {
+		t.Fatalf("%s: expected the channel to be up for at least 30s, got %s", version, channels[0].Duration)
+	}
+
+	activeCalls, err := asterisk.GetCurrentActiveCalls(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if activeCalls != 1 {
+		t.Fatalf("%s: expected 1 active call, got %d", version, activeCalls)
+	}
+
+	server.events <- "Event: Cdr\r\nPrivilege: cdr,all\r\nAccountCode: acme\r\nSource: 1000\r\nDestination: 00244123456789\r\nDestinationContext: from-internal\r\nCallerID: \"John Doe\" <1000>\r\nChannel: SIP/1000-00000002\r\nDestinationChannel: SIP/trunk-00000003\r\nLastApplication: Dial\r\nLastData: SIP/trunk/00244123456789,60\r\nStartTime: 2016-07-29 10:00:00\r\nAnswerTime: 2016-07-29 10:00:18\r\nEndTime: 2016-07-29 10:01:00\r\nDuration: 60\r\nBillableSeconds: 42\r\nDisposition: ANSWERED\r\nAMAFlags: DOCUMENTATION\r\nUniqueID: 1469786460.2\r\nUserField: "
+
+	event = nextCallEvent(t, events, CallEventCDR)
+	expected := CDR{
+		CallDate:    time.Date(2016, 7, 29, 10, 0, 0, 0, time.Local),
+		CLID:        "\"John Doe\" <1000>",
+		Src:         "1000",
+		Dst:         "00244123456789",
+		DContext:    "from-internal",
+		Channel:     "SIP/1000-00000002",
+		DstChannel:  "SIP/trunk-00000003",
+		LastApp:     "Dial",
+		LastData:    "SIP/trunk/00244123456789,60",
+		Duration:    60,
+		BillSec:     42,
+		Disposition: "ANSWERED",
+		AMAFlags:    "DOCUMENTATION",
+		AccountCode: "acme",
+		UniqueID:    "1469786460.2",
+	}
+	if !reflect.DeepEqual(*event.CDR, expected) {
+		t.Fatalf("%s: expected the CDR %+v, got %+v", version, expected, *event.CDR)
+	}
+
+}
+
+func TestLiveCallsSourceAMIEventsResync(t *testing.T) {
+
+	server := newFakeAMI(t, "127.0.0.1:0", "secret")
+	defer server.listener.Close()
+
+	server.events = make(chan string, 10)
+	defer func() { server.events <- "" }()
+
+	server.setChannels("Channel: SIP/1001-00000001\r\nChannelStateDesc: Up\r\nApplication: Dial\r\nApplicationData: SIP/1002,60\r\nDuration: 00:00:30\r\nUniqueid: 1469786400.1")
+
+	source := NewLiveCallsSourceAMIEvents(newTestAMIClient(server, "secret"))
+
+	go source.Run()
+
+	waitForChannels(t, source, "1469786400.1")
+
+	server.events <- "Event: Newchannel\r\nChannel: SIP/1000-00000002\r\nChannelStateDesc: Ring\r\nUniqueid: 1469786460.2"
+
+	waitForChannels(t, source, "1469786400.1", "1469786460.2")
+
+	// NOTE: Whatever happened while disconnected is unknown, after reconnecting the table is what CoreShowChannels says is up
+	server.setChannels("Channel: SIP/1003-00000004\r\nChannelStateDesc: Up\r\nApplication: Dial\r\nApplicationData: SIP/trunk/00351212345678,60\r\nDuration: 00:01:05\r\nUniqueid: 1469786520.4")
+	server.events <- ""
+
+	channels := waitForChannels(t, source, "1469786520.4")
+	if channels[0].Data != "SIP/trunk/00351212345678,60" || channels[0].Duration < 65*time.Second {
+		t.Fatalf("expected the channel that's up after reconnecting, got %+v", channels[0])
+	}
+
+	if server.getLogins() != 2 {
+		t.Fatalf("expected 2 logins, got %d", server.getLogins())
+	}
+
+}
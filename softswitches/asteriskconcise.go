@@ -0,0 +1,188 @@
+package softswitches
+
+import (
+	"bufio"
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andmar/marlog"
+)
+
+const (
+	// NOTE: All supported versions print "channel!context!exten!priority!state!application!data!callerid!accountcode!peeraccount!amaflags!
+	// duration!bridged!uniqueid", what changes is what "bridged" is and how empty values are shown
+	asteriskConciseNumberOfFields      = 14
+	asteriskConciseFieldsBeforeData    = 6
+	asteriskConciseFieldsAfterData     = 7
+	asteriskConciseFieldChannel        = 0
+	asteriskConciseFieldContext        = 1
+	asteriskConciseFieldExtension      = 2
+	asteriskConciseFieldState          = 4
+	asteriskConciseFieldApplication    = 5
+	asteriskConciseFieldData           = 6
+	asteriskConciseFieldCallerID       = 7
+	asteriskConciseFieldAccountCode    = 8
+	asteriskConciseFieldDuration       = 11
+	asteriskConciseFieldBridged        = 12
+	asteriskConciseFieldUniqueID       = 13
+	asteriskConciseNone                = "(None)"
+	asteriskConciseMajorVersionLegacy  = 1
+	asteriskConciseDefaultMajorVersion = 13
+)
+
+// asteriskConciseParser Turns the output of "core show channels concise" of some Asterisk version into ActiveChannels
+type asteriskConciseParser struct {
+	majorVersion int
+	parse        func(output []byte) ([]*ActiveChannel, error)
+}
+
+// NOTE: Ordered by version, versions in between use the parser of the closest version before them (e.g. 12 uses 11's, 17 uses 16's), 1 is
+// 1.8 (and the 1.x versions before it)
+var asteriskConciseParsers = []asteriskConciseParser{
+	{majorVersion: asteriskConciseMajorVersionLegacy, parse: parseAsteriskConciseBridgedChannel}, // 1.8
+	{majorVersion: 11, parse: parseAsteriskConciseBridgedChannel},
+	{majorVersion: 13, parse: parseAsteriskConciseBridgeID},
+	{majorVersion: 16, parse: parseAsteriskConciseBridgeID},
+	{majorVersion: 18, parse: parseAsteriskConciseBridgeID},
+	{majorVersion: 20, parse: parseAsteriskConciseBridgeID},
+}
+
+// parseAsteriskCoreShowChannelsConcise Parses the output of "core show channels concise" as printed by Asterisk "version" (as in the
+// "softswitch" section of the configuration, e.g. "1.8", "11.25.1", "20")
+func parseAsteriskCoreShowChannelsConcise(version string, output []byte) ([]*ActiveChannel, error) {
+	return getAsteriskConciseParser(version).parse(output)
+}
+
+func getAsteriskConciseParser(version string) asteriskConciseParser {
+
+	log := marlog.MarLog
+
+	majorVersion, err := strconv.Atoi(strings.SplitN(strings.TrimSpace(version), ".", 2)[0])
+	if err != nil {
+		log.LogS("ERROR", "Could not get the major version out of Asterisk version \""+version+"\", parsing \"core show channels concise\" as "+strconv.Itoa(asteriskConciseDefaultMajorVersion)+"'s")
+		majorVersion = asteriskConciseDefaultMajorVersion
+	}
+
+	index := sort.Search(len(asteriskConciseParsers), func(index int) bool {
+		return asteriskConciseParsers[index].majorVersion > majorVersion
+	})
+
+	if index == 0 {
+		return asteriskConciseParsers[0]
+	}
+
+	return asteriskConciseParsers[index-1]
+
+}
+
+// parseAsteriskConciseBridgedChannel Up to Asterisk 11 "bridged" is the name of the channel on the other side of the bridge or "(None)"
+func parseAsteriskConciseBridgedChannel(output []byte) ([]*ActiveChannel, error) {
+
+	channels, err := parseAsteriskConciseLines(output)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, channel := range channels {
+		if channel.BridgedTo == asteriskConciseNone {
+			channel.BridgedTo = ""
+		}
+	}
+
+	return channels, nil
+
+}
+
+// parseAsteriskConciseBridgeID From Asterisk 13 on channels are in bridges and "bridged" is the bridge's id (empty when not bridged), the
+// channel on the other side is the other one in the same bridge, if there's more than one (e.g. a conference) it's left as the bridge id
+func parseAsteriskConciseBridgeID(output []byte) ([]*ActiveChannel, error) {
+
+	channels, err := parseAsteriskConciseLines(output)
+	if err != nil {
+		return nil, err
+	}
+
+	bridges := make(map[string][]*ActiveChannel)
+	for _, channel := range channels {
+		if channel.BridgedTo != "" {
+			bridges[channel.BridgedTo] = append(bridges[channel.BridgedTo], channel)
+		}
+	}
+
+	for _, bridged := range bridges {
+		if len(bridged) == 2 {
+			bridged[0].BridgedTo, bridged[1].BridgedTo = bridged[1].Channel, bridged[0].Channel
+		}
+	}
+
+	return channels, nil
+
+}
+
+// parseAsteriskConciseLines Parses the lines all versions have in common, lines that are not channels (e.g. warnings from the CLI) are
+// skipped. Fields are separated by "!" but nothing stops "data" from having a "!" in it, the fields around it are at fixed positions so
+// everything in between is "data"
+func parseAsteriskConciseLines(output []byte) ([]*ActiveChannel, error) {
+
+	log := marlog.MarLog
+
+	var channels []*ActiveChannel
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+
+		lineItems := strings.Split(line, "!")
+
+		if len(lineItems) < asteriskConciseNumberOfFields {
+			log.LogS("DEBUG", "Skipping line that is not a channel: \""+line+"\"")
+			continue
+		}
+
+		if len(lineItems) > asteriskConciseNumberOfFields {
+			dataEnd := len(lineItems) - asteriskConciseFieldsAfterData
+			data := strings.Join(lineItems[asteriskConciseFieldsBeforeData:dataEnd], "!")
+			lineItems = append(append(lineItems[:asteriskConciseFieldsBeforeData], data), lineItems[dataEnd:]...)
+		}
+
+		channel := new(ActiveChannel)
+		channel.Channel = lineItems[asteriskConciseFieldChannel]
+		channel.Context = lineItems[asteriskConciseFieldContext]
+		channel.Extension = lineItems[asteriskConciseFieldExtension]
+		channel.State = lineItems[asteriskConciseFieldState]
+		channel.Application = lineItems[asteriskConciseFieldApplication]
+		channel.Data = lineItems[asteriskConciseFieldData]
+		channel.CallerID = lineItems[asteriskConciseFieldCallerID]
+		channel.AccountCode = lineItems[asteriskConciseFieldAccountCode]
+		channel.BridgedTo = lineItems[asteriskConciseFieldBridged]
+		channel.UniqueID = lineItems[asteriskConciseFieldUniqueID]
+
+		if channel.Application == asteriskConciseNone {
+			channel.Application = ""
+		}
+
+		seconds, err := strconv.ParseUint(lineItems[asteriskConciseFieldDuration], 10, 32)
+		if err != nil {
+			log.LogS("ERROR", "Could not parse duration \""+lineItems[asteriskConciseFieldDuration]+"\" of channel \""+channel.Channel+"\"")
+			continue
+		}
+		channel.Duration = time.Duration(seconds) * time.Second
+
+		channels = append(channels, channel)
+
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return channels, nil
+
+}
@@ -0,0 +1,155 @@
+package softswitches
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// staticLiveCallsSource Always has the same channels up
+type staticLiveCallsSource []*ActiveChannel
+
+func (source staticLiveCallsSource) GetActiveChannels() ([]*ActiveChannel, error) {
+	return source, nil
+}
+
+// NOTE: The captures in testdata/core_show_channels_concise are "asterisk -rx 'core show channels concise'" of each supported version, an
+// outbound call (the dialing channel and its outgoing leg) and some channels that are not outbound calls
+func TestParseAsteriskCoreShowChannelsConcise(t *testing.T) {
+
+	// NOTE: Up to 11 "bridged" is the peer channel, from 13 on it's the bridge id and the peer is the other channel in the same bridge
+	caller := func(technology string, data string) ActiveChannel {
+		return ActiveChannel{Channel: technology + "/1000-00000001", Context: "from-internal", Extension: "00244123456789", State: "Up", Application: "Dial", Data: data, CallerID: "1000", AccountCode: "acme", Duration: 65 * time.Second, BridgedTo: technology + "/trunk-00000002", UniqueID: "1469786400.1"}
+	}
+	outgoingLeg := func(technology string) ActiveChannel {
+		return ActiveChannel{Channel: technology + "/trunk-00000002", Context: "from-trunk", State: "Up", Application: "AppDial", Data: "(Outgoing Line)", CallerID: "00244123456789", AccountCode: "acme", Duration: 65 * time.Second, BridgedTo: technology + "/1000-00000001", UniqueID: "1469786400.2"}
+	}
+
+	tests := []struct {
+		version          string
+		expectedChannels []ActiveChannel
+		expectedCalls    uint32
+	}{
+		{
+			version: "1.8",
+			expectedChannels: []ActiveChannel{
+				caller("SIP", "SIP/trunk/00244123456789,60,tTr"),
+				outgoingLeg("SIP"),
+				{Channel: "SIP/1001-00000003", Context: "from-internal", Extension: "00351212345678", State: "Ring", Application: "Dial", Data: "SIP/trunk/00351212345678&SIP/backup/00351212345678,30", CallerID: "1001", Duration: 4 * time.Second, UniqueID: "1469786461.3"},
+				{Channel: "SIP/1002-00000005", Context: "from-internal", Extension: "*97", State: "Up", Application: "VoiceMailMain", Data: "@default", CallerID: "1002", Duration: 12 * time.Second, UniqueID: "1469786453.5"},
+			},
+			// NOTE: Both legs of the second Dial are numbers being dialed
+			expectedCalls: 3,
+		},
+		{
+			version: "11",
+			expectedChannels: []ActiveChannel{
+				caller("SIP", "SIP/trunk/00244123456789,60,tTr"),
+				outgoingLeg("SIP"),
+				{Channel: "IAX2/branch-1234", Context: "from-branch", Extension: "00882123456789", State: "Ring", Application: "Dial", Data: "IAX2/user:secret@carrier/00882123456789", CallerID: "2000", Duration: 7 * time.Second, UniqueID: "1469786458.3"},
+				{Channel: "SIP/1003-00000006", Context: "from-internal", Extension: "s", State: "Ring", CallerID: "1003", Duration: time.Second, UniqueID: "1469786464.6"},
+			},
+			expectedCalls: 2,
+		},
+		{
+			version: "13",
+			expectedChannels: []ActiveChannel{
+				caller("SIP", "SIP/trunk/00244123456789,60,tTr"),
+				outgoingLeg("SIP"),
+				{Channel: "SIP/1001-00000003", Context: "from-internal", Extension: "00351212345678", State: "Ring", Application: "Dial", Data: "SIP/trunk/00351212345678,30", CallerID: "1001", Duration: 4 * time.Second, UniqueID: "1469786461.3"},
+			},
+			expectedCalls: 2,
+		},
+		{
+			version: "16",
+			expectedChannels: []ActiveChannel{
+				caller("PJSIP", "PJSIP/00244123456789@trunk,60,tTr"),
+				outgoingLeg("PJSIP"),
+				// NOTE: With more than two channels in a bridge there's no peer, they keep the bridge id
+				{Channel: "PJSIP/1004-00000007", Context: "conferences", Extension: "800", State: "Up", Application: "ConfBridge", Data: "800", CallerID: "1004", Duration: 300 * time.Second, BridgedTo: "e1f2a3b4-c5d6-4e7f-8a9b-0c1d2e3f4a5b", UniqueID: "1469786165.7"},
+				{Channel: "PJSIP/1005-00000008", Context: "conferences", Extension: "800", State: "Up", Application: "ConfBridge", Data: "800", CallerID: "1005", Duration: 290 * time.Second, BridgedTo: "e1f2a3b4-c5d6-4e7f-8a9b-0c1d2e3f4a5b", UniqueID: "1469786175.8"},
+				{Channel: "PJSIP/1006-00000009", Context: "conferences", Extension: "800", State: "Up", Application: "ConfBridge", Data: "800", CallerID: "1006", Duration: 280 * time.Second, BridgedTo: "e1f2a3b4-c5d6-4e7f-8a9b-0c1d2e3f4a5b", UniqueID: "1469786185.9"},
+			},
+			expectedCalls: 1,
+		},
+		{
+			version: "18",
+			expectedChannels: []ActiveChannel{
+				// NOTE: The "!" in "data" doesn't shift the fields after it
+				caller("PJSIP", "PJSIP/00244123456789@trunk,60,b(handler^s^1(a!b))"),
+				outgoingLeg("PJSIP"),
+			},
+			expectedCalls: 1,
+		},
+		{
+			version: "20",
+			expectedChannels: []ActiveChannel{
+				caller("PJSIP", "PJSIP/trunk/sip:00244123456789@carrier.example.com,60"),
+				outgoingLeg("PJSIP"),
+				{Channel: "Local/00351212345678@outbound-00000001;1", Context: "outbound", Extension: "00351212345678", State: "Up", Application: "Dial", Data: "Local/00351212345678@outbound/n", CallerID: "1001", Duration: 9 * time.Second, UniqueID: "1469786456.10"},
+			},
+			expectedCalls: 2,
+		},
+	}
+
+	for _, test := range tests {
+
+		output, err := ioutil.ReadFile(filepath.Join("testdata", "core_show_channels_concise", "asterisk-"+test.version+".txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		channels, err := parseAsteriskCoreShowChannelsConcise(test.version, output)
+		if err != nil {
+			t.Fatalf("Asterisk %s: %v", test.version, err)
+		}
+
+		if len(channels) != len(test.expectedChannels) {
+			t.Fatalf("Asterisk %s: expected %d channels, got %d", test.version, len(test.expectedChannels), len(channels))
+		}
+
+		for index, channel := range channels {
+			if *channel != test.expectedChannels[index] {
+				t.Errorf("Asterisk %s: expected channel %+v, got %+v", test.version, test.expectedChannels[index], *channel)
+			}
+		}
+
+		asterisk := &Asterisk{Version: test.version, LiveCallsSource: staticLiveCallsSource(channels)}
+
+		calls, err := asterisk.GetCurrentActiveCalls(5)
+		if err != nil {
+			t.Fatalf("Asterisk %s: %v", test.version, err)
+		}
+
+		if calls != test.expectedCalls {
+			t.Errorf("Asterisk %s: expected %d live calls, got %d", test.version, test.expectedCalls, calls)
+		}
+
+	}
+
+}
+
+func TestGetAsteriskConciseParser(t *testing.T) {
+
+	tests := []struct {
+		version              string
+		expectedMajorVersion int
+	}{
+		{"1.8", asteriskConciseMajorVersionLegacy},
+		{"1.8.32.3", asteriskConciseMajorVersionLegacy},
+		{"11.25.1", 11},
+		{"12", 11},
+		{"13.38.3", 13},
+		{"17", 16},
+		{"21.4.1", 20},
+		{"unknown", asteriskConciseDefaultMajorVersion},
+	}
+
+	for _, test := range tests {
+		if majorVersion := getAsteriskConciseParser(test.version).majorVersion; majorVersion != test.expectedMajorVersion {
+			t.Errorf("expected Asterisk %s to be parsed as %d, got %d", test.version, test.expectedMajorVersion, majorVersion)
+		}
+	}
+
+}
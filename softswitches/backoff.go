@@ -0,0 +1,61 @@
+package softswitches
+
+import (
+	"time"
+)
+
+const (
+	// DefaultMaximumBackoff ...
+	DefaultMaximumBackoff = 1 * time.Minute
+)
+
+const (
+	minimumBackoff = 1 * time.Second
+)
+
+// reconnectBackoff How long to wait before connecting again to something that is down, 1s after the first failed attempt, 2s after the
+// second, 4s after the third and so on up to a maximum, so that it's not hammered while it's down
+type reconnectBackoff struct {
+	failures uint
+	retryAt  time.Time
+}
+
+// failed Records a failed attempt and returns how long to wait before the next one
+func (backoff *reconnectBackoff) failed(maximumBackoff time.Duration) time.Duration {
+
+	if maximumBackoff <= 0 {
+		maximumBackoff = DefaultMaximumBackoff
+	}
+
+	backoff.failures++
+
+	wait := minimumBackoff
+	for attempt := uint(1); attempt < backoff.failures && wait < maximumBackoff; attempt++ {
+		wait *= 2
+	}
+
+	if wait > maximumBackoff {
+		wait = maximumBackoff
+	}
+
+	backoff.retryAt = time.Now().Add(wait)
+
+	return wait
+
+}
+
+func (backoff *reconnectBackoff) succeeded() {
+	backoff.failures = 0
+	backoff.retryAt = time.Time{}
+}
+
+// wait Returns how long until the next attempt can be made, 0 if it can be made now
+func (backoff *reconnectBackoff) wait() time.Duration {
+
+	if wait := backoff.retryAt.Sub(time.Now()); wait > 0 {
+		return wait
+	}
+
+	return 0
+
+}
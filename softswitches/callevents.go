@@ -0,0 +1,100 @@
+package softswitches
+
+import (
+	"sync"
+)
+
+const (
+	// CallEventStarted ...
+	CallEventStarted = iota
+	// CallEventDialed ...
+	CallEventDialed
+	// CallEventEnded ...
+	CallEventEnded
+	// CallEventCDR ...
+	CallEventCDR
+)
+
+// CallEvent Something that just happened to a call, Channel is set for all but CallEventCDR, which has the CDR that was just written
+type CallEvent struct {
+	Type    int
+	Channel *ActiveChannel
+	CDR     *CDR
+}
+
+// CallEventsSource Softswitches (or their Live Calls Sources) that know about calls as soon as something happens to them instead of only
+// when asked
+type CallEventsSource interface {
+	SubscribeCallEvents(types ...int) <-chan CallEvent
+}
+
+// SubscribeCallEvents Returns a channel that receives the CallEvents of the given "types" from "softswitch" or nil, which never receives
+// anything, if "softswitch" can't tell about calls as they happen
+func SubscribeCallEvents(softswitch Softswitch, types ...int) <-chan CallEvent {
+
+	// NOTE: The cache doesn't know about calls, the Softswitch it sits in front of may
+	if cache, ok := softswitch.(*CDRsCache); ok {
+		return SubscribeCallEvents(cache.Softswitch, types...)
+	}
+
+	if normalizer, ok := softswitch.(*NumberNormalizer); ok {
+		return SubscribeCallEvents(normalizer.Softswitch, types...)
+	}
+
+	if source, ok := softswitch.(CallEventsSource); ok {
+		return source.SubscribeCallEvents(types...)
+	}
+
+	return nil
+
+}
+
+// callEventsPublisher Hands CallEvents to subscribers without ever blocking, subscribers are meant to be woken up by events, not to go
+// through every one of them, so events that come while a subscriber still has one to pick up are dropped for that subscriber
+type callEventsPublisher struct {
+	mutex         sync.Mutex
+	subscriptions []*callEventsSubscription
+}
+
+type callEventsSubscription struct {
+	types  map[int]bool
+	events chan CallEvent
+}
+
+func (publisher *callEventsPublisher) subscribe(types ...int) <-chan CallEvent {
+
+	publisher.mutex.Lock()
+	defer publisher.mutex.Unlock()
+
+	subscription := new(callEventsSubscription)
+	subscription.types = make(map[int]bool)
+	for _, eventType := range types {
+		subscription.types[eventType] = true
+	}
+	subscription.events = make(chan CallEvent, 1)
+
+	publisher.subscriptions = append(publisher.subscriptions, subscription)
+
+	return subscription.events
+
+}
+
+func (publisher *callEventsPublisher) publish(event CallEvent) {
+
+	publisher.mutex.Lock()
+	defer publisher.mutex.Unlock()
+
+	for _, subscription := range publisher.subscriptions {
+
+		if !subscription.types[event.Type] {
+			continue
+		}
+
+		select {
+		case subscription.events <- event:
+		default:
+		}
+
+	}
+
+}
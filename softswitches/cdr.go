@@ -0,0 +1,389 @@
+package softswitches
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"encoding/json"
+
+	"github.com/andmar/fraudion/system"
+
+	"github.com/andmar/marlog"
+)
+
+// NOTE: Layouts in which CDR dates/times show up depending on where they come from, the ones without a time zone are local time
+var cdrTimeLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04:05.999999999",
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999-07",
+}
+
+// CDR A normalized Call Detail Record, fields are named after the ones in Asterisk's "cdr" table whatever the Softswitch or CDRs Source
+// they came from, fields a Source doesn't have are left empty. DialedNumbers is only set when the CDR comes from Softswitch.GetCDRs since
+// how to get them from the other fields depends on the Softswitch, there's more than one when more than one number was dialed at once
+// (e.g. Asterisk's Dial(SIP/a/1234&SIP/b/2345)). If they were normalized (see NumberNormalizer) RawDialedNumbers has them as they were
+// dialed, in the same order. InProgress is set by Sources that also have the calls that did not end yet (e.g.
+// CDRsSourceCEL), for those Duration and BillSec are up to when the CDR was made and the same call comes again, changed, on every GetCDRs
+type CDR struct {
+	CallDate         time.Time
+	CLID             string
+	Src              string
+	Dst              string
+	DialedNumbers    []string
+	RawDialedNumbers []string
+	DContext         string
+	Channel          string
+	DstChannel       string
+	LastApp          string
+	LastData         string
+	Duration         uint32
+	BillSec          uint32
+	Disposition      string
+	AMAFlags         string
+	AccountCode      string
+	UniqueID         string
+	UserField        string
+	InProgress       bool
+}
+
+// rawDialedNumber Returns the DialedNumbers at "index" as it was dialed
+func (cdr *CDR) rawDialedNumber(index int) string {
+
+	if index < len(cdr.RawDialedNumbers) {
+		return cdr.RawDialedNumbers[index]
+	}
+
+	return cdr.DialedNumbers[index]
+
+}
+
+// CDRsIterator Works like sql.Rows, call Next until it returns false, then check Err. Close has to be called if iteration is stopped
+// before Next returns false
+type CDRsIterator interface {
+	Next() bool
+	CDR() *CDR
+	Err() error
+	Close() error
+}
+
+// cdrsIteratorSlice Iterates over CDRs that are already in memory
+type cdrsIteratorSlice struct {
+	cdrs  []*CDR
+	index int
+}
+
+func newCDRsIteratorSlice(cdrs []*CDR) *cdrsIteratorSlice {
+	return &cdrsIteratorSlice{cdrs: cdrs, index: -1}
+}
+
+// Next ...
+func (iterator *cdrsIteratorSlice) Next() bool {
+	iterator.index++
+	return iterator.index < len(iterator.cdrs)
+}
+
+// CDR ...
+func (iterator *cdrsIteratorSlice) CDR() *CDR {
+	return iterator.cdrs[iterator.index]
+}
+
+// Err ...
+func (iterator *cdrsIteratorSlice) Err() error {
+	return nil
+}
+
+// Close ...
+func (iterator *cdrsIteratorSlice) Close() error {
+	iterator.index = len(iterator.cdrs)
+	return nil
+}
+
+// cdrsIteratorDialedNumbers Sets DialedNumbers on each CDR coming from the wrapped iterator, this is how Softswitches add what only they
+// know how to get to what comes out of the CDRs Sources
+type cdrsIteratorDialedNumbers struct {
+	CDRsIterator
+	dialedNumbers func(cdr *CDR) []string
+}
+
+// Next ...
+func (iterator *cdrsIteratorDialedNumbers) Next() bool {
+
+	if iterator.CDRsIterator.Next() == false {
+		return false
+	}
+
+	cdr := iterator.CDRsIterator.CDR()
+	cdr.DialedNumbers = iterator.dialedNumbers(cdr)
+
+	return true
+
+}
+
+// hitsSince Returns when the CDRs considered to search for Hits start, "considerCDRsFromLast" ago or, if not in DEBUG, the StartUpTime when
+// that is more recent. This is currenctly the only though of way of resetting the system state to Normal, if we don't do this, when we
+// restart the system will still be in alarm and will be until considerCDRsFromLast passes...
+func hitsSince(considerCDRsFromLast time.Duration) time.Time {
+
+	since := time.Now().Add(-considerCDRsFromLast)
+	if !system.DEBUG && system.State.StartUpTime.After(since) {
+		since = system.State.StartUpTime
+	}
+
+	return since
+
+}
+
+// getHits Does what all Softswitches do in GetHits, counts the Hits in the CDRs from "considerCDRsFromLast" ago (see hitsSince)
+func getHits(softswitch Softswitch, matches func(string, ...uint32) (string, bool, error), considerCDRsFromLast time.Duration, considerCallDuration bool, groupBy GroupBy) (map[string]*Hits, error) {
+	return getHitsSince(softswitch, hitsSince(considerCDRsFromLast), matches, considerCallDuration, groupBy)
+}
+
+// getHitsSince Goes through the CDRs started at or after "since" and counts, by group (see GroupBy), the DialedNumbers that "matches", each number of a
+// CDR with more than one is a Hit of its own
+func getHitsSince(softswitch Softswitch, since time.Time, matches func(string, ...uint32) (string, bool, error), considerCallDuration bool, groupBy GroupBy) (map[string]*Hits, error) {
+
+	result, err := getHitsSinceEach(softswitch, []time.Time{since}, matches, considerCallDuration, groupBy)
+	if err != nil {
+		return nil, err
+	}
+
+	return result[0], nil
+
+}
+
+// GetHitsInWindows Does what Softswitch.GetHits does for each of "windows" (e.g. the ones of the "call_velocity" monitor) but goes through
+// the CDRs of the longest one only once, the Hits of each window are at the same index in the result
+func GetHitsInWindows(softswitch Softswitch, matches func(string, ...uint32) (string, bool, error), windows []time.Duration, considerCallDuration bool, groupBy GroupBy) ([]map[string]*Hits, error) {
+
+	sinces := make([]time.Time, len(windows))
+	for index, window := range windows {
+		// NOTE: A Replay's windows end at its Now
+		if replay, ok := softswitch.(*Replay); ok {
+			sinces[index] = replay.Now.Add(-window)
+		} else {
+			sinces[index] = hitsSince(window)
+		}
+	}
+
+	return getHitsSinceEach(softswitch, sinces, matches, considerCallDuration, groupBy)
+
+}
+
+// getHitsSinceEach Does what getHitsSince does for each of "sinces" with the CDRs started at or after the earliest of them, the Hits of each
+// are at the same index in the result
+func getHitsSinceEach(softswitch Softswitch, sinces []time.Time, matches func(string, ...uint32) (string, bool, error), considerCallDuration bool, groupBy GroupBy) ([]map[string]*Hits, error) {
+
+	log := marlog.MarLog
+
+	earliest := sinces[0]
+	for _, since := range sinces {
+		if since.Before(earliest) {
+			earliest = since
+		}
+	}
+
+	cdrs, err := softswitch.GetCDRs(earliest)
+	if err != nil {
+		log.LogS("ERROR", "could not get the CDRs")
+		return nil, err
+	}
+
+	defer cdrs.Close()
+
+	result := make([]map[string]*Hits, len(sinces))
+	for index := range sinces {
+		result[index] = make(map[string]*Hits)
+	}
+
+	numberOfCDRsTotal := 0
+	numberOfCDRsSuitable := 0
+	numberOfCDRsMatched := 0
+
+	for cdrs.Next() {
+
+		cdr := cdrs.CDR()
+
+		numberOfCDRsTotal++
+
+		// NOTE: Ignore CDRs from where the Softswitch could not get a dialed number
+		if len(cdr.DialedNumbers) == 0 {
+			continue
+		}
+
+		numberOfCDRsSuitable++
+
+		for index, dialedNumber := range cdr.DialedNumbers {
+
+			var prefix string
+			var matched bool
+			if !considerCallDuration {
+				prefix, matched, err = matches(dialedNumber)
+			} else {
+				prefix, matched, err = matches(dialedNumber, cdr.BillSec)
+			}
+			if err != nil {
+				log.LogS("ERROR", "Number not suitable")
+				return nil, err
+			}
+
+			if matched == true {
+
+				numberOfCDRsMatched++
+
+				for sinceIndex, since := range sinces {
+					if !cdr.CallDate.Before(since) {
+						addHit(result[sinceIndex], cdr, index, prefix, groupBy)
+					}
+				}
+
+			}
+
+		}
+
+	}
+
+	if err := cdrs.Err(); err != nil {
+		log.LogS("ERROR", "An error ocurred while going through the CDRs")
+		return nil, err
+	}
+
+	log.LogS("INFO", "Results: Suitable: "+strconv.Itoa(numberOfCDRsSuitable)+", Matched: "+strconv.Itoa(numberOfCDRsMatched)+", Total: "+strconv.Itoa(numberOfCDRsTotal))
+
+	return result, nil
+
+}
+
+// newCDRFromFields Makes a CDR out of something that has its fields by name (e.g. a JSON object), "value" returns the value of each of
+// the CDR fields (see CDRFieldCallDate) as text, or an empty string if there's none. "calldate" can also be seconds since the epoch
+func newCDRFromFields(value func(field string) string) (*CDR, error) {
+
+	callDate, err := parseCDRTimeOrEpoch(value(CDRFieldCallDate))
+	if err != nil {
+		return nil, err
+	}
+
+	duration, err := parseCDRSeconds(value(CDRFieldDuration))
+	if err != nil {
+		return nil, err
+	}
+
+	billSec, err := parseCDRSeconds(value(CDRFieldBillSec))
+	if err != nil {
+		return nil, err
+	}
+
+	cdr := new(CDR)
+	cdr.CallDate = callDate
+	cdr.CLID = value(CDRFieldCLID)
+	cdr.Src = value(CDRFieldSrc)
+	cdr.Dst = value(CDRFieldDst)
+	cdr.DContext = value(CDRFieldDContext)
+	cdr.Channel = value(CDRFieldChannel)
+	cdr.DstChannel = value(CDRFieldDstChannel)
+	cdr.LastApp = value(CDRFieldLastApp)
+	cdr.LastData = value(CDRFieldLastData)
+	cdr.Duration = duration
+	cdr.BillSec = billSec
+	cdr.Disposition = value(CDRFieldDisposition)
+	cdr.AMAFlags = value(CDRFieldAMAFlags)
+	cdr.AccountCode = value(CDRFieldAccountCode)
+	cdr.UniqueID = value(CDRFieldUniqueID)
+	cdr.UserField = value(CDRFieldUserField)
+
+	return cdr, nil
+
+}
+
+// jsonFieldString Returns JSON strings and numbers as strings, anything else (e.g. null) is an empty string
+func jsonFieldString(value interface{}) string {
+
+	switch value := value.(type) {
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	}
+
+	return ""
+
+}
+
+// secondsBetween Whole seconds from "from" to "to", CDR durations are never negative
+func secondsBetween(from time.Time, to time.Time) uint32 {
+
+	if to.Before(from) {
+		return 0
+	}
+
+	return uint32(to.Sub(from) / time.Second)
+
+}
+
+func parseCDRTime(value string) (time.Time, error) {
+
+	return parseCDRTimeInLocation(value, time.Local)
+
+}
+
+// parseCDRTimeInLocation Like parseCDRTime but for times in "location" instead of local time
+func parseCDRTimeInLocation(value string, location *time.Location) (time.Time, error) {
+
+	for _, layout := range cdrTimeLayouts {
+		if parsed, err := time.ParseInLocation(layout, value, location); err == nil {
+			return parsed, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("could not parse CDR time \"%s\"", value)
+
+}
+
+// parseCDRTimeOrEpoch Like parseCDRTime but also takes seconds since the epoch
+func parseCDRTimeOrEpoch(value string) (time.Time, error) {
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+
+	return parseCDRTime(value)
+
+}
+
+func parseCDRSeconds(value string) (uint32, error) {
+
+	// NOTE: Some tables have nothing there for calls that were not answered
+	if value == "" {
+		return 0, nil
+	}
+
+	seconds, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		// NOTE: Some (e.g. v_xml_cdr) have "numeric" columns that come as "12.000"
+		secondsFloat, errFloat := strconv.ParseFloat(value, 64)
+		if errFloat != nil || secondsFloat < 0 {
+			return 0, fmt.Errorf("could not parse CDR seconds \"%s\"", value)
+		}
+		return uint32(secondsFloat), nil
+	}
+
+	return uint32(seconds), nil
+
+}
+
+// cdrsByCallDateDescending Sorts CDRs the way Database CDRs Sources return them, the most recent first
+type cdrsByCallDateDescending []*CDR
+
+func (cdrs cdrsByCallDateDescending) Len() int {
+	return len(cdrs)
+}
+
+func (cdrs cdrsByCallDateDescending) Swap(i, j int) {
+	cdrs[i], cdrs[j] = cdrs[j], cdrs[i]
+}
+
+func (cdrs cdrsByCallDateDescending) Less(i, j int) bool {
+	return cdrs[i].CallDate.After(cdrs[j].CallDate)
+}
@@ -0,0 +1,34 @@
+package softswitches
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetHitsInWindows(t *testing.T) {
+
+	now := time.Now()
+
+	softswitch := new(recordingSoftswitch)
+	softswitch.write(now.Add(-30*time.Second), "1")
+	softswitch.write(now.Add(-5*time.Minute), "2")
+	softswitch.write(now.Add(-50*time.Minute), "3")
+	softswitch.write(now.Add(-2*time.Hour), "4")
+
+	hits, err := GetHitsInWindows(softswitch, matchesAll, []time.Duration{time.Minute, 10 * time.Minute, time.Hour}, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// NOTE: The CDRs are asked for once, for the longest window
+	if len(softswitch.asked) != 1 || !approximately(softswitch.asked[0], now.Add(-time.Hour)) {
+		t.Fatalf("expected the CDRs to be asked for once from an hour ago, asked from %v", softswitch.asked)
+	}
+
+	for index, expected := range []uint32{1, 2, 3} {
+		if hits[index]["244"] == nil || hits[index]["244"].NumberOfHits != expected {
+			t.Errorf("expected %d Hits in window %d, got %+v", expected, index, hits[index])
+		}
+	}
+
+}
@@ -0,0 +1,99 @@
+package softswitches
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/andmar/marlog"
+)
+
+// CDRsCache Sits in front of a Softswitch and answers GetCDRs/GetHits from the CDRs it keeps in memory so that monitors looking at
+// overlapping time windows don't each hit the CDRs Source. CDRs are fetched (incrementally, see CDRsCursor) at most once every
+// RefreshInterval for the widest Window any monitor needs, everything else goes straight to the wrapped Softswitch
+type CDRsCache struct {
+	Softswitch
+	Window          time.Duration
+	RefreshInterval time.Duration
+	mutex           sync.Mutex
+	cursor          *CDRsCursor
+	cdrs            []*CDR
+	refreshedAt     time.Time
+}
+
+// NewCDRsCache ...
+func NewCDRsCache(softswitch Softswitch, window time.Duration, refreshInterval time.Duration) *CDRsCache {
+
+	cache := new(CDRsCache)
+	cache.Softswitch = softswitch
+	cache.Window = window
+	cache.RefreshInterval = refreshInterval
+	cache.cursor = NewCDRsCursor()
+
+	return cache
+
+}
+
+// GetHits ...
+func (cache *CDRsCache) GetHits(matches func(string, ...uint32) (string, bool, error), considerCDRsFromLast time.Duration, considerCallDuration bool, groupBy GroupBy) (map[string]*Hits, error) {
+	return getHits(cache, matches, considerCDRsFromLast, considerCallDuration, groupBy)
+}
+
+// GetCDRs Returns the cached CDRs started at or after "since", refreshing them first if they are older than RefreshInterval. If "since"
+// is before the start of the Window the cache can't answer and the wrapped Softswitch is asked directly
+func (cache *CDRsCache) GetCDRs(since time.Time) (CDRsIterator, error) {
+
+	log := marlog.MarLog
+
+	windowStart := time.Now().Add(-cache.Window)
+	if since.Before(windowStart) {
+		log.LogS("DEBUG", "CDRs requested from before the cached window, going to the Softswitch")
+		return cache.Softswitch.GetCDRs(since)
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if time.Since(cache.refreshedAt) >= cache.RefreshInterval {
+		if err := cache.refresh(windowStart); err != nil {
+			return nil, err
+		}
+	}
+
+	var cdrs []*CDR
+	for _, cdr := range cache.cdrs {
+		if !cdr.CallDate.Before(since) {
+			cdrs = append(cdrs, cdr)
+		}
+	}
+
+	return newCDRsIteratorSlice(cdrs), nil
+
+}
+
+func (cache *CDRsCache) refresh(windowStart time.Time) error {
+
+	log := marlog.MarLog
+
+	newCDRs, err := cache.cursor.Read(cache.Softswitch, windowStart)
+	if err != nil {
+		log.LogS("ERROR", "could not refresh the CDRs cache")
+		return err
+	}
+
+	// NOTE: Calls that were in progress on the last refresh come again, either still in progress or ended, with the new CDRs
+	kept := cache.cdrs[:0]
+	for _, cdr := range cache.cdrs {
+		if !cdr.CallDate.Before(windowStart) && !cdr.InProgress {
+			kept = append(kept, cdr)
+		}
+	}
+
+	cache.cdrs = append(kept, newCDRs...)
+	cache.refreshedAt = time.Now()
+
+	log.LogS("DEBUG", "CDRs cache refreshed with "+strconv.Itoa(len(newCDRs))+" new CDRs, "+strconv.Itoa(len(cache.cdrs))+" CDRs cached")
+
+	return nil
+
+}
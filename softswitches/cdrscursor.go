@@ -0,0 +1,104 @@
+package softswitches
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/andmar/marlog"
+)
+
+const (
+	// DefaultCDRsCursorLookback ...
+	DefaultCDRsCursorLookback = 2 * time.Hour
+)
+
+// CDRsCursor High-water mark of the CDRs already read from a Softswitch, the latest "calldate" seen plus the CDRs (by "uniqueid") seen
+// since Lookback before it. CDRs are written when calls end but their "calldate" is when they started, so a long call's CDR shows up
+// after CDRs of calls that started later, Lookback is how far back from the high-water mark we look again to catch those. CDRs of calls
+// that are InProgress are never seen, every Read returns the ones there are at the time and whoever Reads has to replace the ones it got
+// before with them
+type CDRsCursor struct {
+	CallDate time.Time
+	Lookback time.Duration
+	seen     map[string]time.Time
+}
+
+// NewCDRsCursor ...
+func NewCDRsCursor() *CDRsCursor {
+
+	cursor := new(CDRsCursor)
+	cursor.Lookback = DefaultCDRsCursorLookback
+	cursor.seen = make(map[string]time.Time)
+
+	return cursor
+
+}
+
+// Read Gets from "softswitch" the CDRs started at or after "since" that the cursor has not seen yet and moves the cursor forward, the
+// first Read gets all CDRs since "since", the following ones only ask the Softswitch for CDRs from Lookback before the high-water mark
+// (or since "since" if that's later, a CDR of a call that started before "since" is of no use whatever time it was written at)
+func (cursor *CDRsCursor) Read(softswitch Softswitch, since time.Time) ([]*CDR, error) {
+
+	log := marlog.MarLog
+
+	from := since
+	if !cursor.CallDate.IsZero() {
+		if lookbackFrom := cursor.CallDate.Add(-cursor.Lookback); lookbackFrom.After(from) {
+			from = lookbackFrom
+		}
+	}
+
+	cdrs, err := softswitch.GetCDRs(from)
+	if err != nil {
+		return nil, err
+	}
+
+	defer cdrs.Close()
+
+	var newCDRs []*CDR
+
+	for cdrs.Next() {
+
+		cdr := cdrs.CDR()
+
+		if cdr.InProgress {
+			newCDRs = append(newCDRs, cdr)
+			continue
+		}
+
+		key := cdrKey(cdr)
+		if _, seen := cursor.seen[key]; seen {
+			continue
+		}
+
+		cursor.seen[key] = cdr.CallDate
+		newCDRs = append(newCDRs, cdr)
+
+		if cdr.CallDate.After(cursor.CallDate) {
+			cursor.CallDate = cdr.CallDate
+		}
+
+	}
+
+	if err := cdrs.Err(); err != nil {
+		return nil, err
+	}
+
+	// NOTE: CDRs from before the next Read's "from" won't be asked for again so there's no need to remember them
+	forgetBefore := cursor.CallDate.Add(-cursor.Lookback)
+	for key, callDate := range cursor.seen {
+		if callDate.Before(forgetBefore) || callDate.Before(since) {
+			delete(cursor.seen, key)
+		}
+	}
+
+	log.LogS("DEBUG", "CDRs Cursor read "+strconv.Itoa(len(newCDRs))+" new CDRs, high-water mark is now "+cursor.CallDate.String())
+
+	return newCDRs, nil
+
+}
+
+// cdrKey Identifies a CDR, "uniqueid" alone is not enough since Asterisk writes more than one CDR per channel (e.g. on transfers)
+func cdrKey(cdr *CDR) string {
+	return cdr.UniqueID + "|" + cdr.CallDate.String() + "|" + cdr.Channel + "|" + cdr.DstChannel + "|" + cdr.Dst
+}
@@ -0,0 +1,226 @@
+package softswitches
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"database/sql"
+
+	"github.com/andmar/marlog"
+)
+
+// CDRsSourceAccounting Reads the accounting tables written by SIP proxies (Kamailio's and OpenSIPS's "acc" module), one row per
+// transaction instead of one per call: the INVITE (at the 200 OK) and the BYE of answered calls in "acc" and, if MissedCallsTableName is
+// set, the failed INVITEs in "missed_calls". Rows are mapped to CDR fields like CDRsSourceDatabase does (see KamailioCDRColumnMap), only the
+// INVITEs are returned as CDRs, the BYEs are how their Duration and BillSec are known when those are not mapped to columns. If CDRsTableName
+// is set (e.g. Kamailio's "acc_cdrs" with "callid=$ci" in "cdr_extra") durations are read from there instead. Answered calls without a BYE
+// yet are InProgress
+type CDRsSourceAccounting struct {
+	CDRsSourceDatabase
+	MissedCallsTableName string
+	CDRsTableName        string
+}
+
+// GetCDRs ...
+func (cdrSource *CDRsSourceAccounting) GetCDRs(since time.Time) (CDRsIterator, error) {
+
+	log := marlog.MarLog
+
+	if err := cdrSource.GetConnections().Ping(); err != nil {
+		return nil, err
+	}
+
+	log.LogS("DEBUG", "Database connection is A-Ok!")
+
+	rows, err := cdrSource.selectRows(cdrSource.getAccTableName(), since)
+	if err != nil {
+		return nil, err
+	}
+
+	var missedRows []*CDR
+	if cdrSource.MissedCallsTableName != "" {
+		missedRows, err = cdrSource.selectRows(cdrSource.MissedCallsTableName, since)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var durations map[string]uint32
+	if cdrSource.CDRsTableName != "" {
+		durations, err = cdrSource.selectDurations(since)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cdrs := getAccountingCDRs(rows, missedRows, durations, time.Now())
+
+	log.LogS("DEBUG", "Made "+strconv.Itoa(len(cdrs))+" CDRs out of "+strconv.Itoa(len(rows)+len(missedRows))+" accounting rows")
+
+	return newCDRsIteratorSlice(cdrs), nil
+
+}
+
+// getAccountingCDRs Makes the CDRs out of the rows of the "acc" and "missed_calls" tables, ordered by "calldate" like CDRsSourceDatabase
+// does, "durations" are the ones in the CDRs table by Call-ID (nil if it's not read). Answered calls without a BYE yet are in progress until
+// "now"
+func getAccountingCDRs(rows []*CDR, missedRows []*CDR, durations map[string]uint32, now time.Time) []*CDR {
+
+	// NOTE: In-dialog requests have the INVITE's Call-ID, the last BYE is the one that ended the call and the first INVITE is the one that
+	// started it, the others are re-INVITEs. Without "callid" mapped to a column each row is a call of its own
+	endedAt := make(map[string]time.Time)
+	startedAt := make(map[string]time.Time)
+	for _, row := range rows {
+		if row.UniqueID == "" {
+			continue
+		}
+		if isAccountingMethod(row, "BYE") && row.CallDate.After(endedAt[row.UniqueID]) {
+			endedAt[row.UniqueID] = row.CallDate
+		}
+		if first, found := startedAt[row.UniqueID]; isAccountingMethod(row, "INVITE") && (!found || row.CallDate.Before(first)) {
+			startedAt[row.UniqueID] = row.CallDate
+		}
+	}
+
+	var cdrs []*CDR
+
+	for _, row := range rows {
+
+		if !isAccountingMethod(row, "INVITE") || (row.UniqueID != "" && !row.CallDate.Equal(startedAt[row.UniqueID])) {
+			continue
+		}
+
+		// NOTE: Durations mapped to columns (e.g. OpenSIPS's "duration" with the CDR flag on) win over everything else
+		if row.Duration == 0 && row.BillSec == 0 {
+
+			if duration, found := durations[row.UniqueID]; found {
+				row.Duration = duration
+				row.BillSec = duration
+			} else if byeAt, found := endedAt[row.UniqueID]; found {
+				row.Duration = secondsBetween(row.CallDate, byeAt)
+				row.BillSec = row.Duration
+			} else if strings.HasPrefix(row.Disposition, "2") {
+				row.Duration = secondsBetween(row.CallDate, now)
+				row.BillSec = row.Duration
+				row.InProgress = true
+			}
+
+		}
+
+		cdrs = append(cdrs, row)
+
+	}
+
+	// NOTE: Failed INVITEs are calls that ended without ever being answered
+	for _, row := range missedRows {
+		if isAccountingMethod(row, "INVITE") {
+			cdrs = append(cdrs, row)
+		}
+	}
+
+	sort.Sort(cdrsByCallDateDescending(cdrs))
+
+	return cdrs
+
+}
+
+// selectRows Reads the rows of one of the accounting tables, all of them have the same layout
+func (cdrSource *CDRsSourceAccounting) selectRows(tableName string, since time.Time) ([]*CDR, error) {
+
+	log := marlog.MarLog
+
+	table := cdrSource.CDRsSourceDatabase
+	table.TableName = tableName
+
+	rows, err := table.SelectCDRs(cdrFields, since)
+	if err != nil {
+		log.LogS("ERROR", "could not query the database")
+		return nil, err
+	}
+
+	iterator := &cdrsIteratorDatabase{rows: rows, location: table.getLocation()}
+	defer iterator.Close()
+
+	var cdrs []*CDR
+	for iterator.Next() {
+		cdrs = append(cdrs, iterator.CDR())
+	}
+
+	if err := iterator.Err(); err != nil {
+		return nil, err
+	}
+
+	return cdrs, nil
+
+}
+
+// selectDurations Reads, by Call-ID, the durations of the calls started at or after "since" from the CDRs table, which has to have
+// "callid", "start_time" and "duration" columns
+func (cdrSource *CDRsSourceAccounting) selectDurations(since time.Time) (map[string]uint32, error) {
+
+	log := marlog.MarLog
+
+	sinceCondition, sinceValue, err := cdrSource.SinceCondition("start_time", since)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := cdrSource.GetConnections().Query(fmt.Sprintf("SELECT callid, duration FROM %s WHERE %s;", cdrSource.CDRsTableName, sinceCondition), sinceValue)
+	if err != nil {
+		log.LogS("ERROR", "could not query the database")
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	durations := make(map[string]uint32)
+
+	for rows.Next() {
+
+		var callID, duration sql.NullString
+		if err := rows.Scan(&callID, &duration); err != nil {
+			log.LogS("ERROR", "Could not bring query results to variables ("+err.Error()+")")
+			continue
+		}
+
+		seconds, err := parseCDRSeconds(duration.String)
+		if err != nil {
+			log.LogS("ERROR", "Could not get the duration of call \""+callID.String+"\" ("+err.Error()+")")
+			continue
+		}
+
+		durations[callID.String] = seconds
+
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return durations, nil
+
+}
+
+// isAccountingMethod If "method" is not mapped to a column there's no way to tell the rows apart, all of them are taken as INVITEs
+func isAccountingMethod(row *CDR, method string) bool {
+
+	if row.LastApp == "" {
+		return method == "INVITE"
+	}
+
+	return strings.EqualFold(row.LastApp, method)
+
+}
+
+func (cdrSource *CDRsSourceAccounting) getAccTableName() string {
+
+	if cdrSource.TableName == "" {
+		return "acc"
+	}
+
+	return cdrSource.TableName
+
+}
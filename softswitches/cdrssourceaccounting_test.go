@@ -0,0 +1,99 @@
+package softswitches
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetAccountingCDRs(t *testing.T) {
+
+	start := time.Date(2016, 7, 29, 10, 0, 0, 0, time.Local)
+
+	// NOTE: As Kamailio's "acc" module writes them, "method", "sip_code" and "callid" mapped to LastApp, Disposition and UniqueID
+	row := func(seconds int, method string, code string, callID string) *CDR {
+		return &CDR{CallDate: start.Add(time.Duration(seconds) * time.Second), Dst: "00244123456789", LastApp: method, Disposition: code, UniqueID: callID}
+	}
+
+	// NOTE: With OpenSIPS's "duration" column
+	withDuration := row(15, "INVITE", "200", "e")
+	withDuration.Duration = 30
+	withDuration.BillSec = 30
+
+	rows := []*CDR{
+		// NOTE: The BYE that ended the call and not the re-INVITE is the one that counts
+		row(0, "INVITE", "200", "a"),
+		row(10, "INVITE", "200", "b"),
+		withDuration,
+		row(20, "INVITE", "200", "d"),
+		row(30, "INVITE", "200", "a"),
+		row(45, "BYE", "200", "e"),
+		row(50, "BYE", "200", "d"),
+		row(62, "BYE", "200", "a"),
+		// NOTE: The INVITE was before the rows that were read
+		row(70, "BYE", "200", "f"),
+		// NOTE: Without "method" and "callid" every row is the INVITE of a call of its own
+		row(80, "", "200", ""),
+		row(90, "", "200", ""),
+	}
+
+	missedRows := []*CDR{
+		row(5, "INVITE", "486", "c"),
+		row(25, "INVITE", "404", "g"),
+		// NOTE: Failed in-dialog requests are not calls
+		row(35, "BYE", "481", "a"),
+	}
+
+	// NOTE: From Kamailio's "acc_cdrs"
+	durations := map[string]uint32{"d": 28}
+
+	cdrs := getAccountingCDRs(rows, missedRows, durations, start.Add(100*time.Second))
+
+	expected := []struct {
+		seconds     int
+		callID      string
+		disposition string
+		duration    uint32
+		inProgress  bool
+	}{
+		{90, "", "200", 10, true},
+		{80, "", "200", 20, true},
+		{25, "g", "404", 0, false},
+		{20, "d", "200", 28, false},
+		{15, "e", "200", 30, false},
+		// NOTE: Answered and no BYE yet
+		{10, "b", "200", 90, true},
+		{5, "c", "486", 0, false},
+		{0, "a", "200", 62, false},
+	}
+
+	if len(cdrs) != len(expected) {
+		t.Fatalf("expected %d CDRs, got %d", len(expected), len(cdrs))
+	}
+
+	for index, cdr := range cdrs {
+
+		test := expected[index]
+
+		if !cdr.CallDate.Equal(start.Add(time.Duration(test.seconds)*time.Second)) || cdr.UniqueID != test.callID || cdr.Disposition != test.disposition {
+			t.Fatalf("expected the INVITE of \"%s\" at %ds, got %+v", test.callID, test.seconds, cdr)
+		}
+
+		if cdr.Duration != test.duration || cdr.BillSec != test.duration || cdr.InProgress != test.inProgress {
+			t.Errorf("%s at %ds: expected %ds (in progress %v), got %ds/%ds (in progress %v)", test.callID, test.seconds, test.duration, test.inProgress, cdr.Duration, cdr.BillSec, cdr.InProgress)
+		}
+
+	}
+
+}
+
+func TestAccountingMethod(t *testing.T) {
+
+	if !isAccountingMethod(&CDR{LastApp: "invite"}, "INVITE") || isAccountingMethod(&CDR{LastApp: "BYE"}, "INVITE") {
+		t.Error("expected the method to be compared ignoring case")
+	}
+
+	if !isAccountingMethod(&CDR{}, "INVITE") || isAccountingMethod(&CDR{}, "BYE") {
+		t.Error("expected rows without a method to be INVITEs")
+	}
+
+}
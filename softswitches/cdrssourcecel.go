@@ -0,0 +1,283 @@
+package softswitches
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"database/sql"
+
+	"github.com/andmar/marlog"
+)
+
+const (
+	celEventChannelStart = "CHAN_START"
+	celEventAnswer       = "ANSWER"
+	celEventHangup       = "HANGUP"
+	celEventLinkedIDEnd  = "LINKEDID_END"
+	// NOTE: Indexes of the columns in "celColumns"
+	celColumnEventType   = 0
+	celColumnEventTime   = 1
+	celColumnCIDName     = 2
+	celColumnCIDNum      = 3
+	celColumnExten       = 4
+	celColumnContext     = 5
+	celColumnChanName    = 6
+	celColumnAppName     = 7
+	celColumnAppData     = 8
+	celColumnAMAFlags    = 9
+	celColumnAccountCode = 10
+	celColumnUniqueID    = 11
+	celColumnLinkedID    = 12
+	celColumnUserField   = 13
+)
+
+// NOTE: Columns of Asterisk's "cel" table, in the order they are selected
+var celColumns = []string{
+	"eventtype",
+	"eventtime",
+	"cid_name",
+	"cid_num",
+	"exten",
+	"context",
+	"channame",
+	"appname",
+	"appdata",
+	"amaflags",
+	"accountcode",
+	"uniqueid",
+	"linkedid",
+	"userfield",
+}
+
+// CDRsSourceCEL Makes CDRs out of Asterisk's Channel Event Logging table (cel_odbc, cel_pgsql, cel_sqlite3_custom, ...) instead of the
+// "cdr" table, which only has calls after they end. Events are grouped by "linkedid" into calls, each call is there from its first
+// channel's CHAN_START and, until that channel's HANGUP or the call's LINKEDID_END, is InProgress. It connects like CDRsSourceDatabase
+// does, TableName is "cel" if not set and ColumnMap is not used, "eventtype" has to be written as text (e.g. "CHAN_START")
+type CDRsSourceCEL struct {
+	CDRsSourceDatabase
+}
+
+// celCall What is known about a call from the events of its channels
+type celCall struct {
+	linkedID   string
+	started    bool
+	cdr        *CDR
+	startedAt  time.Time
+	answeredAt time.Time
+	// NOTE: The answer of the channel that was dialed, the first channel may have been answered by the dialplan (e.g. an IVR) long before
+	bridgedAt time.Time
+	endedAt   time.Time
+}
+
+// GetCDRs Gets the events of the calls started at or after "since" and returns a CDR for each call, ordered by "calldate" like
+// CDRsSourceDatabase does
+func (cdrSource *CDRsSourceCEL) GetCDRs(since time.Time) (CDRsIterator, error) {
+
+	log := marlog.MarLog
+
+	if err := cdrSource.GetConnections().Ping(); err != nil {
+		return nil, err
+	}
+
+	log.LogS("DEBUG", "Database connection is A-Ok!")
+
+	sinceCondition, sinceValue, err := cdrSource.SinceCondition("eventtime", since)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := cdrSource.GetConnections().Query(fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY eventtime;", strings.Join(celColumns, ", "), cdrSource.getCELTableName(), sinceCondition), sinceValue)
+	if err != nil {
+		log.LogS("ERROR", "could not query the database")
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	calls := make(map[string]*celCall)
+
+	for rows.Next() {
+
+		var eventTime interface{}
+		values := make([]sql.NullString, len(celColumns))
+
+		destinations := make([]interface{}, len(celColumns))
+		for index := range values {
+			destinations[index] = &values[index]
+		}
+		// NOTE: Depending on the driver it's a time.Time or text, see parseDatabaseTime
+		destinations[celColumnEventTime] = &eventTime
+
+		if err := rows.Scan(destinations...); err != nil {
+			log.LogS("ERROR", "Could not bring query results to variables ("+err.Error()+")")
+			continue
+		}
+
+		parsedEventTime, err := parseDatabaseTime(eventTime, cdrSource.getLocation())
+		if err != nil {
+			log.LogS("ERROR", "Could not convert the \"eventtime\" of a CEL event ("+err.Error()+")")
+			continue
+		}
+
+		addCELEvent(calls, parsedEventTime, values)
+
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	cdrs := getCELCDRs(calls, time.Now())
+
+	log.LogS("DEBUG", "Made "+strconv.Itoa(len(cdrs))+" CDRs out of the CEL events of "+strconv.Itoa(len(calls))+" calls")
+
+	return newCDRsIteratorSlice(cdrs), nil
+
+}
+
+// addCELEvent Adds an event to the call in "calls" of its "linkedid", "values" are the columns in the order of "celColumns" (the one of
+// "eventtime" is not used, that's "eventTime")
+func addCELEvent(calls map[string]*celCall, eventTime time.Time, values []sql.NullString) {
+
+	linkedID := values[celColumnLinkedID].String
+
+	call, found := calls[linkedID]
+	if found == false {
+		call = &celCall{linkedID: linkedID, cdr: new(CDR)}
+		calls[linkedID] = call
+	}
+
+	call.addEvent(eventTime, values)
+
+}
+
+// getCELCDRs Returns the CDRs of "calls", ordered by "calldate" like CDRsSourceDatabase does
+func getCELCDRs(calls map[string]*celCall, now time.Time) []*CDR {
+
+	var cdrs []*CDR
+	for _, call := range calls {
+		// NOTE: Calls started before "since" only have some of their events here
+		if call.started == false {
+			continue
+		}
+		cdrs = append(cdrs, call.getCDR(now))
+	}
+
+	sort.Sort(cdrsByCallDateDescending(cdrs))
+
+	return cdrs
+
+}
+
+// addEvent Adds to the call an event of one of its channels, "values" are as in addCELEvent. The channel the call started on (the one
+// whose "uniqueid" is the "linkedid") is the CDR's "channel", the first other one is its "dstchannel"
+func (call *celCall) addEvent(eventTime time.Time, values []sql.NullString) {
+
+	channel := values[celColumnChanName].String
+	uniqueID := values[celColumnUniqueID].String
+	isFirstChannel := uniqueID == call.linkedID
+
+	switch values[celColumnEventType].String {
+	case celEventChannelStart:
+
+		if isFirstChannel {
+			call.started = true
+			call.startedAt = eventTime
+			call.cdr.CallDate = eventTime
+			call.cdr.CLID = celCallerID(values[celColumnCIDName].String, values[celColumnCIDNum].String)
+			call.cdr.Src = values[celColumnCIDNum].String
+			call.cdr.Dst = values[celColumnExten].String
+			call.cdr.DContext = values[celColumnContext].String
+			call.cdr.Channel = channel
+			call.cdr.AMAFlags = values[celColumnAMAFlags].String
+			call.cdr.AccountCode = values[celColumnAccountCode].String
+			call.cdr.UniqueID = uniqueID
+			call.cdr.UserField = values[celColumnUserField].String
+		} else if call.cdr.DstChannel == "" {
+			call.cdr.DstChannel = channel
+		}
+
+	case celEventAnswer:
+
+		if isFirstChannel {
+			if call.answeredAt.IsZero() {
+				call.answeredAt = eventTime
+			}
+		} else if call.bridgedAt.IsZero() {
+			call.bridgedAt = eventTime
+		}
+
+	case celEventHangup:
+
+		if isFirstChannel {
+			call.endedAt = eventTime
+		}
+
+	case celEventLinkedIDEnd:
+
+		if call.endedAt.IsZero() {
+			call.endedAt = eventTime
+		}
+
+	}
+
+	// NOTE: Like "lastapp" in the "cdr" table but once Dial is seen it stays, what the dialplan does after it (e.g. Hangup()) is not what
+	// was dialed. Events only have Dial in "appname" after it has started (e.g. on the ANSWER), APP_START events (apps=dial in cel.conf)
+	// make calls that are still ringing have it too
+	if isFirstChannel && values[celColumnAppName].String != "" && call.cdr.LastApp != "Dial" {
+		call.cdr.LastApp = values[celColumnAppName].String
+		call.cdr.LastData = values[celColumnAppData].String
+	}
+
+}
+
+// getCDR Returns the call's CDR, for calls that did not end yet Duration and BillSec are up to "now"
+func (call *celCall) getCDR(now time.Time) *CDR {
+
+	cdr := *call.cdr
+
+	endedAt := call.endedAt
+	if endedAt.IsZero() {
+		endedAt = now
+		cdr.InProgress = true
+	}
+
+	answeredAt := call.bridgedAt
+	if answeredAt.IsZero() {
+		answeredAt = call.answeredAt
+	}
+
+	cdr.Duration = secondsBetween(call.startedAt, endedAt)
+	cdr.Disposition = "NO ANSWER"
+	if !answeredAt.IsZero() {
+		cdr.BillSec = secondsBetween(answeredAt, endedAt)
+		cdr.Disposition = "ANSWERED"
+	}
+
+	return &cdr
+
+}
+
+func (cdrSource *CDRsSourceCEL) getCELTableName() string {
+
+	if cdrSource.TableName == "" {
+		return "cel"
+	}
+
+	return cdrSource.TableName
+
+}
+
+// celCallerID Puts the caller ID together the way the "clid" CDR field has it, "Name" <number>
+func celCallerID(name string, number string) string {
+
+	if name == "" {
+		return number
+	}
+
+	return "\"" + name + "\" <" + number + ">"
+
+}
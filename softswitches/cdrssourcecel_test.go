@@ -0,0 +1,126 @@
+package softswitches
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// celTestEvent A row of the "cel" table, "seconds" after "celTestStart", of a call made by "John Doe" <1000> to 00244123456789
+type celTestEvent struct {
+	seconds   int
+	eventType string
+	uniqueID  string
+	linkedID  string
+	channel   string
+	appName   string
+	appData   string
+}
+
+var celTestStart = time.Date(2016, 7, 29, 10, 0, 0, 0, time.Local)
+
+func (event celTestEvent) values() []sql.NullString {
+
+	values := make([]sql.NullString, len(celColumns))
+
+	set := func(column int, value string) {
+		values[column] = sql.NullString{String: value, Valid: true}
+	}
+
+	set(celColumnEventType, event.eventType)
+	set(celColumnCIDName, "John Doe")
+	set(celColumnCIDNum, "1000")
+	set(celColumnExten, "00244123456789")
+	set(celColumnContext, "from-internal")
+	set(celColumnChanName, event.channel)
+	set(celColumnAppName, event.appName)
+	set(celColumnAppData, event.appData)
+	set(celColumnAMAFlags, "DOCUMENTATION")
+	set(celColumnAccountCode, "acme")
+	set(celColumnUniqueID, event.uniqueID)
+	set(celColumnLinkedID, event.linkedID)
+	set(celColumnUserField, "")
+
+	return values
+
+}
+
+func TestCELCalls(t *testing.T) {
+
+	events := []celTestEvent{
+		// NOTE: Answered by the dialed channel, after Dial the dialplan runs Hangup()
+		{0, celEventChannelStart, "1469786400.1", "1469786400.1", "SIP/1000-00000001", "", ""},
+		{1, "APP_START", "1469786400.1", "1469786400.1", "SIP/1000-00000001", "Dial", "SIP/trunk/00244123456789,60"},
+		{1, celEventChannelStart, "1469786400.2", "1469786400.1", "SIP/trunk-00000002", "", ""},
+		// NOTE: An IVR, answered by the dialplan and then dialing out
+		{5, celEventChannelStart, "1469786405.3", "1469786405.3", "SIP/1001-00000003", "", ""},
+		{6, celEventAnswer, "1469786405.3", "1469786405.3", "SIP/1001-00000003", "Answer", ""},
+		// NOTE: Only answered by the dialplan
+		{8, celEventChannelStart, "1469786408.5", "1469786408.5", "SIP/1002-00000005", "", ""},
+		{9, celEventAnswer, "1469786408.5", "1469786408.5", "SIP/1002-00000005", "Answer", ""},
+		{10, celEventAnswer, "1469786400.2", "1469786400.1", "SIP/trunk-00000002", "AppDial", "(Outgoing Line)"},
+		{10, celEventAnswer, "1469786400.1", "1469786400.1", "SIP/1000-00000001", "Dial", "SIP/trunk/00244123456789,60"},
+		// NOTE: Still ringing
+		{10, celEventChannelStart, "1469786410.6", "1469786410.6", "SIP/1003-00000006", "", ""},
+		{11, "APP_START", "1469786410.6", "1469786410.6", "SIP/1003-00000006", "Dial", "SIP/trunk/00244123456789"},
+		{11, celEventChannelStart, "1469786410.7", "1469786410.6", "SIP/trunk-00000007", "", ""},
+		{12, "APP_START", "1469786408.5", "1469786408.5", "SIP/1002-00000005", "Playback", "welcome"},
+		// NOTE: Still up after the dialed channel hung up
+		{12, celEventChannelStart, "1469786412.8", "1469786412.8", "SIP/1004-00000008", "", ""},
+		{13, celEventChannelStart, "1469786412.9", "1469786412.8", "SIP/trunk-00000009", "", ""},
+		{14, celEventAnswer, "1469786412.9", "1469786412.8", "SIP/trunk-00000009", "AppDial", "(Outgoing Line)"},
+		{14, celEventAnswer, "1469786412.8", "1469786412.8", "SIP/1004-00000008", "Dial", "SIP/trunk/00244123456789"},
+		{20, "APP_START", "1469786405.3", "1469786405.3", "SIP/1001-00000003", "Dial", "SIP/trunk/00244123456789"},
+		{20, celEventHangup, "1469786412.9", "1469786412.8", "SIP/trunk-00000009", "AppDial", "(Outgoing Line)"},
+		{21, celEventChannelStart, "1469786405.4", "1469786405.3", "SIP/trunk-00000004", "", ""},
+		{23, celEventHangup, "1469786408.5", "1469786408.5", "SIP/1002-00000005", "Playback", "welcome"},
+		{23, celEventLinkedIDEnd, "1469786408.5", "1469786408.5", "SIP/1002-00000005", "Playback", "welcome"},
+		// NOTE: Started before the events that were read, so it's left out
+		{25, celEventHangup, "1469786300.10", "1469786300.10", "SIP/1005-0000000a", "Dial", "SIP/trunk/00244123456789"},
+		{30, celEventAnswer, "1469786405.4", "1469786405.3", "SIP/trunk-00000004", "AppDial", "(Outgoing Line)"},
+		{30, celEventAnswer, "1469786405.3", "1469786405.3", "SIP/1001-00000003", "Dial", "SIP/trunk/00244123456789"},
+		{45, celEventHangup, "1469786405.3", "1469786405.3", "SIP/1001-00000003", "Dial", "SIP/trunk/00244123456789"},
+		{45, celEventHangup, "1469786405.4", "1469786405.3", "SIP/trunk-00000004", "AppDial", "(Outgoing Line)"},
+		{52, celEventHangup, "1469786400.2", "1469786400.1", "SIP/trunk-00000002", "AppDial", "(Outgoing Line)"},
+		{52, celEventHangup, "1469786400.1", "1469786400.1", "SIP/1000-00000001", "Hangup", ""},
+		{52, celEventLinkedIDEnd, "1469786400.1", "1469786400.1", "SIP/1000-00000001", "Hangup", ""},
+	}
+
+	calls := make(map[string]*celCall)
+	for _, event := range events {
+		addCELEvent(calls, celTestStart.Add(time.Duration(event.seconds)*time.Second), event.values())
+	}
+
+	cdrs := getCELCDRs(calls, celTestStart.Add(60*time.Second))
+
+	expected := []CDR{
+		{CallDate: celTestStart.Add(12 * time.Second), UniqueID: "1469786412.8", Channel: "SIP/1004-00000008", DstChannel: "SIP/trunk-00000009", LastApp: "Dial", LastData: "SIP/trunk/00244123456789", Duration: 48, BillSec: 46, Disposition: "ANSWERED", InProgress: true},
+		{CallDate: celTestStart.Add(10 * time.Second), UniqueID: "1469786410.6", Channel: "SIP/1003-00000006", DstChannel: "SIP/trunk-00000007", LastApp: "Dial", LastData: "SIP/trunk/00244123456789", Duration: 50, Disposition: "NO ANSWER", InProgress: true},
+		{CallDate: celTestStart.Add(8 * time.Second), UniqueID: "1469786408.5", Channel: "SIP/1002-00000005", LastApp: "Playback", LastData: "welcome", Duration: 15, BillSec: 14, Disposition: "ANSWERED"},
+		// NOTE: Billed from when the dialed channel answered, not from when the IVR did
+		{CallDate: celTestStart.Add(5 * time.Second), UniqueID: "1469786405.3", Channel: "SIP/1001-00000003", DstChannel: "SIP/trunk-00000004", LastApp: "Dial", LastData: "SIP/trunk/00244123456789", Duration: 40, BillSec: 15, Disposition: "ANSWERED"},
+		{CallDate: celTestStart, UniqueID: "1469786400.1", Channel: "SIP/1000-00000001", DstChannel: "SIP/trunk-00000002", LastApp: "Dial", LastData: "SIP/trunk/00244123456789,60", Duration: 52, BillSec: 42, Disposition: "ANSWERED"},
+	}
+
+	if len(cdrs) != len(expected) {
+		t.Fatalf("expected %d CDRs, got %d", len(expected), len(cdrs))
+	}
+
+	for index, cdr := range cdrs {
+
+		expectedCDR := expected[index]
+		expectedCDR.CLID = "\"John Doe\" <1000>"
+		expectedCDR.Src = "1000"
+		expectedCDR.Dst = "00244123456789"
+		expectedCDR.DContext = "from-internal"
+		expectedCDR.AMAFlags = "DOCUMENTATION"
+		expectedCDR.AccountCode = "acme"
+
+		if !reflect.DeepEqual(*cdr, expectedCDR) {
+			t.Errorf("expected %+v, got %+v", expectedCDR, *cdr)
+		}
+
+	}
+
+}
@@ -0,0 +1,350 @@
+package softswitches
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"compress/gzip"
+	"encoding/csv"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/andmar/marlog"
+)
+
+const (
+	// NOTE: Column layout of Asterisk's cdr_csv (Master.csv), "uniqueid" and "userfield" are only there if "loguniqueid" and
+	// "loguserfield" are enabled in cdr.conf so we can't count on them
+	csvFileColumnAccountCode = iota
+	csvFileColumnSrc
+	csvFileColumnDst
+	csvFileColumnDContext
+	csvFileColumnCLID
+	csvFileColumnChannel
+	csvFileColumnDstChannel
+	csvFileColumnLastApp
+	csvFileColumnLastData
+	csvFileColumnStart
+	csvFileColumnAnswer
+	csvFileColumnEnd
+	csvFileColumnDuration
+	csvFileColumnBillSec
+	csvFileColumnDisposition
+	csvFileColumnAMAFlags
+	csvFileColumnUniqueID
+	csvFileColumnUserField
+)
+
+const (
+	csvFileMinimumColumns = csvFileColumnAMAFlags + 1
+	csvFileTimeLayout     = "2006-01-02 15:04:05"
+)
+
+// CDRsSourceCSVFile Reads the CDRs of Asterisk's cdr_csv, UseGMTime has to be what "usegmtime" is in the [csv] section of cdr.conf. The
+// CDRs of each file are kept once read, on the next GetCDRs only what was appended to it since is read
+type CDRsSourceCSVFile struct {
+	FilePath  string
+	UseGMTime bool
+	mutex     sync.Mutex
+	files     map[string]*csvFileRead
+	// NOTE: The longest ago GetCDRs was asked for CDRs from, kept CDRs older than that are dropped
+	lookback time.Duration
+}
+
+// csvFileRead What was read of a file, the CDRs started at or after "from" in its first "offset" bytes
+type csvFileRead struct {
+	info   os.FileInfo
+	offset int64
+	lines  int
+	from   time.Time
+	cdrs   []*CDR
+}
+
+// GetCDRs Reads the CDRs started at or after "since" from the CSV file and from its rotated versions (e.g. Master.csv.1, Master.csv.2.gz,
+// Master.csv-20160801)
+func (cdrSource *CDRsSourceCSVFile) GetCDRs(since time.Time) (CDRsIterator, error) {
+
+	log := marlog.MarLog
+
+	cdrSource.mutex.Lock()
+	defer cdrSource.mutex.Unlock()
+
+	now := time.Now()
+	if now.Sub(since) > cdrSource.lookback {
+		cdrSource.lookback = now.Sub(since)
+	}
+
+	filePaths, err := cdrSource.getFilePaths(since)
+	if err != nil {
+		return nil, err
+	}
+
+	// NOTE: Files that are not listed anymore (e.g. rotated away, too old) are forgotten
+	files := make(map[string]*csvFileRead)
+
+	var cdrs []*CDR
+
+	for _, filePath := range filePaths {
+
+		fileRead, err := cdrSource.readFile(filePath, cdrSource.files[filePath], since, now.Add(-cdrSource.lookback))
+		if err != nil {
+			// NOTE: The file may have been rotated away between listing and opening it, that's not a reason to fail the whole read
+			if os.IsNotExist(err) && filePath != cdrSource.FilePath {
+				continue
+			}
+			return nil, err
+		}
+
+		files[filePath] = fileRead
+
+		for _, cdr := range fileRead.cdrs {
+			if !cdr.CallDate.Before(since) {
+				// NOTE: Softswitches set DialedNumbers on what they get from the Source, they can't be setting them on the kept CDRs
+				copied := *cdr
+				cdrs = append(cdrs, &copied)
+			}
+		}
+
+	}
+
+	cdrSource.files = files
+
+	log.LogS("DEBUG", "Read "+strconv.Itoa(len(cdrs))+" CDRs from "+strconv.Itoa(len(files))+" CSV files")
+
+	return newCDRsIteratorSlice(cdrs), nil
+
+}
+
+// readFile Returns "previous" (what was read of the file at "filePath" on the last GetCDRs) with what was appended to the file since then,
+// the file is read from the start if it was replaced, truncated or "previous" doesn't go back to "since". CDRs started before "keepFrom"
+// are dropped
+func (cdrSource *CDRsSourceCSVFile) readFile(filePath string, previous *csvFileRead, since time.Time, keepFrom time.Time) (*csvFileRead, error) {
+
+	log := marlog.MarLog
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// NOTE: Compressed files can't be read from the middle, they are not appended to anyway
+	compressed := strings.HasSuffix(filePath, ".gz")
+
+	// NOTE: A file that changed without growing was written over (e.g. truncated by logrotate's "copytruncate" and written to again)
+	rewritten := previous != nil && info.Size() == previous.info.Size() && !info.ModTime().Equal(previous.info.ModTime())
+
+	fileRead := previous
+	if previous == nil || previous.from.After(since) || !os.SameFile(previous.info, info) || info.Size() < previous.offset || rewritten || (compressed && info.Size() != previous.offset) {
+		fileRead = &csvFileRead{from: keepFrom}
+	}
+
+	fileRead.info = info
+
+	if fileRead.offset < info.Size() {
+
+		log.LogS("DEBUG", "Reading CDRs from \""+filePath+"\" from byte "+strconv.FormatInt(fileRead.offset, 10)+"...")
+
+		location := time.Local
+		if cdrSource.UseGMTime {
+			location = time.UTC
+		}
+
+		cdrs, read, lines, err := readCSVFileCDRs(filePath, compressed, fileRead.offset, fileRead.lines, fileRead.from, location)
+		if err != nil {
+			return nil, err
+		}
+
+		fileRead.cdrs = append(fileRead.cdrs, cdrs...)
+		fileRead.offset += read
+		fileRead.lines = lines
+
+	}
+
+	if fileRead.from.Before(keepFrom) {
+
+		kept := make([]*CDR, 0, len(fileRead.cdrs))
+		for _, cdr := range fileRead.cdrs {
+			if !cdr.CallDate.Before(keepFrom) {
+				kept = append(kept, cdr)
+			}
+		}
+
+		fileRead.cdrs = kept
+		fileRead.from = keepFrom
+
+	}
+
+	return fileRead, nil
+
+}
+
+// getFilePaths Lists the CSV file and its rotated versions that may have CDRs started at or after "since", since CDRs are
+// written when calls end, files last modified before "since" can be skipped
+func (cdrSource *CDRsSourceCSVFile) getFilePaths(since time.Time) ([]string, error) {
+
+	var rotated []string
+	for _, pattern := range []string{cdrSource.FilePath + ".*", cdrSource.FilePath + "-*"} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		rotated = append(rotated, matches...)
+	}
+
+	sort.Strings(rotated)
+
+	filePaths := []string{cdrSource.FilePath}
+	for _, filePath := range rotated {
+
+		info, err := os.Stat(filePath)
+		if err != nil || info.IsDir() || info.ModTime().Before(since) {
+			continue
+		}
+
+		filePaths = append(filePaths, filePath)
+
+	}
+
+	return filePaths, nil
+
+}
+
+// readCSVFileCDRs Reads the CDRs started at or after "since" from byte "offset" of the file at "filePath", where line "lines" ended, with
+// "start" in "location". Returns how many bytes were read, up to the end of the last whole line (the one being written may not be), and the
+// number of the last line read
+func readCSVFileCDRs(filePath string, compressed bool, offset int64, lines int, since time.Time, location *time.Location) ([]*CDR, int64, int, error) {
+
+	log := marlog.MarLog
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, 0, lines, err
+	}
+
+	defer file.Close()
+
+	var reader io.Reader = file
+	if compressed {
+		gzipReader, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, 0, lines, err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	} else if _, err := file.Seek(offset, os.SEEK_SET); err != nil {
+		return nil, 0, lines, err
+	}
+
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, 0, lines, err
+	}
+
+	read := int64(0)
+	if compressed {
+		if info, err := file.Stat(); err == nil {
+			read = info.Size()
+		}
+	} else {
+		data = data[:bytes.LastIndexByte(data, '\n')+1]
+		read = int64(len(data))
+	}
+
+	csvReader := csv.NewReader(bytes.NewReader(data))
+	// NOTE: The number of columns depends on cdr.conf and "clid" is something like "Name" <1234> which some versions don't quote properly
+	csvReader.FieldsPerRecord = -1
+	csvReader.LazyQuotes = true
+
+	var cdrs []*CDR
+
+	lineNumber := lines
+	for {
+
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		lineNumber++
+
+		if err != nil {
+			if _, ok := err.(*csv.ParseError); ok {
+				log.LogS("ERROR", "Could not parse line "+strconv.Itoa(lineNumber)+" of \""+filePath+"\" ("+err.Error()+")")
+				continue
+			}
+			return nil, 0, lines, err
+		}
+
+		if len(record) < csvFileMinimumColumns {
+			log.LogS("ERROR", "Line "+strconv.Itoa(lineNumber)+" of \""+filePath+"\" has weird item count: "+strconv.Itoa(len(record)))
+			continue
+		}
+
+		start, err := time.ParseInLocation(csvFileTimeLayout, record[csvFileColumnStart], location)
+		if err != nil {
+			log.LogS("ERROR", "Could not parse \"start\" value \""+record[csvFileColumnStart]+"\" on line "+strconv.Itoa(lineNumber)+" of \""+filePath+"\"")
+			continue
+		}
+
+		if start.Before(since) {
+			continue
+		}
+
+		cdr, err := newCDRFromCSVFileRecord(start, record)
+		if err != nil {
+			log.LogS("ERROR", "Could not convert line "+strconv.Itoa(lineNumber)+" of \""+filePath+"\" to a CDR ("+err.Error()+")")
+			continue
+		}
+
+		cdrs = append(cdrs, cdr)
+
+	}
+
+	return cdrs, read, lineNumber, nil
+
+}
+
+func newCDRFromCSVFileRecord(start time.Time, record []string) (*CDR, error) {
+
+	duration, err := parseCDRSeconds(record[csvFileColumnDuration])
+	if err != nil {
+		return nil, err
+	}
+
+	billSec, err := parseCDRSeconds(record[csvFileColumnBillSec])
+	if err != nil {
+		return nil, err
+	}
+
+	cdr := new(CDR)
+	cdr.CallDate = start
+	cdr.CLID = record[csvFileColumnCLID]
+	cdr.Src = record[csvFileColumnSrc]
+	cdr.Dst = record[csvFileColumnDst]
+	cdr.DContext = record[csvFileColumnDContext]
+	cdr.Channel = record[csvFileColumnChannel]
+	cdr.DstChannel = record[csvFileColumnDstChannel]
+	cdr.LastApp = record[csvFileColumnLastApp]
+	cdr.LastData = record[csvFileColumnLastData]
+	cdr.Duration = duration
+	cdr.BillSec = billSec
+	cdr.Disposition = record[csvFileColumnDisposition]
+	cdr.AMAFlags = record[csvFileColumnAMAFlags]
+	cdr.AccountCode = record[csvFileColumnAccountCode]
+
+	if len(record) > csvFileColumnUniqueID {
+		cdr.UniqueID = record[csvFileColumnUniqueID]
+	}
+	if len(record) > csvFileColumnUserField {
+		cdr.UserField = record[csvFileColumnUserField]
+	}
+
+	return cdr, nil
+
+}
@@ -0,0 +1,140 @@
+package softswitches
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func csvFileLine(start time.Time, uniqueID string) string {
+	return `"acme","1000","00244123456789","from-internal","""John"" <1000>","SIP/1000-00000001","SIP/trunk-00000002","Dial","SIP/trunk/00244123456789,60","` + start.Format(csvFileTimeLayout) + `","` + start.Format(csvFileTimeLayout) + `","` + start.Add(time.Minute).Format(csvFileTimeLayout) + `",60,60,"ANSWERED","DOCUMENTATION","` + uniqueID + `",""` + "\n"
+}
+
+func appendToFile(t *testing.T, filePath string, data string) {
+
+	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer file.Close()
+
+	if _, err := file.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+
+}
+
+func getUniqueIDs(t *testing.T, cdrSource *CDRsSourceCSVFile, since time.Time) map[string]bool {
+
+	cdrs, err := cdrSource.GetCDRs(since)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uniqueIDs := make(map[string]bool)
+	for cdrs.Next() {
+		uniqueIDs[cdrs.CDR().UniqueID] = true
+	}
+
+	return uniqueIDs
+
+}
+
+func TestCDRsSourceCSVFileReadsOnlyWhatWasAppended(t *testing.T) {
+
+	directory, err := ioutil.TempDir("", "fraudion")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(directory)
+
+	filePath := filepath.Join(directory, "Master.csv")
+	since := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	appendToFile(t, filePath, csvFileLine(since.Add(-time.Minute), "0")+csvFileLine(since.Add(time.Minute), "1"))
+
+	cdrSource := &CDRsSourceCSVFile{FilePath: filePath}
+
+	if uniqueIDs := getUniqueIDs(t, cdrSource, since); len(uniqueIDs) != 1 || !uniqueIDs["1"] {
+		t.Fatalf("expected only the CDR started after \"since\", got %v", uniqueIDs)
+	}
+
+	// NOTE: The line being written is left for the next GetCDRs
+	line := csvFileLine(since.Add(3*time.Minute), "3")
+	appendToFile(t, filePath, csvFileLine(since.Add(2*time.Minute), "2")+line[:20])
+
+	if uniqueIDs := getUniqueIDs(t, cdrSource, since); len(uniqueIDs) != 2 || !uniqueIDs["2"] {
+		t.Fatalf("expected the CDR that was appended, got %v", uniqueIDs)
+	}
+
+	info, _ := os.Stat(filePath)
+	if offset := cdrSource.files[filePath].offset; offset != info.Size()-20 {
+		t.Fatalf("expected to have read up to the last whole line (byte %d), read up to %d", info.Size()-20, offset)
+	}
+
+	appendToFile(t, filePath, line[20:])
+
+	if uniqueIDs := getUniqueIDs(t, cdrSource, since); len(uniqueIDs) != 3 || !uniqueIDs["3"] {
+		t.Fatalf("expected the CDR that was finished being written, got %v", uniqueIDs)
+	}
+
+	// NOTE: The CDRs that were read are not read again, whatever changed in the part of the file that was already read
+	if cdrs := cdrSource.files[filePath].cdrs; len(cdrs) != 3 {
+		t.Fatalf("expected 3 CDRs to be kept, got %d", len(cdrs))
+	}
+
+	// NOTE: Asking for CDRs from further back than ever before reads the file again
+	if uniqueIDs := getUniqueIDs(t, cdrSource, since.Add(-time.Hour)); len(uniqueIDs) != 4 || !uniqueIDs["0"] {
+		t.Fatalf("expected all the CDRs, got %v", uniqueIDs)
+	}
+
+	// NOTE: Once rotated, the old file is read from the start under its new name and so is the new one
+	if err := os.Rename(filePath, filePath+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendToFile(t, filePath, csvFileLine(since.Add(4*time.Minute), "4"))
+
+	if uniqueIDs := getUniqueIDs(t, cdrSource, since); len(uniqueIDs) != 4 || !uniqueIDs["4"] {
+		t.Fatalf("expected the CDRs of both files, got %v", uniqueIDs)
+	}
+
+	// NOTE: A truncated file is read from the start again
+	if err := os.Truncate(filePath, 0); err != nil {
+		t.Fatal(err)
+	}
+	appendToFile(t, filePath, csvFileLine(since.Add(5*time.Minute), "5"))
+
+	if uniqueIDs := getUniqueIDs(t, cdrSource, since); len(uniqueIDs) != 4 || !uniqueIDs["5"] || uniqueIDs["4"] {
+		t.Fatalf("expected the CDRs of the truncated file to be gone, got %v", uniqueIDs)
+	}
+
+}
+
+func TestCDRsSourceCSVFileUseGMTime(t *testing.T) {
+
+	directory, err := ioutil.TempDir("", "fraudion")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(directory)
+
+	filePath := filepath.Join(directory, "Master.csv")
+	start := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+
+	appendToFile(t, filePath, csvFileLine(start, "1"))
+
+	cdrs, err := (&CDRsSourceCSVFile{FilePath: filePath, UseGMTime: true}).GetCDRs(start.Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cdrs.Next() == false || !cdrs.CDR().CallDate.Equal(start) {
+		t.Fatalf("expected a CDR started at %s", start)
+	}
+
+}
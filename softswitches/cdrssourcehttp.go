@@ -0,0 +1,312 @@
+package softswitches
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/andmar/marlog"
+)
+
+const (
+	// HTTPDefaultPath ...
+	HTTPDefaultPath = "/cdrs"
+	// NOTE: A batch of a few thousand mod_json_cdr CDRs, with all their variables, is well below this
+	httpMaximumBodySize = 32 << 20
+	// NOTE: So that clients that stop in the middle of a request, or never read the answer, don't keep connections open forever. The read
+	// one is for the whole request, long enough for the biggest of bodies on a slow link
+	httpReadTimeout  = time.Minute
+	httpWriteTimeout = 30 * time.Second
+)
+
+// NOTE: Sources listening on the same address share its server, each on its own path
+var httpListeners = struct {
+	sync.Mutex
+	muxes map[string]*http.ServeMux
+	paths map[string]bool
+}{muxes: make(map[string]*http.ServeMux), paths: make(map[string]bool)}
+
+// CDRsSourceHTTP Has the Softswitch push its CDRs to Fraudion instead of Fraudion reading them from somewhere it would have to be able to
+// reach (e.g. the database of a PBX in the cloud). It takes POSTs to Path on Address, authenticated with Token (as "Authorization: Bearer
+// <token>" or as the password of Basic authentication, which is what mod_json_cdr's "cred" sends), of a CDR or a JSON array of them, and
+// keeps them in CDRs (see CDRsSourceMemory). Each CDR is either what FreeSWITCH's mod_json_cdr posts or an object with the fields of
+// Asterisk's "cdr" table (e.g. {"calldate": "2016-07-29 10:00:00", "src": "1000", "dst": "00244123456789", "billsec": 10, ...}), where
+// "calldate" can also be seconds since the epoch
+type CDRsSourceHTTP struct {
+	Address string
+	Path    string
+	Token   string
+	// NOTE: Served over plain HTTP if nil
+	TLSConfig *tls.Config
+	CDRs      *CDRsSourceMemory
+}
+
+// NewCDRsSourceHTTP ...
+func NewCDRsSourceHTTP(address string, path string, token string, cdrs *CDRsSourceMemory) *CDRsSourceHTTP {
+
+	source := new(CDRsSourceHTTP)
+	source.Address = address
+	source.Path = path
+	if source.Path == "" {
+		source.Path = HTTPDefaultPath
+	}
+	source.Token = token
+	source.CDRs = cdrs
+
+	return source
+
+}
+
+// Listen Starts taking CDRs, the server of Address is started by the first Source listening on it, the others are only added to it. It
+// only returns an error if the address can't be listened on or its path is already taken, the server itself runs in the background
+func (source *CDRsSourceHTTP) Listen() error {
+
+	log := marlog.MarLog
+
+	httpListeners.Lock()
+	defer httpListeners.Unlock()
+
+	if httpListeners.paths[source.Address+source.Path] {
+		return fmt.Errorf("path \"" + source.Path + "\" of \"" + source.Address + "\" is already taken")
+	}
+
+	mux, found := httpListeners.muxes[source.Address]
+	if found == false {
+
+		listener, err := net.Listen("tcp", source.Address)
+		if err != nil {
+			return fmt.Errorf("could not listen on \"" + source.Address + "\" (" + err.Error() + ")")
+		}
+
+		if source.TLSConfig != nil {
+			listener = tls.NewListener(listener, source.TLSConfig)
+		}
+
+		mux = http.NewServeMux()
+		httpListeners.muxes[source.Address] = mux
+
+		server := &http.Server{Handler: mux, ReadTimeout: httpReadTimeout, WriteTimeout: httpWriteTimeout}
+
+		go func() {
+			err := server.Serve(listener)
+			log.LogS("ERROR", "Stopped taking CDRs on \""+source.Address+"\" ("+err.Error()+")")
+		}()
+
+	}
+
+	mux.Handle(source.Path, source)
+	httpListeners.paths[source.Address+source.Path] = true
+
+	log.LogS("INFO", "Taking CDRs on \""+source.Address+source.Path+"\"")
+
+	return nil
+
+}
+
+// ServeHTTP Adds the posted CDRs, CDRs that can't be converted are logged and skipped so that the Softswitch doesn't post the others again
+func (source *CDRsSourceHTTP) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+
+	log := marlog.MarLog
+
+	if request.Method != "POST" {
+		writer.Header().Set("Allow", "POST")
+		http.Error(writer, "only POST is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if source.isAuthorized(request) == false {
+		log.LogS("ERROR", "Refused CDRs from \""+request.RemoteAddr+"\" (wrong or missing token)")
+		writer.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(writer, "wrong or missing token", http.StatusUnauthorized)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(writer, request.Body, httpMaximumBodySize))
+	if err != nil {
+		http.Error(writer, "could not read the request body", http.StatusBadRequest)
+		return
+	}
+
+	records, err := parseHTTPCDRs(request.Header.Get("Content-Type"), body)
+	if err != nil {
+		log.LogS("ERROR", "Could not parse the CDRs posted by \""+request.RemoteAddr+"\" ("+err.Error()+")")
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var cdrs []*CDR
+	for _, record := range records {
+
+		cdr, err := newCDRFromHTTPRecord(record)
+		if err != nil {
+			log.LogS("ERROR", "Could not convert a CDR posted by \""+request.RemoteAddr+"\" ("+err.Error()+")")
+			continue
+		}
+
+		cdrs = append(cdrs, cdr)
+
+	}
+
+	source.CDRs.Add(cdrs...)
+
+	log.LogS("DEBUG", "Added "+strconv.Itoa(len(cdrs))+" of the "+strconv.Itoa(len(records))+" CDRs posted by \""+request.RemoteAddr+"\"")
+
+	fmt.Fprintf(writer, "%d\n", len(cdrs))
+
+}
+
+// GetCDRs ...
+func (source *CDRsSourceHTTP) GetCDRs(since time.Time) (CDRsIterator, error) {
+	return source.CDRs.GetCDRs(since)
+}
+
+func (source *CDRsSourceHTTP) isAuthorized(request *http.Request) bool {
+
+	var token string
+	if _, password, ok := request.BasicAuth(); ok {
+		token = password
+	} else if authorization := request.Header.Get("Authorization"); strings.HasPrefix(authorization, "Bearer ") {
+		token = strings.TrimPrefix(authorization, "Bearer ")
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(source.Token)) == 1
+
+}
+
+// parseHTTPCDRs Returns the objects in a request's body, mod_json_cdr posts either the JSON itself or, with "encode" set to true, a form
+// with it in "cdr"
+func parseHTTPCDRs(contentType string, body []byte) ([]map[string]interface{}, error) {
+
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, fmt.Errorf("could not parse the form (" + err.Error() + ")")
+		}
+		body = []byte(form.Get("cdr"))
+	}
+
+	body = bytes.TrimSpace(body)
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var records []map[string]interface{}
+
+	if len(body) > 0 && body[0] == '[' {
+		if err := decoder.Decode(&records); err != nil {
+			return nil, fmt.Errorf("could not parse the JSON (" + err.Error() + ")")
+		}
+		return records, nil
+	}
+
+	var record map[string]interface{}
+	if err := decoder.Decode(&record); err != nil {
+		return nil, fmt.Errorf("could not parse the JSON (" + err.Error() + ")")
+	}
+
+	return append(records, record), nil
+
+}
+
+// newCDRFromHTTPRecord Converts a posted CDR, the ones with "variables" are mod_json_cdr's
+func newCDRFromHTTPRecord(record map[string]interface{}) (*CDR, error) {
+
+	if variables, ok := record["variables"].(map[string]interface{}); ok {
+		return newCDRFromJSONCDR(record, variables)
+	}
+
+	return newCDRFromFields(func(field string) string {
+		return jsonFieldString(record[field])
+	})
+
+}
+
+// newCDRFromJSONCDR Converts what mod_json_cdr posts, the fields are the same newCDRFromESLEvent gets from the event's channel variables.
+// The caller profile is the one of the first "callflow" (the last one the call went through), which is an array in recent FreeSWITCH
+// versions and an object in older ones
+func newCDRFromJSONCDR(record map[string]interface{}, variables map[string]interface{}) (*CDR, error) {
+
+	variable := func(name string) string {
+		value := jsonFieldString(variables[name])
+		// NOTE: Values are URL encoded unless mod_json_cdr's "encode-values" is false
+		if strings.Contains(value, "%") {
+			if unescaped, err := url.QueryUnescape(value); err == nil {
+				return unescaped
+			}
+		}
+		return value
+	}
+
+	callerProfile := make(map[string]interface{})
+	callFlow := record["callflow"]
+	if callFlows, ok := callFlow.([]interface{}); ok && len(callFlows) > 0 {
+		callFlow = callFlows[0]
+	}
+	if callFlow, ok := callFlow.(map[string]interface{}); ok {
+		if profile, ok := callFlow["caller_profile"].(map[string]interface{}); ok {
+			callerProfile = profile
+		}
+	}
+
+	profile := func(name string, variableName string) string {
+		if value := jsonFieldString(callerProfile[name]); value != "" {
+			return value
+		}
+		return variable(variableName)
+	}
+
+	var callDate time.Time
+	if startEpoch := variable("start_epoch"); startEpoch != "" && startEpoch != "0" {
+		parsed, err := parseCDRTimeOrEpoch(startEpoch)
+		if err != nil {
+			return nil, err
+		}
+		callDate = parsed
+	} else {
+		parsed, err := parseCDRTime(variable("start_stamp"))
+		if err != nil {
+			return nil, err
+		}
+		callDate = parsed
+	}
+
+	duration, err := parseCDRSeconds(variable("duration"))
+	if err != nil {
+		return nil, err
+	}
+
+	billSec, err := parseCDRSeconds(variable("billsec"))
+	if err != nil {
+		return nil, err
+	}
+
+	cdr := new(CDR)
+	cdr.CallDate = callDate
+	cdr.CLID = profile("caller_id_name", "caller_id_name")
+	cdr.Src = profile("caller_id_number", "caller_id_number")
+	cdr.Dst = profile("destination_number", "destination_number")
+	cdr.DContext = profile("context", "user_context")
+	cdr.Channel = profile("chan_name", "channel_name")
+	cdr.DstChannel = variable("bridge_channel")
+	cdr.LastApp = variable("last_app")
+	cdr.LastData = variable("last_arg")
+	cdr.Duration = duration
+	cdr.BillSec = billSec
+	cdr.Disposition = variable("hangup_cause")
+	cdr.AccountCode = variable("accountcode")
+	cdr.UniqueID = profile("uuid", "uuid")
+
+	return cdr, nil
+
+}
@@ -0,0 +1,131 @@
+package softswitches
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+)
+
+func postCDRs(t *testing.T, source *CDRsSourceHTTP, method string, contentType string, body string, authorize func(request *http.Request)) *httptest.ResponseRecorder {
+
+	request, err := http.NewRequest(method, "http://localhost"+HTTPDefaultPath, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request.Header.Set("Content-Type", contentType)
+	if authorize != nil {
+		authorize(request)
+	}
+
+	recorder := httptest.NewRecorder()
+	source.ServeHTTP(recorder, request)
+
+	return recorder
+
+}
+
+func TestCDRsSourceHTTP(t *testing.T) {
+
+	source := NewCDRsSourceHTTP(":8090", "", "secret", NewCDRsSourceMemory(time.Hour))
+
+	// NOTE: CDRs older than the retention are dropped, these are from now
+	now := time.Unix(time.Now().Unix(), 0)
+	epoch := strconv.FormatInt(now.Unix(), 10)
+
+	bearer := func(token string) func(request *http.Request) {
+		return func(request *http.Request) {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	basic := func(password string) func(request *http.Request) {
+		return func(request *http.Request) {
+			request.SetBasicAuth("freeswitch", password)
+		}
+	}
+
+	asteriskCDR := `{"calldate": ` + epoch + `, "clid": "\"John Doe\" <1000>", "src": "1000", "dst": "00244123456789", "duration": "60", "billsec": 42, "disposition": "ANSWERED", "uniqueid": "1469786400.1"}`
+
+	// NOTE: What recent FreeSWITCH versions post, "callflow" is an array with the last one the call went through first and values are URL
+	// encoded
+	jsonCDR := `{"variables": {"uuid": "a1", "start_epoch": "` + epoch + `", "duration": "30", "billsec": "20", "hangup_cause": "NORMAL_CLEARING", "caller_id_name": "John%20Doe", "accountcode": "acme", "last_app": "bridge", "last_arg": "%7Bignore_early_media%3Dtrue%7Dsofia/gateway/trunk/00244123456789"}, "callflow": [{"caller_profile": {"uuid": "a1", "caller_id_number": "1000", "destination_number": "00244123456789", "context": "default", "chan_name": "sofia/internal/1000@pbx"}}, {"caller_profile": {"destination_number": "1000"}}]}`
+
+	// NOTE: Older versions have a single "callflow" object
+	startStamp := url.QueryEscape(now.Format("2006-01-02 15:04:05"))
+	oldJSONCDR := `{"variables": {"uuid": "b1", "start_stamp": "` + startStamp + `", "duration": "10", "billsec": "0", "hangup_cause": "NO_ANSWER"}, "callflow": {"caller_profile": {"uuid": "b1", "caller_id_number": "1001", "destination_number": "00351212345678"}}}`
+
+	tests := []struct {
+		method       string
+		contentType  string
+		body         string
+		authorize    func(request *http.Request)
+		expectedCode int
+		expectedBody string
+	}{
+		{"GET", "", "", bearer("secret"), http.StatusMethodNotAllowed, ""},
+		{"POST", "application/json", asteriskCDR, nil, http.StatusUnauthorized, ""},
+		{"POST", "application/json", asteriskCDR, bearer("wrong"), http.StatusUnauthorized, ""},
+		{"POST", "application/json", asteriskCDR, basic("wrong"), http.StatusUnauthorized, ""},
+		{"POST", "application/json", "{\"src\": ", bearer("secret"), http.StatusBadRequest, ""},
+		{"POST", "application/json", asteriskCDR, bearer("secret"), http.StatusOK, "1\n"},
+		// NOTE: CDRs that can't be converted are skipped, the others are taken
+		{"POST", "application/json", "[" + jsonCDR + ", {\"calldate\": \"yesterday\"}]", basic("secret"), http.StatusOK, "1\n"},
+		{"POST", "application/x-www-form-urlencoded", url.Values{"cdr": {oldJSONCDR}}.Encode(), basic("secret"), http.StatusOK, "1\n"},
+	}
+
+	for _, test := range tests {
+
+		recorder := postCDRs(t, source, test.method, test.contentType, test.body, test.authorize)
+
+		if recorder.Code != test.expectedCode {
+			t.Fatalf("%s %s: expected the status %d, got %d (%s)", test.method, test.body, test.expectedCode, recorder.Code, recorder.Body.String())
+		}
+
+		if test.expectedBody != "" && recorder.Body.String() != test.expectedBody {
+			t.Fatalf("%s %s: expected \"%s\", got \"%s\"", test.method, test.body, test.expectedBody, recorder.Body.String())
+		}
+
+	}
+
+	iterator, err := source.GetCDRs(now.Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cdrs := make(map[string]CDR)
+	for iterator.Next() {
+		cdrs[iterator.CDR().UniqueID] = *iterator.CDR()
+	}
+
+	expected := map[string]CDR{
+		"1469786400.1": {CallDate: now, CLID: "\"John Doe\" <1000>", Src: "1000", Dst: "00244123456789", Duration: 60, BillSec: 42, Disposition: "ANSWERED", UniqueID: "1469786400.1"},
+		"a1":           {CallDate: now, CLID: "John Doe", Src: "1000", Dst: "00244123456789", DContext: "default", Channel: "sofia/internal/1000@pbx", LastApp: "bridge", LastData: "{ignore_early_media=true}sofia/gateway/trunk/00244123456789", Duration: 30, BillSec: 20, Disposition: "NORMAL_CLEARING", AccountCode: "acme", UniqueID: "a1"},
+		"b1":           {CallDate: now, Src: "1001", Dst: "00351212345678", Duration: 10, Disposition: "NO_ANSWER", UniqueID: "b1"},
+	}
+
+	if len(cdrs) != len(expected) {
+		t.Fatalf("expected %d CDRs, got %d", len(expected), len(cdrs))
+	}
+
+	for uniqueID, expectedCDR := range expected {
+
+		cdr := cdrs[uniqueID]
+		if !cdr.CallDate.Equal(expectedCDR.CallDate) {
+			t.Errorf("%s: expected the calldate %s, got %s", uniqueID, expectedCDR.CallDate, cdr.CallDate)
+		}
+
+		cdr.CallDate = expectedCDR.CallDate
+		if !reflect.DeepEqual(cdr, expectedCDR) {
+			t.Errorf("%s: expected %+v, got %+v", uniqueID, expectedCDR, cdr)
+		}
+
+	}
+
+}
@@ -0,0 +1,71 @@
+package softswitches
+
+import (
+	"sync"
+	"time"
+)
+
+// CDRsSourceMemory Keeps in memory the CDRs that are pushed to it (e.g. by LiveCallsSourceESLEvents) instead of reading them from
+// somewhere, CDRs started more than Retention ago are dropped. Since nothing is persisted, after a restart it only has the CDRs of the
+// calls that ended since then
+type CDRsSourceMemory struct {
+	Retention time.Duration
+	mutex     sync.Mutex
+	cdrs      []*CDR
+}
+
+// NewCDRsSourceMemory ...
+func NewCDRsSourceMemory(retention time.Duration) *CDRsSourceMemory {
+
+	source := new(CDRsSourceMemory)
+	source.Retention = retention
+
+	return source
+
+}
+
+// Add ...
+func (source *CDRsSourceMemory) Add(cdrs ...*CDR) {
+
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	source.cdrs = append(source.cdrs, cdrs...)
+
+	// NOTE: CDRs are added when calls end, not in "calldate" order, so all of them have to be looked at
+	retainFrom := time.Now().Add(-source.Retention)
+
+	kept := source.cdrs[:0]
+	for _, cdr := range source.cdrs {
+		if !cdr.CallDate.Before(retainFrom) {
+			kept = append(kept, cdr)
+		}
+	}
+
+	// NOTE: Don't hold on to the dropped CDRs via the rest of the backing array
+	for index := len(kept); index < len(source.cdrs); index++ {
+		source.cdrs[index] = nil
+	}
+
+	source.cdrs = kept
+
+}
+
+// GetCDRs ...
+func (source *CDRsSourceMemory) GetCDRs(since time.Time) (CDRsIterator, error) {
+
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	var cdrs []*CDR
+	for _, cdr := range source.cdrs {
+		if !cdr.CallDate.Before(since) {
+			// NOTE: Softswitches set DialedNumbers on what they get from the Source, they can't be setting them on the stored CDRs
+			copied := *cdr
+			cdrs = append(cdrs, &copied)
+		}
+	}
+
+	return newCDRsIteratorSlice(cdrs), nil
+
+}
@@ -0,0 +1,289 @@
+package softswitches
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"encoding/csv"
+	"encoding/json"
+
+	"github.com/andmar/marlog"
+)
+
+const (
+	// StreamFormatJSON ...
+	StreamFormatJSON = "*json"
+	// StreamFormatCSV ...
+	StreamFormatCSV = "*csv"
+	// StreamStdin ...
+	StreamStdin = "-"
+	// StreamDefaultPollInterval ...
+	StreamDefaultPollInterval = time.Second
+)
+
+// StreamCSVFieldMap Columns of each CDR field in the CSV lines if there's no FieldMap, the layout of Asterisk's cdr_csv (see
+// CDRsSourceCSVFile) with its "start" as "calldate"
+var StreamCSVFieldMap = map[string]string{
+	CDRFieldAccountCode: strconv.Itoa(csvFileColumnAccountCode),
+	CDRFieldSrc:         strconv.Itoa(csvFileColumnSrc),
+	CDRFieldDst:         strconv.Itoa(csvFileColumnDst),
+	CDRFieldDContext:    strconv.Itoa(csvFileColumnDContext),
+	CDRFieldCLID:        strconv.Itoa(csvFileColumnCLID),
+	CDRFieldChannel:     strconv.Itoa(csvFileColumnChannel),
+	CDRFieldDstChannel:  strconv.Itoa(csvFileColumnDstChannel),
+	CDRFieldLastApp:     strconv.Itoa(csvFileColumnLastApp),
+	CDRFieldLastData:    strconv.Itoa(csvFileColumnLastData),
+	CDRFieldCallDate:    strconv.Itoa(csvFileColumnStart),
+	CDRFieldDuration:    strconv.Itoa(csvFileColumnDuration),
+	CDRFieldBillSec:     strconv.Itoa(csvFileColumnBillSec),
+	CDRFieldDisposition: strconv.Itoa(csvFileColumnDisposition),
+	CDRFieldAMAFlags:    strconv.Itoa(csvFileColumnAMAFlags),
+	CDRFieldUniqueID:    strconv.Itoa(csvFileColumnUniqueID),
+	CDRFieldUserField:   strconv.Itoa(csvFileColumnUserField),
+}
+
+// CDRsSourceStream Follows a file like "tail -F" does, or reads stdin if FilePath is "-", and adds to CDRs (see CDRsSourceMemory) the CDR
+// in each line written to it, so that anything that can write lines (rsyslog, a message broker consumer, a script) can feed Fraudion. Lines
+// are JSON objects or CSV records, depending on Format, FieldMap has the key (JSON) or the column number, starting at 0, (CSV) of each CDR
+// field, fields that are not there are left empty. JSON keys not in FieldMap are the CDR field names (e.g. "calldate", "dst", "billsec")
+// and, without FieldMap, CSV columns are in StreamCSVFieldMap. The file is followed from its end, when it's replaced (e.g. by logrotate) what is left of the
+// old one is read before the new one is followed from its beginning, and if it's truncated it's followed from its beginning again
+type CDRsSourceStream struct {
+	FilePath     string
+	Format       string
+	FieldMap     map[string]string
+	PollInterval time.Duration
+	CDRs         *CDRsSourceMemory
+	// NOTE: Once the file has been opened, reading starts at the beginning of the ones opened after
+	opened bool
+}
+
+// NewCDRsSourceStream ...
+func NewCDRsSourceStream(filePath string, format string, fieldMap map[string]string, cdrs *CDRsSourceMemory) *CDRsSourceStream {
+
+	source := new(CDRsSourceStream)
+	source.FilePath = filePath
+	source.Format = format
+	source.FieldMap = fieldMap
+	source.PollInterval = StreamDefaultPollInterval
+	source.CDRs = cdrs
+
+	return source
+
+}
+
+// Run Reads the stream forever or, for stdin, until it's closed
+func (source *CDRsSourceStream) Run() {
+
+	log := marlog.MarLog
+
+	if source.FilePath == StreamStdin {
+
+		reader := bufio.NewReader(os.Stdin)
+
+		for {
+
+			line, err := reader.ReadString('\n')
+			if line != "" {
+				source.add(line)
+			}
+
+			if err != nil {
+				if err != io.EOF {
+					log.LogS("ERROR", "Could not read CDRs from stdin ("+err.Error()+")")
+				}
+				log.LogS("INFO", "No more CDRs on stdin")
+				return
+			}
+
+		}
+
+	}
+
+	for {
+
+		if err := source.follow(); err != nil {
+			log.LogS("ERROR", "Could not follow \""+source.FilePath+"\" ("+err.Error()+"), trying again...")
+		}
+
+		time.Sleep(source.PollInterval)
+
+	}
+
+}
+
+// follow Follows the file until it can't be read anymore, the first time it's opened reading starts at its end, when it's replaced
+// reading starts at the beginning of the new one
+func (source *CDRsSourceStream) follow() error {
+
+	log := marlog.MarLog
+
+	for {
+
+		file, err := os.Open(source.FilePath)
+		if err != nil {
+			// NOTE: Between being moved away and created again by whatever writes it the file is not there, what is written to it after is new
+			source.opened = true
+			return err
+		}
+
+		if source.opened == false {
+			source.opened = true
+			if _, err := file.Seek(0, os.SEEK_END); err != nil {
+				file.Close()
+				return err
+			}
+		}
+
+		log.LogS("DEBUG", "Following \""+source.FilePath+"\"")
+
+		err = source.followFile(file)
+		file.Close()
+
+		if err != nil {
+			return err
+		}
+
+		log.LogS("INFO", "\""+source.FilePath+"\" was rotated, following the new one")
+
+	}
+
+}
+
+// followFile Reads the lines added to "file" until the file at FilePath is not "file" anymore and everything in it was read
+func (source *CDRsSourceStream) followFile(file *os.File) error {
+
+	reader := bufio.NewReader(file)
+
+	// NOTE: What was read of a line that is still being written
+	var partial string
+
+	for {
+
+		line, err := reader.ReadString('\n')
+		if err == nil {
+			source.add(partial + line)
+			partial = ""
+			continue
+		}
+
+		if err != io.EOF {
+			return err
+		}
+
+		partial += line
+
+		opened, err := file.Stat()
+		if err != nil {
+			return err
+		}
+
+		current, err := os.Stat(source.FilePath)
+		if err != nil || os.SameFile(opened, current) == false {
+			// NOTE: Rotated, whatever was left of the old file was just read
+			if strings.TrimSpace(partial) != "" {
+				source.add(partial)
+			}
+			return nil
+		}
+
+		offset, err := file.Seek(0, os.SEEK_CUR)
+		if err != nil {
+			return err
+		}
+
+		// NOTE: Truncated (e.g. logrotate's "copytruncate"), what's in it now was written after that
+		if current.Size() < offset {
+			if _, err := file.Seek(0, os.SEEK_SET); err != nil {
+				return err
+			}
+			reader.Reset(file)
+			partial = ""
+			continue
+		}
+
+		time.Sleep(source.PollInterval)
+
+	}
+
+}
+
+// add Adds the CDR in a line, lines that can't be converted are logged and skipped
+func (source *CDRsSourceStream) add(line string) {
+
+	log := marlog.MarLog
+
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+
+	cdr, err := source.parseLine(line)
+	if err != nil {
+		log.LogS("ERROR", "Could not convert a line of \""+source.FilePath+"\" to a CDR ("+err.Error()+")")
+		return
+	}
+
+	source.CDRs.Add(cdr)
+
+}
+
+func (source *CDRsSourceStream) parseLine(line string) (*CDR, error) {
+
+	switch source.Format {
+	case StreamFormatJSON:
+
+		decoder := json.NewDecoder(strings.NewReader(line))
+		decoder.UseNumber()
+
+		var record map[string]interface{}
+		if err := decoder.Decode(&record); err != nil {
+			return nil, err
+		}
+
+		return newCDRFromFields(func(field string) string {
+			if key, found := source.FieldMap[field]; found {
+				return jsonFieldString(record[key])
+			}
+			return jsonFieldString(record[field])
+		})
+
+	case StreamFormatCSV:
+
+		csvReader := csv.NewReader(strings.NewReader(line))
+		csvReader.FieldsPerRecord = -1
+		csvReader.LazyQuotes = true
+
+		record, err := csvReader.Read()
+		if err != nil {
+			return nil, err
+		}
+
+		fieldMap := source.FieldMap
+		if fieldMap == nil {
+			fieldMap = StreamCSVFieldMap
+		}
+
+		return newCDRFromFields(func(field string) string {
+			column, err := strconv.Atoi(fieldMap[field])
+			if err != nil || column < 0 || column >= len(record) {
+				return ""
+			}
+			return record[column]
+		})
+
+	}
+
+	return nil, fmt.Errorf("unknown stream format \"" + source.Format + "\"")
+
+}
+
+// GetCDRs ...
+func (source *CDRsSourceStream) GetCDRs(since time.Time) (CDRsIterator, error) {
+	return source.CDRs.GetCDRs(since)
+}
@@ -0,0 +1,128 @@
+package softswitches
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"io/ioutil"
+	"path/filepath"
+)
+
+// waitForStreamCDRs Waits for "source" to have CDRs with the "expected" uniqueids and no others
+func waitForStreamCDRs(t *testing.T, source *CDRsSourceStream, expected ...string) {
+
+	sort.Strings(expected)
+
+	var uniqueIDs []string
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+
+		iterator, err := source.GetCDRs(time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		found := make(map[string]bool)
+		for iterator.Next() {
+			found[iterator.CDR().UniqueID] = true
+		}
+
+		uniqueIDs = nil
+		for uniqueID := range found {
+			uniqueIDs = append(uniqueIDs, uniqueID)
+		}
+		sort.Strings(uniqueIDs)
+
+		if strings.Join(uniqueIDs, ",") == strings.Join(expected, ",") {
+			return
+		}
+
+		time.Sleep(source.PollInterval)
+
+	}
+
+	t.Fatalf("expected the CDRs %v, got %v", expected, uniqueIDs)
+
+}
+
+func TestCDRsSourceStreamFollow(t *testing.T) {
+
+	directory, err := ioutil.TempDir("", "fraudion")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(directory)
+
+	filePath := filepath.Join(directory, "cdrs.log")
+
+	// NOTE: CDRs older than the retention are dropped, these are from now
+	line := func(uniqueID string) string {
+		return `{"calldate": "` + time.Now().Format("2006-01-02 15:04:05") + `", "to": "00244123456789", "billsec": 42, "uniqueid": "` + uniqueID + `"}` + "\n"
+	}
+
+	// NOTE: Written before it's followed, it's not read
+	appendToFile(t, filePath, line("before"))
+
+	source := NewCDRsSourceStream(filePath, StreamFormatJSON, map[string]string{CDRFieldDst: "to"}, NewCDRsSourceMemory(time.Hour))
+	source.PollInterval = 10 * time.Millisecond
+
+	go source.Run()
+
+	// NOTE: There's no telling when it started following the file, lines written before that are missed so this one is written until it's
+	// read
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		appendToFile(t, filePath, line("started"))
+		iterator, _ := source.GetCDRs(time.Time{})
+		if iterator.Next() || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(source.PollInterval)
+	}
+
+	waitForStreamCDRs(t, source, "started")
+
+	appendToFile(t, filePath, line("appended"))
+	waitForStreamCDRs(t, source, "started", "appended")
+
+	// NOTE: A line is only taken once its end is written
+	parts := line("parts")
+	appendToFile(t, filePath, parts[:20])
+	time.Sleep(10 * source.PollInterval)
+	waitForStreamCDRs(t, source, "started", "appended")
+	appendToFile(t, filePath, parts[20:])
+	waitForStreamCDRs(t, source, "started", "appended", "parts")
+
+	// NOTE: Rotated by moving it away and creating it again, what was left in the old one is read and then the new one from its beginning
+	appendToFile(t, filePath, line("left"))
+	if err := os.Rename(filePath, filePath+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendToFile(t, filePath, line("recreated"))
+	waitForStreamCDRs(t, source, "started", "appended", "parts", "left", "recreated")
+
+	// NOTE: Rotated by logrotate's "copytruncate", what's written after the truncate is read
+	time.Sleep(10 * source.PollInterval)
+	if err := os.Truncate(filePath, 0); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * source.PollInterval)
+	appendToFile(t, filePath, line("truncated"))
+	waitForStreamCDRs(t, source, "started", "appended", "parts", "left", "recreated", "truncated")
+
+	iterator, err := source.GetCDRs(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for iterator.Next() {
+		if cdr := iterator.CDR(); cdr.Dst != "00244123456789" || cdr.BillSec != 42 {
+			t.Fatalf("expected the CDRs to have the mapped \"dst\" and \"billsec\", got %+v", cdr)
+		}
+	}
+
+}
@@ -0,0 +1,264 @@
+package softswitches
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/url"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	// NOTE: TLS configurations are registered in the driver under this followed by the name of the Softswitch, each one has its own
+	mysqlTLSConfigNamePrefix = "fraudion-"
+	// NOTE: How DATETIME columns (and SQLite's text ones) are compared against
+	databaseTimeLayout = "2006-01-02 15:04:05"
+)
+
+// databaseDialect Holds what changes from DBMS to DBMS when using a Database as a CDRs Source, the "database/sql" driver to use, how to
+// build the data source name to connect to it, the placeholder of a query's first parameter and how to pass a time to compare a column
+// with. Times are passed as parameters, the Database's own clock (e.g. NOW()) may not agree with Fraudion's
+type databaseDialect struct {
+	driverName     string
+	dataSourceName func(cdrSource *CDRsSourceDatabase) (string, error)
+	placeholder    string
+	timeValue      func(value time.Time) interface{}
+}
+
+var databaseDialects = map[string]*databaseDialect{
+
+	DBMSMySQL: &databaseDialect{
+		driverName:     "mysql",
+		dataSourceName: mysqlDataSourceName,
+		placeholder:    "?",
+		// NOTE: The driver would convert a time.Time to its "loc" (UTC by default), DATETIME columns have no time zone
+		timeValue: func(value time.Time) interface{} {
+			return value.Format(databaseTimeLayout)
+		},
+	},
+
+	// NOTE: cdr_pgsql and mod_cdr_pg_csv use "timestamp" columns, which ignore the time zone of what they're compared with, and for
+	// "timestamptz" ones it's the time zone that makes it the right time
+	DBMSPostgreSQL: &databaseDialect{
+		driverName:     "postgres",
+		dataSourceName: postgreSQLDataSourceName,
+		placeholder:    "$1",
+		timeValue: func(value time.Time) interface{} {
+			return value
+		},
+	},
+
+	// NOTE: SQLite has no date types, cdr_sqlite3_custom writes "YYYY-MM-DD HH:MM:SS" local time strings that compare well as text,
+	// "database_name" is the path to the database file (e.g. /var/log/asterisk/master.db)
+	DBMSSQLite: &databaseDialect{
+		driverName:     "sqlite3",
+		dataSourceName: sqliteDataSourceName,
+		placeholder:    "?",
+		timeValue: func(value time.Time) interface{} {
+			return value.Format(databaseTimeLayout)
+		},
+	},
+}
+
+func getDatabaseDialect(dbms string) (*databaseDialect, error) {
+
+	dialect, found := databaseDialects[dbms]
+	if found == false {
+		return nil, fmt.Errorf("unsupported DBMS \"%s\"", dbms)
+	}
+
+	return dialect, nil
+
+}
+
+// mysqlDataSourceName Builds something like "user:password@tcp(host:port)/database?param=value" or, if a socket is configured,
+// "user:password@unix(/path/to/socket)/database?param=value"
+func mysqlDataSourceName(cdrSource *CDRsSourceDatabase) (string, error) {
+
+	address := "tcp(" + net.JoinHostPort(cdrSource.getHost(), strconv.Itoa(int(cdrSource.getPort(3306)))) + ")"
+	if cdrSource.Socket != "" {
+		address = "unix(" + cdrSource.Socket + ")"
+	}
+
+	parameters := url.Values{}
+
+	// NOTE: Older Asterisk boxes tend to have old MySQL servers, so this is what we did before DSN options were configurable, it's a default
+	// that "dsn_options" can turn off ("allowOldPasswords": "0") and not something that goes away when other options are configured
+	parameters.Set("allowOldPasswords", "1")
+
+	for key, value := range cdrSource.DSNOptions {
+		parameters.Set(key, value)
+	}
+
+	if cdrSource.Timeout > 0 {
+		parameters.Set("timeout", cdrSource.Timeout.String())
+	}
+
+	if cdrSource.TLS.Enabled {
+
+		tlsConfig, err := cdrSource.TLS.getConfig(cdrSource.getHost())
+		if err != nil {
+			return "", err
+		}
+
+		tlsConfigName := mysqlTLSConfigNamePrefix + cdrSource.SoftswitchName
+
+		if err := mysql.RegisterTLSConfig(tlsConfigName, tlsConfig); err != nil {
+			return "", fmt.Errorf("could not register the TLS configuration (" + err.Error() + ")")
+		}
+
+		parameters.Set("tls", tlsConfigName)
+
+	}
+
+	dataSourceName := fmt.Sprintf("%s:%s@%s/%s", cdrSource.UserName, cdrSource.UserPassword, address, cdrSource.DatabaseName)
+	if len(parameters) > 0 {
+		dataSourceName = dataSourceName + "?" + parameters.Encode()
+	}
+
+	return dataSourceName, nil
+
+}
+
+// postgreSQLDataSourceName Builds a lib/pq "key=value" connection string, for sockets lib/pq wants the directory where the socket is
+func postgreSQLDataSourceName(cdrSource *CDRsSourceDatabase) (string, error) {
+
+	parameters := map[string]string{
+		"user":     cdrSource.UserName,
+		"password": cdrSource.UserPassword,
+		"dbname":   cdrSource.DatabaseName,
+		"host":     cdrSource.getHost(),
+		"port":     strconv.Itoa(int(cdrSource.getPort(5432))),
+		"sslmode":  "disable",
+	}
+
+	if cdrSource.Socket != "" {
+		parameters["host"] = cdrSource.Socket
+	}
+
+	if cdrSource.Timeout > 0 {
+		parameters["connect_timeout"] = strconv.Itoa(int(cdrSource.Timeout.Seconds()))
+	}
+
+	if cdrSource.TLS.Enabled {
+
+		parameters["sslmode"] = "verify-full"
+		if cdrSource.TLS.SkipVerify {
+			parameters["sslmode"] = "require"
+		}
+
+		if cdrSource.TLS.CAFile != "" {
+			parameters["sslrootcert"] = cdrSource.TLS.CAFile
+		}
+
+		if cdrSource.TLS.CertFile != "" {
+			parameters["sslcert"] = cdrSource.TLS.CertFile
+			parameters["sslkey"] = cdrSource.TLS.KeyFile
+		}
+
+	}
+
+	for key, value := range cdrSource.DSNOptions {
+		parameters[key] = value
+	}
+
+	keys := make([]string, 0, len(parameters))
+	for key := range parameters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+quotePostgreSQLValue(parameters[key]))
+	}
+
+	return strings.Join(pairs, " "), nil
+
+}
+
+// sqliteDataSourceName The database is a local file so host, port and TLS mean nothing here
+func sqliteDataSourceName(cdrSource *CDRsSourceDatabase) (string, error) {
+
+	parameters := url.Values{}
+
+	for key, value := range cdrSource.DSNOptions {
+		parameters.Set(key, value)
+	}
+
+	if cdrSource.Timeout > 0 {
+		parameters.Set("_busy_timeout", strconv.Itoa(int(cdrSource.Timeout/time.Millisecond)))
+	}
+
+	if len(parameters) == 0 {
+		return cdrSource.DatabaseName, nil
+	}
+
+	return "file:" + cdrSource.DatabaseName + "?" + parameters.Encode(), nil
+
+}
+
+// quotePostgreSQLValue Quotes a value for use in a lib/pq "key=value" connection string
+func quotePostgreSQLValue(value string) string {
+
+	value = strings.Replace(value, "\\", "\\\\", -1)
+	value = strings.Replace(value, "'", "\\'", -1)
+
+	return "'" + value + "'"
+
+}
+
+// DatabaseTLS ...
+type DatabaseTLS struct {
+	Enabled    bool
+	CAFile     string
+	CertFile   string
+	KeyFile    string
+	SkipVerify bool
+}
+
+func (databaseTLS DatabaseTLS) getConfig(serverName string) (*tls.Config, error) {
+
+	tlsConfig := &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: databaseTLS.SkipVerify,
+	}
+
+	if databaseTLS.CAFile != "" {
+
+		caCertificates, err := ioutil.ReadFile(databaseTLS.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the TLS CA file (" + err.Error() + ")")
+		}
+
+		rootCAs := x509.NewCertPool()
+		if rootCAs.AppendCertsFromPEM(caCertificates) == false {
+			return nil, fmt.Errorf("could not find any PEM certificates in the TLS CA file")
+		}
+
+		tlsConfig.RootCAs = rootCAs
+
+	}
+
+	if databaseTLS.CertFile != "" {
+
+		certificate, err := tls.LoadX509KeyPair(databaseTLS.CertFile, databaseTLS.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load the TLS certificate/key pair (" + err.Error() + ")")
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+
+	}
+
+	return tlsConfig, nil
+
+}
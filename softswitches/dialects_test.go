@@ -0,0 +1,203 @@
+package softswitches
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMySQLDataSourceName(t *testing.T) {
+
+	tests := []struct {
+		cdrSource CDRsSourceDatabase
+		expected  string
+	}{
+		{
+			CDRsSourceDatabase{UserName: "user", UserPassword: "password", DatabaseName: "asteriskcdrdb"},
+			"user:password@tcp(localhost:3306)/asteriskcdrdb?allowOldPasswords=1",
+		},
+		{
+			CDRsSourceDatabase{UserName: "user", UserPassword: "password", DatabaseName: "asteriskcdrdb", Host: "10.0.0.1", Port: 3307, Timeout: 10 * time.Second},
+			"user:password@tcp(10.0.0.1:3307)/asteriskcdrdb?allowOldPasswords=1&timeout=10s",
+		},
+		{
+			CDRsSourceDatabase{UserName: "user", UserPassword: "password", DatabaseName: "asteriskcdrdb", Host: "::1"},
+			"user:password@tcp([::1]:3306)/asteriskcdrdb?allowOldPasswords=1",
+		},
+		// NOTE: The socket takes the place of host and port
+		{
+			CDRsSourceDatabase{UserName: "user", UserPassword: "password", DatabaseName: "asteriskcdrdb", Host: "10.0.0.1", Socket: "/var/lib/mysql/mysql.sock"},
+			"user:password@unix(/var/lib/mysql/mysql.sock)/asteriskcdrdb?allowOldPasswords=1",
+		},
+		// NOTE: Other options don't take the allowOldPasswords default away, setting it does
+		{
+			CDRsSourceDatabase{UserName: "user", UserPassword: "password", DatabaseName: "asteriskcdrdb", DSNOptions: map[string]string{"parseTime": "true"}},
+			"user:password@tcp(localhost:3306)/asteriskcdrdb?allowOldPasswords=1&parseTime=true",
+		},
+		{
+			CDRsSourceDatabase{UserName: "user", UserPassword: "password", DatabaseName: "asteriskcdrdb", DSNOptions: map[string]string{"allowOldPasswords": "0", "loc": "UTC"}},
+			"user:password@tcp(localhost:3306)/asteriskcdrdb?allowOldPasswords=0&loc=UTC",
+		},
+		// NOTE: Each Softswitch has its own TLS configuration
+		{
+			CDRsSourceDatabase{SoftswitchName: "pbx1", UserName: "user", UserPassword: "password", DatabaseName: "asteriskcdrdb", TLS: DatabaseTLS{Enabled: true}},
+			"user:password@tcp(localhost:3306)/asteriskcdrdb?allowOldPasswords=1&tls=fraudion-pbx1",
+		},
+	}
+
+	for _, test := range tests {
+
+		dataSourceName, err := mysqlDataSourceName(&test.cdrSource)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if dataSourceName != test.expected {
+			t.Errorf("expected \"%s\", got \"%s\"", test.expected, dataSourceName)
+		}
+
+	}
+
+}
+
+func TestPostgreSQLDataSourceName(t *testing.T) {
+
+	tests := []struct {
+		cdrSource CDRsSourceDatabase
+		expected  string
+	}{
+		{
+			CDRsSourceDatabase{UserName: "user", UserPassword: "password", DatabaseName: "cdrs"},
+			"dbname='cdrs' host='localhost' password='password' port='5432' sslmode='disable' user='user'",
+		},
+		// NOTE: Spaces, quotes and backslashes would otherwise end the value or start another one
+		{
+			CDRsSourceDatabase{UserName: "user", UserPassword: `it's a \secret`, DatabaseName: "cdrs", Port: 5433, Timeout: 10 * time.Second},
+			`connect_timeout='10' dbname='cdrs' host='localhost' password='it\'s a \\secret' port='5433' sslmode='disable' user='user'`,
+		},
+		{
+			CDRsSourceDatabase{UserName: "user", UserPassword: "password", DatabaseName: "cdrs", Socket: "/var/run/postgresql"},
+			"dbname='cdrs' host='/var/run/postgresql' password='password' port='5432' sslmode='disable' user='user'",
+		},
+		{
+			CDRsSourceDatabase{UserName: "user", UserPassword: "password", DatabaseName: "cdrs", TLS: DatabaseTLS{Enabled: true, CAFile: "/etc/ca.pem", CertFile: "/etc/cert.pem", KeyFile: "/etc/key.pem"}},
+			"dbname='cdrs' host='localhost' password='password' port='5432' sslcert='/etc/cert.pem' sslkey='/etc/key.pem' sslmode='verify-full' sslrootcert='/etc/ca.pem' user='user'",
+		},
+		{
+			CDRsSourceDatabase{UserName: "user", UserPassword: "password", DatabaseName: "cdrs", TLS: DatabaseTLS{Enabled: true, SkipVerify: true}},
+			"dbname='cdrs' host='localhost' password='password' port='5432' sslmode='require' user='user'",
+		},
+		// NOTE: DSN options have the last word
+		{
+			CDRsSourceDatabase{UserName: "user", UserPassword: "password", DatabaseName: "cdrs", DSNOptions: map[string]string{"sslmode": "prefer", "application_name": "fraudion"}},
+			"application_name='fraudion' dbname='cdrs' host='localhost' password='password' port='5432' sslmode='prefer' user='user'",
+		},
+	}
+
+	for _, test := range tests {
+
+		dataSourceName, err := postgreSQLDataSourceName(&test.cdrSource)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if dataSourceName != test.expected {
+			t.Errorf("expected \"%s\", got \"%s\"", test.expected, dataSourceName)
+		}
+
+	}
+
+}
+
+func TestSQLiteDataSourceName(t *testing.T) {
+
+	tests := []struct {
+		cdrSource CDRsSourceDatabase
+		expected  string
+	}{
+		{
+			CDRsSourceDatabase{DatabaseName: "/var/log/asterisk/master.db", Host: "ignored", TLS: DatabaseTLS{Enabled: true}},
+			"/var/log/asterisk/master.db",
+		},
+		{
+			CDRsSourceDatabase{DatabaseName: "/var/log/asterisk/master.db", Timeout: 5 * time.Second},
+			"file:/var/log/asterisk/master.db?_busy_timeout=5000",
+		},
+		{
+			CDRsSourceDatabase{DatabaseName: "/var/log/asterisk/master.db", DSNOptions: map[string]string{"mode": "ro"}},
+			"file:/var/log/asterisk/master.db?mode=ro",
+		},
+	}
+
+	for _, test := range tests {
+
+		dataSourceName, err := sqliteDataSourceName(&test.cdrSource)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if dataSourceName != test.expected {
+			t.Errorf("expected \"%s\", got \"%s\"", test.expected, dataSourceName)
+		}
+
+	}
+
+}
+
+func TestGetDatabaseDialect(t *testing.T) {
+
+	for dbms, expectedDriverName := range map[string]string{DBMSMySQL: "mysql", DBMSPostgreSQL: "postgres", DBMSSQLite: "sqlite3"} {
+
+		dialect, err := getDatabaseDialect(dbms)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if dialect.driverName != expectedDriverName {
+			t.Errorf("expected %s to use the %s driver, got %s", dbms, expectedDriverName, dialect.driverName)
+		}
+
+	}
+
+	if _, err := getDatabaseDialect("*oracle"); err == nil {
+		t.Error("expected an error for an unsupported DBMS")
+	}
+
+}
+
+func TestSinceCondition(t *testing.T) {
+
+	since := time.Date(2016, 7, 29, 10, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		dbms              string
+		useGMTime         bool
+		expectedCondition string
+		expectedValue     interface{}
+	}{
+		{DBMSMySQL, false, "calldate >= ?", "2016-07-29 10:00:00"},
+		{DBMSSQLite, false, "calldate >= ?", "2016-07-29 10:00:00"},
+		{DBMSPostgreSQL, false, "calldate >= $1", since},
+		// NOTE: With "usegmtime" the columns hold GMT so that's what "since" is compared as
+		{DBMSMySQL, true, "calldate >= ?", since.UTC().Format(databaseTimeLayout)},
+		{DBMSPostgreSQL, true, "calldate >= $1", since.UTC()},
+	}
+
+	for _, test := range tests {
+
+		// NOTE: "since" is passed as it is, whatever time zone it comes in, not as an interval back from the Database's clock
+		condition, value, err := (&CDRsSourceDatabase{DBMS: test.dbms, UseGMTime: test.useGMTime}).SinceCondition("calldate", since.UTC())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if condition != test.expectedCondition {
+			t.Errorf("%s: expected the condition \"%s\", got \"%s\"", test.dbms, test.expectedCondition, condition)
+		}
+
+		if value != test.expectedValue {
+			t.Errorf("%s: expected the value %v, got %v", test.dbms, test.expectedValue, value)
+		}
+
+	}
+
+}
@@ -0,0 +1,119 @@
+package softswitches
+
+import (
+	"strings"
+)
+
+// asteriskDialedNumbers Returns the numbers dialed in "data" if "application" is "Dial", one for each leg of the dial string that dials a
+// number, nil otherwise
+func asteriskDialedNumbers(application string, data string) []string {
+
+	// NOTE: Ignore if "lastapp" is not Dial
+	if application != "Dial" {
+		return nil
+	}
+
+	return parseAsteriskDialString(data)
+
+}
+
+// parseAsteriskDialString Parses the arguments of Dial, "Technology/Resource[&Technology2/Resource2[&...]][,timeout[,options[,URL]]]"
+// (arguments were separated by "|" up to Asterisk 1.4), and returns the number dialed by each leg, legs that dial a peer/device and not
+// a number (e.g. "SIP/1001", "PJSIP/alice") are left out
+func parseAsteriskDialString(data string) []string {
+
+	if separatorIndex := strings.IndexAny(data, ",|"); separatorIndex != -1 {
+		data = data[:separatorIndex]
+	}
+
+	var numbers []string
+
+	for _, leg := range strings.Split(data, "&") {
+		if number := parseAsteriskDialStringLeg(strings.TrimSpace(leg)); number != "" {
+			numbers = append(numbers, number)
+		}
+	}
+
+	return numbers
+
+}
+
+// parseAsteriskDialStringLeg Returns the number dialed by "leg" or "" if it does not dial a number, the supported forms are
+// Technology/peer/number (e.g. SIP/trunk/1234, DAHDI/g0/1234, IAX2/user:secret@host/1234), Technology/peer/number@context (IAX2),
+// Technology/peer/sip:number@host (PJSIP), Technology/number@peer (e.g. SIP/1234@trunk, PJSIP/1234@trunk) and
+// Local/number@context[/options]
+func parseAsteriskDialStringLeg(leg string) string {
+
+	separatorIndex := strings.Index(leg, "/")
+	if separatorIndex == -1 {
+		return ""
+	}
+
+	technology := strings.ToUpper(leg[:separatorIndex])
+	resource := leg[separatorIndex+1:]
+
+	var number string
+
+	if technology == "LOCAL" {
+
+		// NOTE: Local channel options (e.g. "/n") come after the context
+		if optionsIndex := strings.Index(resource, "/"); optionsIndex != -1 {
+			resource = resource[:optionsIndex]
+		}
+
+		atIndex := strings.Index(resource, "@")
+		if atIndex == -1 {
+			return ""
+		}
+
+		number = resource[:atIndex]
+
+	} else {
+
+		parts := strings.SplitN(resource, "/", 3)
+
+		if len(parts) >= 2 {
+			number = parts[1]
+		} else {
+			// NOTE: Without "@" it's a peer/device (e.g. SIP/1001 is the phone registered as 1001) and not a dialed number
+			atIndex := strings.Index(resource, "@")
+			if atIndex == -1 {
+				return ""
+			}
+			number = resource[:atIndex]
+		}
+
+		number = strings.TrimPrefix(number, "sips:")
+		number = strings.TrimPrefix(number, "sip:")
+		if atIndex := strings.Index(number, "@"); atIndex != -1 {
+			number = number[:atIndex]
+		}
+
+	}
+
+	number = strings.TrimPrefix(number, "+")
+
+	if !isDialedNumber(number) {
+		return ""
+	}
+
+	return number
+
+}
+
+// isDialedNumber Extensions like "s", feature codes like "*97" and the like are not numbers that were dialed
+func isDialedNumber(number string) bool {
+
+	if number == "" {
+		return false
+	}
+
+	for _, digit := range number {
+		if digit < '0' || digit > '9' {
+			return false
+		}
+	}
+
+	return true
+
+}
@@ -0,0 +1,66 @@
+package softswitches
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAsteriskDialStringLeg(t *testing.T) {
+
+	tests := []struct {
+		leg      string
+		expected string
+	}{
+		{"SIP/trunk/00244123456789", "00244123456789"},
+		{"DAHDI/g0/00244123456789", "00244123456789"},
+		{"IAX2/user:secret@host/00244123456789", "00244123456789"},
+		{"IAX2/peer/00244123456789@international", "00244123456789"},
+		{"PJSIP/00244123456789@trunk", "00244123456789"},
+		{"PJSIP/trunk/sip:+244123456789@sip.example.com", "244123456789"},
+		{"PJSIP/trunk/sips:00244123456789@sip.example.com:5061", "00244123456789"},
+		{"SIP/00244123456789@trunk", "00244123456789"},
+		{"Local/00244123456789@from-internal/n", "00244123456789"},
+		{"local/00244123456789@from-internal", "00244123456789"},
+		// NOTE: Peers/devices, extensions that are not numbers and feature codes were not dialed
+		{"SIP/1001", ""},
+		{"PJSIP/alice", ""},
+		{"Local/s@from-internal", ""},
+		{"Local/*97@from-internal", ""},
+		{"Local/1001", ""},
+		{"*97", ""},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		if number := parseAsteriskDialStringLeg(test.leg); number != test.expected {
+			t.Errorf("%s: expected \"%s\", got \"%s\"", test.leg, test.expected, number)
+		}
+	}
+
+}
+
+func TestParseAsteriskDialString(t *testing.T) {
+
+	tests := []struct {
+		data     string
+		expected []string
+	}{
+		{"SIP/trunk/00244123456789", []string{"00244123456789"}},
+		// NOTE: Dial's options come after "," (or "|" up to Asterisk 1.4)
+		{"SIP/trunk/00244123456789,60,tT", []string{"00244123456789"}},
+		{"SIP/trunk/00244123456789|60|tT", []string{"00244123456789"}},
+		{"PJSIP/trunk/sip:+244123456789@sip.example.com,30", []string{"244123456789"}},
+		// NOTE: A forked Dial dials each of its legs, those that are not numbers are left out
+		{"SIP/trunk/00244123456789&DAHDI/g0/00351212345678,60", []string{"00244123456789", "00351212345678"}},
+		{"SIP/1001 & Local/00244123456789@from-internal/n|60", []string{"00244123456789"}},
+		{"SIP/1001&PJSIP/alice,20", nil},
+		{"", nil},
+	}
+
+	for _, test := range tests {
+		if numbers := parseAsteriskDialString(test.data); !reflect.DeepEqual(numbers, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.data, test.expected, numbers)
+		}
+	}
+
+}
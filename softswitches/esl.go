@@ -0,0 +1,340 @@
+package softswitches
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"io/ioutil"
+	"net/url"
+
+	"github.com/andmar/marlog"
+)
+
+const (
+	// ESLDefaultPort ...
+	ESLDefaultPort = 8021
+	// ESLDefaultPassword ...
+	ESLDefaultPassword = "ClueCon"
+	// ESLDefaultTimeout ...
+	ESLDefaultTimeout = 10 * time.Second
+)
+
+const (
+	eslContentTypeAuthRequest      = "auth/request"
+	eslContentTypeCommandReply     = "command/reply"
+	eslContentTypeEventPlain       = "text/event-plain"
+	eslContentTypeDisconnectNotice = "text/disconnect-notice"
+)
+
+// ESLMessage Something FreeSWITCH sent over the Event Socket, for events (Content-Type "text/event-plain") Headers are the event's headers
+// already decoded (e.g. "Event-Name", "Unique-ID") and Body is the event's body, for everything else (e.g. "api/response") Headers are the
+// message's headers and Body its content
+type ESLMessage struct {
+	ContentType string
+	Headers     map[string]string
+	Body        string
+}
+
+// ESLClient An inbound FreeSWITCH Event Socket (mod_event_socket) client, it connects, authenticates and subscribes to events when told to
+// Listen and, like AMIClient, waits a backoff that doubles on each failed attempt (up to MaximumBackoff) before connecting again
+type ESLClient struct {
+	Host           string
+	Port           uint32
+	Password       string
+	Timeout        time.Duration
+	MaximumBackoff time.Duration
+	// NOTE: net.DialTimeout if nil, it's here so that the client can be pointed at something that is not a real FreeSWITCH (e.g. a fake ESL server)
+	Dial func(network string, address string, timeout time.Duration) (net.Conn, error)
+
+	mutex      sync.Mutex
+	connection net.Conn
+	reader     *bufio.Reader
+	backoff    reconnectBackoff
+}
+
+// Listen Subscribes to the "events" (e.g. "CHANNEL_CREATE"), sends the "commands" (e.g. "api show channels as json") and then passes every
+// message received, events and the replies to "commands", to "handle" until the connection is lost, which is when it returns. If
+// connecting failed before, it waits for the backoff instead of returning
+func (client *ESLClient) Listen(events []string, commands []string, handle func(message *ESLMessage)) error {
+
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	time.Sleep(client.backoff.wait())
+
+	if err := client.connect(events); err != nil {
+		return err
+	}
+
+	defer client.disconnect()
+
+	client.connection.SetDeadline(time.Now().Add(client.getTimeout()))
+
+	for _, command := range commands {
+		if err := client.writeCommand(command); err != nil {
+			return err
+		}
+	}
+
+	// NOTE: There may be no events for a long time (e.g. at night), that doesn't mean the connection is gone
+	client.connection.SetDeadline(time.Time{})
+
+	for {
+
+		received, err := client.readMessage()
+		if err == nil && received.ContentType == eslContentTypeDisconnectNotice {
+			err = fmt.Errorf("FreeSWITCH closed the Event Socket connection")
+		}
+		if err != nil {
+			// NOTE: Reconnecting right away to a FreeSWITCH that keeps dropping us would be a busy loop
+			client.backoff.failed(client.MaximumBackoff)
+			return err
+		}
+
+		if received.ContentType == eslContentTypeCommandReply {
+			continue
+		}
+
+		handle(received)
+
+	}
+
+}
+
+func (client *ESLClient) connect(events []string) error {
+
+	log := marlog.MarLog
+
+	if err := client.login(events); err != nil {
+
+		client.disconnect()
+
+		wait := client.backoff.failed(client.MaximumBackoff)
+
+		log.LogS("ERROR", "Could not connect to the Event Socket at \""+client.getAddress()+"\", attempt "+strconv.Itoa(int(client.backoff.failures))+", next one in "+wait.String())
+
+		return err
+
+	}
+
+	client.backoff.succeeded()
+
+	log.LogS("DEBUG", "Connected to the Event Socket at \""+client.getAddress()+"\"")
+
+	return nil
+
+}
+
+func (client *ESLClient) login(events []string) error {
+
+	dial := client.Dial
+	if dial == nil {
+		dial = net.DialTimeout
+	}
+
+	connection, err := dial("tcp", client.getAddress(), client.getTimeout())
+	if err != nil {
+		return fmt.Errorf("could not connect to the Event Socket (" + err.Error() + ")")
+	}
+
+	client.connection = connection
+	client.reader = bufio.NewReader(connection)
+
+	client.connection.SetDeadline(time.Now().Add(client.getTimeout()))
+
+	request, err := client.readMessage()
+	if err != nil {
+		return fmt.Errorf("could not read the Event Socket auth request (" + err.Error() + ")")
+	}
+
+	if request.ContentType != eslContentTypeAuthRequest {
+		return fmt.Errorf("unexpected Event Socket message \"%s\" instead of the auth request", request.ContentType)
+	}
+
+	password := client.Password
+	if password == "" {
+		password = ESLDefaultPassword
+	}
+
+	if err := client.command("auth " + password); err != nil {
+		return fmt.Errorf("could not authenticate on the Event Socket (" + err.Error() + ")")
+	}
+
+	if err := client.command("event plain " + strings.Join(events, " ")); err != nil {
+		return fmt.Errorf("could not subscribe to Event Socket events (" + err.Error() + ")")
+	}
+
+	return nil
+
+}
+
+func (client *ESLClient) disconnect() {
+
+	if client.connection != nil {
+		client.connection.Close()
+	}
+
+	client.connection = nil
+	client.reader = nil
+
+}
+
+// command Sends "command" and waits for its reply, which says "+OK ..." or "-ERR ..."
+func (client *ESLClient) command(command string) error {
+
+	if err := client.writeCommand(command); err != nil {
+		return err
+	}
+
+	for {
+
+		received, err := client.readMessage()
+		if err != nil {
+			return err
+		}
+
+		if received.ContentType != eslContentTypeCommandReply {
+			continue
+		}
+
+		if replyText := received.Headers["Reply-Text"]; !strings.HasPrefix(replyText, "+OK") {
+			return fmt.Errorf("FreeSWITCH replied \"%s\"", replyText)
+		}
+
+		return nil
+
+	}
+
+}
+
+func (client *ESLClient) writeCommand(command string) error {
+
+	if _, err := io.WriteString(client.connection, command+"\n\n"); err != nil {
+		return fmt.Errorf("could not write to the Event Socket (" + err.Error() + ")")
+	}
+
+	return nil
+
+}
+
+func (client *ESLClient) readMessage() (*ESLMessage, error) {
+
+	headers, err := readESLHeaders(client.reader, false)
+	if err != nil {
+		return nil, fmt.Errorf("could not read from the Event Socket (" + err.Error() + ")")
+	}
+
+	message := new(ESLMessage)
+	message.ContentType = headers["Content-Type"]
+	message.Headers = headers
+
+	if contentLength, err := strconv.Atoi(headers["Content-Length"]); err == nil && contentLength > 0 {
+
+		content := make([]byte, contentLength)
+		if _, err := io.ReadFull(client.reader, content); err != nil {
+			return nil, fmt.Errorf("could not read from the Event Socket (" + err.Error() + ")")
+		}
+
+		message.Body = string(content)
+
+	}
+
+	// NOTE: Plain events are headers (URL encoded values) and, if they have a "Content-Length" themselves, a body
+	if message.ContentType == eslContentTypeEventPlain {
+
+		eventReader := bufio.NewReader(strings.NewReader(message.Body))
+
+		eventHeaders, err := readESLHeaders(eventReader, true)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse an Event Socket event (" + err.Error() + ")")
+		}
+
+		eventBody, _ := ioutil.ReadAll(eventReader)
+
+		message.Headers = eventHeaders
+		message.Body = string(eventBody)
+
+	}
+
+	return message, nil
+
+}
+
+// readESLHeaders Reads "Key: Value" lines up to an empty line, for events ("decodeValues") the end of the event is also the end of the headers
+func readESLHeaders(reader *bufio.Reader, decodeValues bool) (map[string]string, error) {
+
+	headers := make(map[string]string)
+
+	for {
+
+		line, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || !decodeValues) {
+			return nil, err
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			if err == io.EOF {
+				return headers, nil
+			}
+			// NOTE: Empty lines between messages are not a message
+			if len(headers) == 0 {
+				continue
+			}
+			return headers, nil
+		}
+
+		if separatorIndex := strings.Index(line, ":"); separatorIndex != -1 {
+
+			value := strings.TrimSpace(line[separatorIndex+1:])
+			if decodeValues {
+				// NOTE: FreeSWITCH encodes spaces as "%20" and "+" as "%2B" so there are no ambiguous "+"
+				if decoded, err := url.QueryUnescape(value); err == nil {
+					value = decoded
+				}
+			}
+
+			headers[line[:separatorIndex]] = value
+
+		}
+
+		if err == io.EOF {
+			return headers, nil
+		}
+
+	}
+
+}
+
+func (client *ESLClient) getAddress() string {
+
+	host := client.Host
+	if host == "" {
+		host = "localhost"
+	}
+
+	port := client.Port
+	if port == 0 {
+		port = ESLDefaultPort
+	}
+
+	return net.JoinHostPort(host, strconv.FormatUint(uint64(port), 10))
+
+}
+
+func (client *ESLClient) getTimeout() time.Duration {
+
+	if client.Timeout > 0 {
+		return client.Timeout
+	}
+
+	return ESLDefaultTimeout
+
+}
@@ -0,0 +1,297 @@
+package softswitches
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeESL Speaks enough of FreeSWITCH's inbound Event Socket for ESLClient: the auth request, "auth", "event plain" and "api show channels
+// as json" (answered with channelsJSON), after which it sends the plain events in events
+type fakeESL struct {
+	listener     net.Listener
+	password     string
+	channelsJSON string
+	events       []string
+	// NOTE: When true, connections get a disconnect notice and are closed after the events are sent
+	hangUp bool
+
+	mutex       sync.Mutex
+	commands    []string
+	logins      []time.Time
+	connections []net.Conn
+}
+
+func newFakeESL(t *testing.T, password string) *fakeESL {
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &fakeESL{listener: listener, password: password, channelsJSON: `{"row_count":0}`}
+
+}
+
+func (server *fakeESL) start() {
+	go server.serve()
+}
+
+func (server *fakeESL) close() {
+
+	server.listener.Close()
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	for _, connection := range server.connections {
+		connection.Close()
+	}
+
+}
+
+func (server *fakeESL) serve() {
+
+	for {
+
+		connection, err := server.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		server.mutex.Lock()
+		server.connections = append(server.connections, connection)
+		server.mutex.Unlock()
+
+		go server.handle(connection)
+
+	}
+
+}
+
+func (server *fakeESL) handle(connection net.Conn) {
+
+	defer connection.Close()
+
+	reader := bufio.NewReader(connection)
+	send := func(headers string, content string) {
+		if content != "" {
+			headers += "\nContent-Length: " + strconv.Itoa(len(content))
+		}
+		connection.Write([]byte(headers + "\n\n" + content))
+	}
+
+	send("Content-Type: auth/request", "")
+
+	for {
+
+		var lines []string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\n")
+			if line == "" {
+				break
+			}
+			lines = append(lines, line)
+		}
+		command := strings.Join(lines, "\n")
+
+		server.mutex.Lock()
+		server.commands = append(server.commands, command)
+		server.mutex.Unlock()
+
+		switch {
+		case strings.HasPrefix(command, "auth "):
+			if command != "auth "+server.password {
+				send("Content-Type: command/reply\nReply-Text: -ERR invalid", "")
+				return
+			}
+			server.mutex.Lock()
+			server.logins = append(server.logins, time.Now())
+			server.mutex.Unlock()
+			send("Content-Type: command/reply\nReply-Text: +OK accepted", "")
+		case strings.HasPrefix(command, "event plain "):
+			send("Content-Type: command/reply\nReply-Text: +OK event listener enabled plain", "")
+		case command == "api show channels as json":
+			send("Content-Type: api/response", server.channelsJSON)
+			for _, event := range server.events {
+				send("Content-Type: text/event-plain", event)
+			}
+			if server.hangUp {
+				send("Content-Type: text/disconnect-notice", "Disconnected, goodbye.\n")
+				return
+			}
+		default:
+			send("Content-Type: command/reply\nReply-Text: -ERR command not found", "")
+		}
+
+	}
+
+}
+
+func (server *fakeESL) getCommands() []string {
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	return append([]string(nil), server.commands...)
+
+}
+
+func (server *fakeESL) getLogins() []time.Time {
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	return append([]time.Time(nil), server.logins...)
+
+}
+
+func newTestESLClient(server *fakeESL, password string) *ESLClient {
+
+	address := server.listener.Addr().(*net.TCPAddr)
+
+	return &ESLClient{Host: address.IP.String(), Port: uint32(address.Port), Password: password, Timeout: time.Second, MaximumBackoff: 2 * time.Second}
+
+}
+
+func TestESLClientLogin(t *testing.T) {
+
+	server := newFakeESL(t, "ClueCon")
+	server.hangUp = true
+	server.start()
+	defer server.close()
+
+	client := newTestESLClient(server, "")
+
+	err := client.Listen([]string{"CHANNEL_CREATE", "CHANNEL_HANGUP_COMPLETE"}, []string{"api show channels as json"}, func(message *ESLMessage) {})
+	if err == nil || !strings.Contains(err.Error(), "closed") {
+		t.Fatalf("expected Listen to return on the disconnect notice, got %v", err)
+	}
+
+	expected := []string{"auth ClueCon", "event plain CHANNEL_CREATE CHANNEL_HANGUP_COMPLETE", "api show channels as json"}
+	if commands := server.getCommands(); strings.Join(commands, "|") != strings.Join(expected, "|") {
+		t.Fatalf("expected the commands %q, got %q", expected, commands)
+	}
+
+}
+
+func TestESLClientLoginFailed(t *testing.T) {
+
+	server := newFakeESL(t, "ClueCon")
+	server.start()
+	defer server.close()
+
+	client := newTestESLClient(server, "wrong")
+
+	err := client.Listen(eslCallEvents, nil, func(message *ESLMessage) {})
+	if err == nil || !strings.Contains(err.Error(), "-ERR invalid") {
+		t.Fatalf("expected the authentication to fail, got %v", err)
+	}
+
+	if client.backoff.failures != 1 {
+		t.Fatalf("expected 1 failed attempt, got %d", client.backoff.failures)
+	}
+
+	if len(server.getLogins()) != 0 {
+		t.Fatal("expected no logins")
+	}
+
+}
+
+func TestLiveCallsSourceESLEvents(t *testing.T) {
+
+	server := newFakeESL(t, "ClueCon")
+	server.channelsJSON = `{"row_count":1,"rows":[{"uuid":"a1","direction":"inbound","created_epoch":"1469786400","name":"sofia/internal/1000@pbx","state":"CS_EXECUTE","cid_num":"1000","dest":"00244123456789","application":"bridge","application_data":"sofia/gateway/trunk/00244123456789","context":"default"}]}`
+	server.events = []string{
+		"Event-Name: CHANNEL_CREATE\nUnique-ID: b1\nCall-Direction: outbound\nChannel-Name: sofia/gateway/trunk/00244123456789\nChannel-State: CS_INIT\nCaller-Context: default\nCaller-Destination-Number: 00244123456789\nCaller-Caller-ID-Number: 1000\n\n",
+		"Event-Name: CHANNEL_HANGUP_COMPLETE\nUnique-ID: a1\nCall-Direction: inbound\nChannel-Name: sofia/internal/1000@pbx\nCaller-Context: default\nCaller-Caller-ID-Name: John%20Doe\nCaller-Caller-ID-Number: 1000\nCaller-Destination-Number: 00244123456789\nHangup-Cause: NORMAL_CLEARING\nvariable_start_stamp: " + strings.Replace(time.Now().Add(-time.Minute).Format("2006-01-02 15:04:05"), " ", "%20", 1) + "\nvariable_duration: 60\nvariable_billsec: 42\nvariable_accountcode: acme\n\n",
+	}
+	server.start()
+	defer server.close()
+
+	cdrs := NewCDRsSourceMemory(time.Hour)
+	source := NewLiveCallsSourceESLEvents(newTestESLClient(server, "ClueCon"), cdrs)
+	freeswitch := &FreeSwitch{LiveCallsSource: source, CDRsSource: cdrs}
+
+	dialed := SubscribeCallEvents(freeswitch, CallEventDialed)
+	written := SubscribeCallEvents(freeswitch, CallEventCDR)
+
+	go source.Run()
+
+	select {
+	case event := <-dialed:
+		if event.Channel.UniqueID != "b1" || event.Channel.Extension != "00244123456789" {
+			t.Fatalf("expected the outbound channel b1 to be dialed, got %+v", event.Channel)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a dialed event")
+	}
+
+	// NOTE: The CDR event is published after the hang up is in the table
+	select {
+	case event := <-written:
+		if event.CDR.UniqueID != "a1" || event.CDR.BillSec != 42 || event.CDR.CLID != "John Doe" || event.CDR.AccountCode != "acme" {
+			t.Fatalf("expected the CDR of a1, got %+v", event.CDR)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a CDR event")
+	}
+
+	channels, err := source.GetActiveChannels()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(channels) != 1 || channels[0].UniqueID != "b1" || channels[0].Direction != "outbound" {
+		t.Fatalf("expected only the channel b1 to be up, got %+v", channels)
+	}
+
+	hits, err := freeswitch.GetHits(func(destination string, args ...uint32) (string, bool, error) {
+		return "244", true, nil
+	}, time.Hour, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hits["244"] == nil || hits["244"].NumberOfHits != 1 {
+		t.Fatalf("expected 1 Hit from the CDR that was written, got %+v", hits)
+	}
+
+}
+
+func TestLiveCallsSourceESLEventsReconnect(t *testing.T) {
+
+	server := newFakeESL(t, "ClueCon")
+	server.channelsJSON = `{"row_count":1,"rows":[{"uuid":"a1","direction":"inbound","created_epoch":"1469786400","name":"sofia/internal/1000@pbx","dest":"00244123456789"}]}`
+	server.hangUp = true
+	server.start()
+	defer server.close()
+
+	source := NewLiveCallsSourceESLEvents(newTestESLClient(server, "ClueCon"), nil)
+
+	go source.Run()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(server.getLogins()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	logins := server.getLogins()
+	if len(logins) < 2 {
+		t.Fatalf("expected to reconnect after being disconnected, got %d logins", len(logins))
+	}
+
+	// NOTE: Being dropped is a failed attempt, the next one waits for the backoff
+	if wait := logins[1].Sub(logins[0]); wait < minimumBackoff-50*time.Millisecond {
+		t.Fatalf("expected to wait at least %s before reconnecting, waited %s", minimumBackoff, wait)
+	}
+
+}
@@ -0,0 +1,254 @@
+package softswitches
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/andmar/marlog"
+)
+
+// NOTE: CHANNEL_HANGUP_COMPLETE is the one that has all the channel variables (e.g. "variable_billsec") needed to make a CDR out of it
+var eslCallEvents = []string{"CHANNEL_CREATE", "CHANNEL_ANSWER", "CHANNEL_HANGUP_COMPLETE"}
+
+// LiveCallsSourceESLEvents Keeps a table of the channels that are up, built from the events of a persistent FreeSWITCH Event Socket
+// connection (see ESLClient.Listen), and tells subscribers about calls as they happen (see CallEventsSource). If CDRs is set, a CDR is made
+// out of each a-leg that hangs up and added to it. Each time it (re)connects the table is rebuilt from a "show channels"
+type LiveCallsSourceESLEvents struct {
+	Client *ESLClient
+	CDRs   *CDRsSourceMemory
+	callEventsPublisher
+	mutex    sync.Mutex
+	channels map[string]*trackedChannel
+	synced   bool
+}
+
+// NewLiveCallsSourceESLEvents ...
+func NewLiveCallsSourceESLEvents(client *ESLClient, cdrs *CDRsSourceMemory) *LiveCallsSourceESLEvents {
+
+	source := new(LiveCallsSourceESLEvents)
+	source.Client = client
+	source.CDRs = cdrs
+	source.channels = make(map[string]*trackedChannel)
+
+	return source
+
+}
+
+// Run Listens to Event Socket events forever, reconnecting whenever the connection is lost
+func (source *LiveCallsSourceESLEvents) Run() {
+
+	log := marlog.MarLog
+
+	for {
+
+		source.mutex.Lock()
+		source.channels = make(map[string]*trackedChannel)
+		source.synced = false
+		source.mutex.Unlock()
+
+		err := source.Client.Listen(eslCallEvents, []string{"api show channels as json"}, source.handle)
+
+		log.LogS("ERROR", "Lost the Event Socket connection ("+err.Error()+"), reconnecting...")
+
+	}
+
+}
+
+// GetActiveChannels ...
+func (source *LiveCallsSourceESLEvents) GetActiveChannels() ([]*ActiveChannel, error) {
+
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	// NOTE: Until the "show channels" reply is in, the table only has the calls that started after connecting
+	if !source.synced {
+		return nil, fmt.Errorf("could not get the channels, not in sync with Event Socket events")
+	}
+
+	now := time.Now()
+
+	channels := make([]*ActiveChannel, 0, len(source.channels))
+	for _, tracked := range source.channels {
+		channel := *tracked.channel
+		channel.Duration = now.Sub(tracked.startedAt)
+		channels = append(channels, &channel)
+	}
+
+	return channels, nil
+
+}
+
+// SubscribeCallEvents ...
+func (source *LiveCallsSourceESLEvents) SubscribeCallEvents(types ...int) <-chan CallEvent {
+	return source.subscribe(types...)
+}
+
+func (source *LiveCallsSourceESLEvents) handle(message *ESLMessage) {
+
+	log := marlog.MarLog
+
+	if message.ContentType == "api/response" {
+		source.sync(message.Body)
+		return
+	}
+
+	if message.ContentType != eslContentTypeEventPlain {
+		return
+	}
+
+	event := message.Headers
+
+	source.mutex.Lock()
+
+	var callEvent *CallEvent
+
+	switch event["Event-Name"] {
+	case "CHANNEL_CREATE":
+
+		channel := new(ActiveChannel)
+		channel.Channel = event["Channel-Name"]
+		channel.Context = event["Caller-Context"]
+		channel.Extension = event["Caller-Destination-Number"]
+		channel.Direction = event["Call-Direction"]
+		channel.State = event["Channel-State"]
+		channel.CallerID = event["Caller-Caller-ID-Number"]
+		channel.UniqueID = event["Unique-ID"]
+
+		source.channels[channel.UniqueID] = &trackedChannel{channel: channel, startedAt: time.Now()}
+
+		// NOTE: In FreeSWITCH an outbound channel being created is a number being dialed
+		if channel.Direction == "outbound" {
+			callEvent = &CallEvent{Type: CallEventDialed, Channel: channel}
+		} else {
+			callEvent = &CallEvent{Type: CallEventStarted, Channel: channel}
+		}
+
+	case "CHANNEL_ANSWER":
+
+		if tracked, found := source.channels[event["Unique-ID"]]; found {
+			tracked.channel.State = event["Channel-State"]
+		}
+
+	case "CHANNEL_HANGUP_COMPLETE":
+
+		if tracked, found := source.channels[event["Unique-ID"]]; found {
+			delete(source.channels, event["Unique-ID"])
+			callEvent = &CallEvent{Type: CallEventEnded, Channel: tracked.channel}
+		}
+
+	}
+
+	source.mutex.Unlock()
+
+	if callEvent != nil {
+		channel := *callEvent.Channel
+		callEvent.Channel = &channel
+		source.publish(*callEvent)
+	}
+
+	// NOTE: Like mod_cdr_* with the default "legs", only the a-leg (inbound) gets a CDR, the b-leg is the same call
+	if event["Event-Name"] == "CHANNEL_HANGUP_COMPLETE" && event["Call-Direction"] == "inbound" && source.CDRs != nil {
+
+		cdr, err := newCDRFromESLEvent(event)
+		if err != nil {
+			log.LogS("ERROR", "Could not convert a CHANNEL_HANGUP_COMPLETE event to a CDR ("+err.Error()+")")
+			return
+		}
+
+		source.CDRs.Add(cdr)
+
+		copied := *cdr
+		source.publish(CallEvent{Type: CallEventCDR, CDR: &copied})
+
+	}
+
+}
+
+// sync Replaces the table with the channels in the reply to "show channels as json" (see parseFreeSwitchShowChannels)
+func (source *LiveCallsSourceESLEvents) sync(reply string) {
+
+	log := marlog.MarLog
+
+	now := time.Now()
+
+	parsed, err := parseFreeSwitchShowChannels([]byte(reply), now)
+	if err != nil {
+		log.LogS("ERROR", err.Error())
+		return
+	}
+
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	channels := make(map[string]*trackedChannel)
+
+	for _, channel := range parsed {
+		startedAt := now.Add(-channel.Duration)
+		channel.Duration = 0
+		channels[channel.UniqueID] = &trackedChannel{channel: channel, startedAt: startedAt}
+	}
+
+	// NOTE: Channels created while "show channels" was running are already in the table but not in the reply
+	for uniqueID, tracked := range source.channels {
+		if _, found := channels[uniqueID]; !found {
+			channels[uniqueID] = tracked
+		}
+	}
+
+	source.channels = channels
+	source.synced = true
+
+	log.LogS("DEBUG", "In sync with Event Socket events, "+strconv.Itoa(len(source.channels))+" channels up")
+
+}
+
+func newCDRFromESLEvent(event map[string]string) (*CDR, error) {
+
+	var callDate time.Time
+
+	// NOTE: "variable_start_stamp" is local time, "Caller-Channel-Created-Time" (microseconds since the epoch) is there when it's not
+	if startStamp := event["variable_start_stamp"]; startStamp != "" {
+		parsed, err := parseCDRTime(startStamp)
+		if err != nil {
+			return nil, err
+		}
+		callDate = parsed
+	} else {
+		createdTime, err := strconv.ParseInt(event["Caller-Channel-Created-Time"], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("could not get the CDR start time")
+		}
+		callDate = time.Unix(0, createdTime*int64(time.Microsecond))
+	}
+
+	duration, err := parseCDRSeconds(event["variable_duration"])
+	if err != nil {
+		return nil, err
+	}
+
+	billSec, err := parseCDRSeconds(event["variable_billsec"])
+	if err != nil {
+		return nil, err
+	}
+
+	cdr := new(CDR)
+	cdr.CallDate = callDate
+	cdr.CLID = event["Caller-Caller-ID-Name"]
+	cdr.Src = event["Caller-Caller-ID-Number"]
+	cdr.Dst = event["Caller-Destination-Number"]
+	cdr.DContext = event["Caller-Context"]
+	cdr.Channel = event["Channel-Name"]
+	cdr.DstChannel = event["Other-Leg-Channel-Name"]
+	cdr.LastApp = event["variable_last_app"]
+	cdr.LastData = event["variable_last_arg"]
+	cdr.Duration = duration
+	cdr.BillSec = billSec
+	cdr.Disposition = event["Hangup-Cause"]
+	cdr.AccountCode = event["variable_accountcode"]
+	cdr.UniqueID = event["Unique-ID"]
+
+	return cdr, nil
+
+}
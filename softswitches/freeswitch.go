@@ -0,0 +1,220 @@
+package softswitches
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"encoding/json"
+	"os/exec"
+
+	"github.com/andmar/marlog"
+)
+
+const (
+	// NOTE: FreeSWITCH CDRs carry the dialed number as is in "destination_number" so we only need to make sure it's a number
+	// and not something like a feature code (e.g. *97) or an extension name
+	freeswitchDialedNumber = "^\\+?[0-9]+$"
+)
+
+// FreeSwitchCDRColumnMap Column of the "cdr" (mod_cdr_pg_csv/mod_odbc_cdr) and "v_xml_cdr" (FusionPBX) tables for each CDR field, fields
+// mapped to an empty column name don't exist there, any of them can be changed via "column_map" in the CDRs Source configuration
+var FreeSwitchCDRColumnMap = map[string]string{
+	CDRFieldCallDate:    "start_stamp",
+	CDRFieldCLID:        "caller_id_name",
+	CDRFieldSrc:         "caller_id_number",
+	CDRFieldDst:         "destination_number",
+	CDRFieldDContext:    "context",
+	CDRFieldChannel:     "",
+	CDRFieldDstChannel:  "",
+	CDRFieldLastApp:     "",
+	CDRFieldLastData:    "",
+	CDRFieldDuration:    "duration",
+	CDRFieldBillSec:     "billsec",
+	CDRFieldDisposition: "hangup_cause",
+	CDRFieldAMAFlags:    "",
+	CDRFieldAccountCode: "accountcode",
+	CDRFieldUniqueID:    "uuid",
+	CDRFieldUserField:   "",
+}
+
+// FreeSwitch ...
+type FreeSwitch struct {
+	Version         string
+	CDRsSource      CDRsSource
+	LiveCallsSource LiveCallsSource
+}
+
+// GetHits Tries to match "destination_number" CDR field's value against the "matches" function, it works with the CDR tables
+// written by mod_cdr_pg_csv/mod_odbc_cdr ("cdr") and with FusionPBX's "v_xml_cdr" since they share the used column names
+func (freeswitch *FreeSwitch) GetHits(matches func(string, ...uint32) (string, bool, error), considerCDRsFromLast time.Duration, considerCallDuration bool, groupBy GroupBy) (map[string]*Hits, error) {
+	return getHits(freeswitch, matches, considerCDRsFromLast, considerCallDuration, groupBy)
+}
+
+// GetCDRs Gets the CDRs started at or after "since" from the CDRs Source, with DialedNumbers set to "destination_number" (Dst) if it's a number
+func (freeswitch *FreeSwitch) GetCDRs(since time.Time) (CDRsIterator, error) {
+
+	cdrs, err := freeswitch.CDRsSource.GetCDRs(since)
+	if err != nil {
+		return nil, err
+	}
+
+	matchesDialedNumber := regexp.MustCompile(freeswitchDialedNumber)
+
+	dialedNumbers := func(cdr *CDR) []string {
+
+		// NOTE: Ignore if "destination_number" is not a number
+		if matchesDialedNumber.MatchString(cdr.Dst) == false {
+			return nil
+		}
+
+		return []string{strings.TrimPrefix(cdr.Dst, "+")}
+
+	}
+
+	return &cdrsIteratorDialedNumbers{CDRsIterator: cdrs, dialedNumbers: dialedNumbers}, nil
+
+}
+
+// GetCurrentActiveCalls Counts the outbound channels listed by "show channels" (or by the Live Calls Source, if there's one) whose "dest"
+// is a number longer than "minimumNumberLength"
+func (freeswitch *FreeSwitch) GetCurrentActiveCalls(minimumNumberLength uint32) (uint32, error) {
+
+	log := marlog.MarLog
+
+	var channels []*ActiveChannel
+	var err error
+
+	if freeswitch.LiveCallsSource != nil {
+
+		channels, err = freeswitch.LiveCallsSource.GetActiveChannels()
+		if err != nil {
+			return 0, err
+		}
+
+	} else {
+
+		command := exec.Command("fs_cli", "-x", "show channels as json") // NOTE: Fraudion has to have the permission to do this...
+
+		output, err := command.Output()
+		if err != nil {
+			log.LogS("ERROR", err.Error())
+			return 0, err
+		}
+
+		channels, err = parseFreeSwitchShowChannels(output, time.Now())
+		if err != nil {
+			log.LogS("ERROR", err.Error())
+			return 0, err
+		}
+
+	}
+
+	return countFreeSwitchActiveCalls(channels, minimumNumberLength), nil
+
+}
+
+// countFreeSwitchActiveCalls ...
+func countFreeSwitchActiveCalls(channels []*ActiveChannel, minimumNumberLength uint32) uint32 {
+
+	log := marlog.MarLog
+
+	matchesDialedNumber := regexp.MustCompile(freeswitchDialedNumber)
+
+	numberOfCalls := 0
+
+	for _, channel := range channels {
+
+		// NOTE: Calls to the outside world are the outbound legs, inbound legs are the ones coming from phones/trunks into FreeSWITCH
+		if channel.Direction != "outbound" || matchesDialedNumber.MatchString(channel.Extension) == false {
+			continue
+		}
+
+		dialedNumber := strings.TrimPrefix(channel.Extension, "+")
+
+		if uint32(len(dialedNumber)) > minimumNumberLength {
+			numberOfCalls++
+		} else {
+			log.LogS("DEBUG", "Number \""+dialedNumber+"\" is ignored due to length")
+		}
+
+	}
+
+	log.LogS("DEBUG", "Analized "+strconv.Itoa(len(channels))+" channels and found "+strconv.Itoa(numberOfCalls)+" suitable calls")
+
+	return uint32(numberOfCalls)
+
+}
+
+// SubscribeCallEvents Only Live Calls Sources that follow calls as they happen (e.g. LiveCallsSourceESLEvents) have CallEvents, with
+// others the returned channel is nil
+func (freeswitch *FreeSwitch) SubscribeCallEvents(types ...int) <-chan CallEvent {
+
+	if source, ok := freeswitch.LiveCallsSource.(CallEventsSource); ok {
+		return source.SubscribeCallEvents(types...)
+	}
+
+	return nil
+
+}
+
+// parseFreeSwitchShowChannels Parses the output of "show channels as json", which is {"row_count":0} when there are none. The plain
+// "show channels" output is comma separated with no quoting, so any value with a comma in it (e.g. "cid_name", "application_data") shifts
+// the columns after it. Durations are up to "now"
+func parseFreeSwitchShowChannels(output []byte, now time.Time) ([]*ActiveChannel, error) {
+
+	var parsed struct {
+		RowCount int `json:"row_count"`
+		Rows     []struct {
+			UUID         string `json:"uuid"`
+			Direction    string `json:"direction"`
+			CreatedEpoch string `json:"created_epoch"`
+			Name         string `json:"name"`
+			State        string `json:"state"`
+			CIDNum       string `json:"cid_num"`
+			Dest         string `json:"dest"`
+			Application  string `json:"application"`
+			Data         string `json:"application_data"`
+			Context      string `json:"context"`
+			AccountCode  string `json:"accountcode"`
+		} `json:"rows"`
+	}
+
+	if err := json.Unmarshal(output, &parsed); err != nil {
+		return nil, fmt.Errorf("could not parse the \"show channels\" output (" + err.Error() + ")")
+	}
+
+	channels := make([]*ActiveChannel, 0, len(parsed.Rows))
+
+	for _, row := range parsed.Rows {
+
+		channel := new(ActiveChannel)
+		channel.Channel = row.Name
+		channel.Context = row.Context
+		channel.Extension = row.Dest
+		channel.Direction = row.Direction
+		channel.State = row.State
+		channel.Application = row.Application
+		channel.Data = row.Data
+		channel.CallerID = row.CIDNum
+		channel.AccountCode = row.AccountCode
+		channel.UniqueID = row.UUID
+
+		if createdEpoch, err := strconv.ParseInt(row.CreatedEpoch, 10, 64); err == nil && now.Unix() > createdEpoch {
+			channel.Duration = now.Sub(time.Unix(createdEpoch, 0))
+		}
+
+		channels = append(channels, channel)
+
+	}
+
+	return channels, nil
+
+}
+
+// GetCDRsSource ...
+func (freeswitch *FreeSwitch) GetCDRsSource() CDRsSource {
+	return freeswitch.CDRsSource
+}
@@ -0,0 +1,59 @@
+package softswitches
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseFreeSwitchShowChannels(t *testing.T) {
+
+	now := time.Unix(1469786465, 0)
+
+	// NOTE: The commas in "cid_name" and "application_data" would shift every column after them in the plain "show channels" output
+	output := []byte(`{"row_count":3,"rows":[` +
+		`{"uuid":"a1","direction":"inbound","created":"2016-07-29 10:00:00","created_epoch":"1469786400","name":"sofia/internal/1000@pbx","state":"CS_EXECUTE","cid_name":"Doe, John","cid_num":"1000","ip_addr":"10.0.0.10","dest":"00244123456789","application":"bridge","application_data":"{origination_caller_id_name='Doe, John'}sofia/gateway/trunk/00244123456789","dialplan":"XML","context":"default","read_codec":"PCMA","read_rate":"8000","read_bit_rate":"64000","write_codec":"PCMA","write_rate":"8000","write_bit_rate":"64000","secure":"","hostname":"pbx","presence_id":"","presence_data":"","accountcode":"acme","callstate":"ACTIVE","callee_name":"","callee_num":"","callee_direction":"","call_uuid":"","sent_callee_name":"","sent_callee_num":"","initial_cid_name":"Doe, John","initial_cid_num":"1000","initial_ip_addr":"10.0.0.10","initial_dest":"00244123456789","initial_dialplan":"XML","initial_context":"default"},` +
+		`{"uuid":"b1","direction":"outbound","created_epoch":"1469786405","name":"sofia/gateway/trunk/00244123456789","state":"CS_EXCHANGE_MEDIA","cid_name":"Doe, John","cid_num":"1000","dest":"00244123456789","application":"","application_data":"","context":"default","accountcode":"acme"},` +
+		`{"uuid":"c1","direction":"outbound","created_epoch":"1469786460","name":"sofia/internal/1001@pbx","state":"CS_CONSUME_MEDIA","cid_name":"Reception","cid_num":"1000","dest":"1001","application":"","application_data":"","context":"default","accountcode":""}` +
+		`]}`)
+
+	channels, err := parseFreeSwitchShowChannels(output, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(channels) != 3 {
+		t.Fatalf("expected 3 channels, got %d", len(channels))
+	}
+
+	expected := ActiveChannel{
+		Channel:     "sofia/internal/1000@pbx",
+		Context:     "default",
+		Extension:   "00244123456789",
+		Direction:   "inbound",
+		State:       "CS_EXECUTE",
+		Application: "bridge",
+		Data:        "{origination_caller_id_name='Doe, John'}sofia/gateway/trunk/00244123456789",
+		CallerID:    "1000",
+		AccountCode: "acme",
+		Duration:    65 * time.Second,
+		UniqueID:    "a1",
+	}
+	if *channels[0] != expected {
+		t.Fatalf("expected %+v, got %+v", expected, *channels[0])
+	}
+
+	// NOTE: Only the outbound leg to the outside world is a live call, the one to an extension is too short a number
+	if calls := countFreeSwitchActiveCalls(channels, 5); calls != 1 {
+		t.Fatalf("expected 1 live call, got %d", calls)
+	}
+
+	channels, err = parseFreeSwitchShowChannels([]byte(`{"row_count":0}`), now)
+	if err != nil || len(channels) != 0 {
+		t.Fatalf("expected no channels, got %v (%v)", channels, err)
+	}
+
+	if _, err := parseFreeSwitchShowChannels([]byte("-ERR no reply\n"), now); err == nil {
+		t.Fatal("expected an error parsing something that's not JSON")
+	}
+
+}
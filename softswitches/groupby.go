@@ -0,0 +1,105 @@
+package softswitches
+
+import (
+	"strings"
+)
+
+const (
+	// GroupByPrefix ...
+	GroupByPrefix = "*prefix"
+	// GroupBySrc ...
+	GroupBySrc = "*src"
+	// GroupByAccountCode ...
+	GroupByAccountCode = "*accountcode"
+	// GroupByChannelPeer ...
+	GroupByChannelPeer = "*channel_peer"
+	// GroupByDContext ...
+	GroupByDContext = "*dcontext"
+	// GroupBySoftswitch All the calls of the Softswitch are in the same group, its value is empty because CDRs don't know where they come
+	// from (see Hits.Softswitch)
+	GroupBySoftswitch = "*softswitch"
+	// GroupKeySeparator Separates the values of each field in the key of a group (e.g. "1000|244" grouped by "*src" and "*prefix")
+	GroupKeySeparator = "|"
+)
+
+// GroupBy The fields Hits are counted by, in this order, a group is the Hits of the CDRs that have the same values for all of them (e.g.
+// the calls from extension "1000" to "244" grouped by "*src" and "*prefix"). Without fields Hits are counted by prefix
+type GroupBy []string
+
+// group Returns the key of the group "cdr" and the prefix its dialed number matched are in, with the value of each field
+func (groupBy GroupBy) group(cdr *CDR, prefix string) (string, map[string]string) {
+
+	if len(groupBy) == 0 {
+		return prefix, map[string]string{GroupByPrefix: prefix}
+	}
+
+	values := make(map[string]string, len(groupBy))
+	keyValues := make([]string, len(groupBy))
+
+	for index, field := range groupBy {
+
+		var value string
+		switch field {
+		case GroupByPrefix:
+			value = prefix
+		case GroupBySrc:
+			value = cdr.Src
+		case GroupByAccountCode:
+			value = cdr.AccountCode
+		case GroupByChannelPeer:
+			value = ChannelPeer(cdr.Channel)
+		case GroupByDContext:
+			value = cdr.DContext
+		case GroupBySoftswitch:
+			value = ""
+		}
+
+		values[field] = value
+		keyValues[index] = value
+
+	}
+
+	return strings.Join(keyValues, GroupKeySeparator), values
+
+}
+
+// ChannelPeer Returns the peer, extension or trunk, of a channel name, without what makes each channel to it unique (e.g.
+// "SIP/trunk-0000001a" is "SIP/trunk", "Local/1000@from-internal-0000;1" is "Local/1000" and FreeSWITCH's
+// "sofia/internal/1000@10.0.0.1" is "sofia/internal/1000")
+func ChannelPeer(channel string) string {
+
+	if index := strings.LastIndex(channel, ";"); index != -1 {
+		channel = channel[:index]
+	}
+
+	if slash, dash := strings.Index(channel, "/"), strings.LastIndex(channel, "-"); slash != -1 && dash > slash {
+		channel = channel[:dash]
+	}
+
+	if index := strings.Index(channel, "@"); index != -1 {
+		channel = channel[:index]
+	}
+
+	return channel
+
+}
+
+// addHit Adds the dialed number at "index" of "cdr", which matched "prefix", to its group in "hits" and returns the key of that group
+func addHit(hits map[string]*Hits, cdr *CDR, index int, prefix string, groupBy GroupBy) string {
+
+	key, values := groupBy.group(cdr, prefix)
+
+	if _, found := hits[key]; found != true {
+		hits[key] = new(Hits)
+		hits[key].Group = key
+		hits[key].GroupValues = values
+		hits[key].Prefix = values[GroupByPrefix]
+	}
+	hits[key].NumberOfHits++
+	hits[key].Destinations = append(hits[key].Destinations, cdr.DialedNumbers[index])
+	hits[key].RawDestinations = append(hits[key].RawDestinations, cdr.rawDialedNumber(index))
+	hits[key].BillSecs = append(hits[key].BillSecs, cdr.BillSec)
+
+	return key
+
+}
@@ -0,0 +1,159 @@
+package softswitches
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/andmar/marlog"
+)
+
+// HitsWindow Keeps the Hits of the CDRs in the last Window up to date without going through all of them on every Update, only new CDRs
+// (see CDRsCursor) are matched and added while the ones that fell out of the Window are taken out
+type HitsWindow struct {
+	Window  time.Duration
+	cursor  *CDRsCursor
+	entries []hitsWindowEntry
+	hits    map[string]*Hits
+}
+
+type hitsWindowEntry struct {
+	callDate    time.Time
+	group       string
+	destination string
+	// NOTE: As it was dialed, see CDR.RawDialedNumbers
+	rawDestination string
+	billSec        uint32
+	inProgress     bool
+}
+
+// NewHitsWindow ...
+func NewHitsWindow(window time.Duration) *HitsWindow {
+
+	hitsWindow := new(HitsWindow)
+	hitsWindow.Window = window
+	hitsWindow.cursor = NewCDRsCursor()
+	hitsWindow.hits = make(map[string]*Hits)
+
+	return hitsWindow
+
+}
+
+// Update Does what Softswitch.GetHits does but incrementally, returns the Hits in the Window after adding the new CDRs that "matches" and
+// evicting the ones that are now out of it
+func (hitsWindow *HitsWindow) Update(softswitch Softswitch, matches func(string, ...uint32) (string, bool, error), considerCallDuration bool, groupBy GroupBy) (map[string]*Hits, error) {
+
+	log := marlog.MarLog
+
+	since := hitsSince(hitsWindow.Window)
+
+	cdrs, err := hitsWindow.cursor.Read(softswitch, since)
+	if err != nil {
+		log.LogS("ERROR", "could not get the new CDRs")
+		return nil, err
+	}
+
+	// NOTE: Before adding the new CDRs, the ones of calls that were in progress on the last Update are in "cdrs" again
+	numberOfCDRsEvicted := hitsWindow.evict(since)
+
+	numberOfCDRsMatched := 0
+
+	for _, cdr := range cdrs {
+
+		for index, dialedNumber := range cdr.DialedNumbers {
+
+			var prefix string
+			var matched bool
+			if !considerCallDuration {
+				prefix, matched, err = matches(dialedNumber)
+			} else {
+				prefix, matched, err = matches(dialedNumber, cdr.BillSec)
+			}
+			if err != nil {
+				log.LogS("ERROR", "Number not suitable")
+				return nil, err
+			}
+
+			if matched == true {
+
+				numberOfCDRsMatched++
+
+				group := addHit(hitsWindow.hits, cdr, index, prefix, groupBy)
+
+				hitsWindow.entries = append(hitsWindow.entries, hitsWindowEntry{callDate: cdr.CallDate, group: group, destination: dialedNumber, rawDestination: cdr.rawDialedNumber(index), billSec: cdr.BillSec, inProgress: cdr.InProgress})
+
+			}
+
+		}
+
+	}
+
+	log.LogS("INFO", "Results: New: "+strconv.Itoa(len(cdrs))+", Matched: "+strconv.Itoa(numberOfCDRsMatched)+", Evicted: "+strconv.Itoa(numberOfCDRsEvicted)+", In Window: "+strconv.Itoa(len(hitsWindow.entries)))
+
+	return hitsWindow.copyHits(), nil
+
+}
+
+// evict Takes the entries from before "since", and the ones of calls that were in progress, out of the Window and out of the Hits, entries
+// are not ordered by "calldate" because late CDRs (see CDRsCursor) are appended when they show up
+func (hitsWindow *HitsWindow) evict(since time.Time) int {
+
+	kept := hitsWindow.entries[:0]
+	numberOfEvicted := 0
+
+	for _, entry := range hitsWindow.entries {
+
+		if !entry.callDate.Before(since) && !entry.inProgress {
+			kept = append(kept, entry)
+			continue
+		}
+
+		numberOfEvicted++
+
+		hits, found := hitsWindow.hits[entry.group]
+		if found == false {
+			continue
+		}
+
+		hits.NumberOfHits--
+		for index, destination := range hits.Destinations {
+			if destination == entry.destination && hits.RawDestinations[index] == entry.rawDestination && hits.BillSecs[index] == entry.billSec {
+				hits.Destinations = append(hits.Destinations[:index], hits.Destinations[index+1:]...)
+				hits.RawDestinations = append(hits.RawDestinations[:index], hits.RawDestinations[index+1:]...)
+				hits.BillSecs = append(hits.BillSecs[:index], hits.BillSecs[index+1:]...)
+				break
+			}
+		}
+
+		if hits.NumberOfHits == 0 {
+			delete(hitsWindow.hits, entry.group)
+		}
+
+	}
+
+	hitsWindow.entries = kept
+
+	return numberOfEvicted
+
+}
+
+// copyHits The returned Hits are handed to action chains, they can't change under them on the next Update
+func (hitsWindow *HitsWindow) copyHits() map[string]*Hits {
+
+	result := make(map[string]*Hits, len(hitsWindow.hits))
+
+	for group, hits := range hitsWindow.hits {
+		result[group] = &Hits{
+			Softswitch:      hits.Softswitch,
+			Group:           hits.Group,
+			GroupValues:     hits.GroupValues,
+			Prefix:          hits.Prefix,
+			NumberOfHits:    hits.NumberOfHits,
+			Destinations:    append([]string(nil), hits.Destinations...),
+			RawDestinations: append([]string(nil), hits.RawDestinations...),
+			BillSecs:        append([]uint32(nil), hits.BillSecs...),
+		}
+	}
+
+	return result
+
+}
@@ -0,0 +1,128 @@
+package softswitches
+
+import (
+	"testing"
+	"time"
+)
+
+// recordingSoftswitch Has the CDRs in cdrs and remembers from when it was asked for them on each GetCDRs
+type recordingSoftswitch struct {
+	cdrs  []*CDR
+	asked []time.Time
+}
+
+func (softswitch *recordingSoftswitch) GetCDRsSource() CDRsSource {
+	return nil
+}
+
+func (softswitch *recordingSoftswitch) GetCDRs(since time.Time) (CDRsIterator, error) {
+
+	softswitch.asked = append(softswitch.asked, since)
+
+	var cdrs []*CDR
+	for _, cdr := range softswitch.cdrs {
+		if !cdr.CallDate.Before(since) {
+			cdrs = append(cdrs, cdr)
+		}
+	}
+
+	return newCDRsIteratorSlice(cdrs), nil
+
+}
+
+func (softswitch *recordingSoftswitch) GetHits(matches func(string, ...uint32) (string, bool, error), considerCDRsFromLast time.Duration, considerCallDuration bool, groupBy GroupBy) (map[string]*Hits, error) {
+	return getHits(softswitch, matches, considerCDRsFromLast, considerCallDuration, groupBy)
+}
+
+func (softswitch *recordingSoftswitch) GetCurrentActiveCalls(minimumNumberLength uint32) (uint32, error) {
+	return 0, nil
+}
+
+func (softswitch *recordingSoftswitch) write(callDate time.Time, uniqueID string) {
+	softswitch.cdrs = append(softswitch.cdrs, &CDR{CallDate: callDate, UniqueID: uniqueID, DialedNumbers: []string{"00244123456789"}})
+}
+
+func matchesAll(destination string, args ...uint32) (string, bool, error) {
+	return "244", true, nil
+}
+
+// approximately Tells if "value" is "expected" give or take the time the test takes to run
+func approximately(value time.Time, expected time.Time) bool {
+	return value.Sub(expected) < time.Second && expected.Sub(value) < time.Second
+}
+
+func TestHitsWindowReadsOnlyNewCDRs(t *testing.T) {
+
+	now := time.Now()
+
+	softswitch := new(recordingSoftswitch)
+	softswitch.write(now.Add(-20*time.Hour), "1")
+	softswitch.write(now.Add(-3*time.Hour), "2")
+	softswitch.write(now.Add(-10*time.Minute), "3")
+
+	hitsWindow := NewHitsWindow(24 * time.Hour)
+
+	hits, err := hitsWindow.Update(softswitch, matchesAll, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hits["244"].NumberOfHits != 3 {
+		t.Fatalf("expected 3 Hits, got %d", hits["244"].NumberOfHits)
+	}
+
+	if !approximately(softswitch.asked[0], now.Add(-24*time.Hour)) {
+		t.Fatalf("expected the first Update to ask for the whole window, asked from %s", softswitch.asked[0])
+	}
+
+	// NOTE: A new call and a long one that started before the latest CDR but ended (had its CDR written) only now
+	softswitch.write(now.Add(-time.Minute), "4")
+	softswitch.write(now.Add(-90*time.Minute), "5")
+
+	hits, err = hitsWindow.Update(softswitch, matchesAll, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hits["244"].NumberOfHits != 5 {
+		t.Fatalf("expected 5 Hits, got %d", hits["244"].NumberOfHits)
+	}
+
+	// NOTE: Only the CDRs from the cursor's Lookback before the latest one seen are asked for again, not the whole window
+	if expected := now.Add(-10*time.Minute - DefaultCDRsCursorLookback); !softswitch.asked[1].Equal(expected) {
+		t.Fatalf("expected the second Update to ask from %s, asked from %s", expected, softswitch.asked[1])
+	}
+
+}
+
+func TestHitsWindowShorterThanLookback(t *testing.T) {
+
+	now := time.Now()
+
+	softswitch := new(recordingSoftswitch)
+	softswitch.write(now.Add(-5*time.Minute), "1")
+
+	hitsWindow := NewHitsWindow(10 * time.Minute)
+
+	if _, err := hitsWindow.Update(softswitch, matchesAll, false, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// NOTE: A call that started before the latest CDR seen, still in the window, whose CDR was written after the last Update
+	softswitch.write(now.Add(-8*time.Minute), "2")
+
+	hits, err := hitsWindow.Update(softswitch, matchesAll, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hits["244"].NumberOfHits != 2 {
+		t.Fatalf("expected the late CDR to be in the window, got %d Hits", hits["244"].NumberOfHits)
+	}
+
+	// NOTE: The Lookback goes back further than the window, which is as far as it makes sense to ask
+	if !approximately(softswitch.asked[1], now.Add(-10*time.Minute)) {
+		t.Fatalf("expected the second Update to ask for the window, asked from %s", softswitch.asked[1])
+	}
+
+}
@@ -0,0 +1,94 @@
+package softswitches
+
+import (
+	"strconv"
+	"time"
+
+	"os/exec"
+
+	"github.com/andmar/marlog"
+)
+
+const (
+	// LiveCallSourceCLI ...
+	LiveCallSourceCLI = "*cli"
+	// LiveCallSourceAMI ...
+	LiveCallSourceAMI = "*ami"
+	// LiveCallSourceEventSocket ...
+	LiveCallSourceEventSocket = "*event_socket"
+)
+
+// ActiveChannel A channel that is up right now, fields are named after the columns of Asterisk's "core show channels" whatever the Live
+// Calls Source they came from, Direction is only known for FreeSWITCH
+type ActiveChannel struct {
+	Channel     string
+	Context     string
+	Extension   string
+	State       string
+	Application string
+	Data        string
+	Direction   string
+	CallerID    string
+	AccountCode string
+	Duration    time.Duration
+	BridgedTo   string
+	UniqueID    string
+}
+
+// LiveCallsSource Where a Softswitch gets the channels that are up right now from
+type LiveCallsSource interface {
+	GetActiveChannels() ([]*ActiveChannel, error)
+}
+
+// LiveCallsSourceCLI Runs "core show channels concise" through the Asterisk CLI, Fraudion has to have the permission to do this... The
+// output is parsed as printed by Asterisk Version (see "parseAsteriskCoreShowChannelsConcise")
+type LiveCallsSourceCLI struct {
+	Version string
+}
+
+// GetActiveChannels ...
+func (source *LiveCallsSourceCLI) GetActiveChannels() ([]*ActiveChannel, error) {
+
+	log := marlog.MarLog
+
+	command := exec.Command("asterisk", "-rx", "core show channels concise")
+
+	output, err := command.Output()
+	if err != nil {
+		log.LogS("ERROR", "could not run the Asterisk CLI ("+err.Error()+")")
+		return nil, err
+	}
+
+	channels, err := parseAsteriskCoreShowChannelsConcise(source.Version, output)
+	if err != nil {
+		log.LogS("ERROR", "could not parse the Asterisk CLI output ("+err.Error()+")")
+		return nil, err
+	}
+
+	log.LogS("DEBUG", "Asterisk CLI listed "+strconv.Itoa(len(channels))+" channels")
+
+	return channels, nil
+
+}
+
+// LiveCallsSourceAMI Asks Asterisk for its channels via the Asterisk Manager Interface (see AMIClient)
+type LiveCallsSourceAMI struct {
+	Client *AMIClient
+}
+
+// GetActiveChannels ...
+func (source *LiveCallsSourceAMI) GetActiveChannels() ([]*ActiveChannel, error) {
+
+	log := marlog.MarLog
+
+	channels, err := source.Client.CoreShowChannels()
+	if err != nil {
+		log.LogS("ERROR", "could not get the channels via AMI ("+err.Error()+")")
+		return nil, err
+	}
+
+	log.LogS("DEBUG", "AMI listed "+strconv.Itoa(len(channels))+" channels")
+
+	return channels, nil
+
+}
@@ -0,0 +1,118 @@
+package softswitches
+
+import (
+	"strings"
+	"time"
+)
+
+// NumberingPlan How numbers are dialed on a Softswitch, it's what Normalize needs to know to turn them into E.164 numbers. TrunkPrefixes
+// are digits dialed to get an outside line (e.g. "9", "0") and are stripped first, then numbers starting with one of InternationalPrefixes
+// (e.g. "00", "011") or "+" are international and numbers starting with NationalPrefix (e.g. "0" in the UK) are national ones of
+// CountryCode. Where there's no NationalPrefix (e.g. Portugal, where national numbers are 9 digits dialed as they are) numbers with at
+// least MinimumNationalLength digits and no prefix are national, unless they are that long after CountryCode (they already have it), if
+// it's 0 they are left as they are (e.g. extensions, short codes)
+type NumberingPlan struct {
+	InternationalPrefixes []string
+	NationalPrefix        string
+	TrunkPrefixes         []string
+	CountryCode           string
+	MinimumNationalLength uint32
+}
+
+// Normalize Returns "number" as an E.164 number without the "+" (e.g. "00244123456789" is "244123456789"), numbers that are not
+// international nor national (e.g. extensions) are returned as they are
+func (plan *NumberingPlan) Normalize(number string) string {
+
+	if strings.HasPrefix(number, "+") {
+		return number[1:]
+	}
+
+	if trunkPrefix := longestPrefix(number, plan.TrunkPrefixes); trunkPrefix != "" {
+		number = number[len(trunkPrefix):]
+	}
+
+	if internationalPrefix := longestPrefix(number, plan.InternationalPrefixes); internationalPrefix != "" {
+		return number[len(internationalPrefix):]
+	}
+
+	if plan.NationalPrefix != "" {
+		if strings.HasPrefix(number, plan.NationalPrefix) && len(number) > len(plan.NationalPrefix) {
+			return plan.CountryCode + number[len(plan.NationalPrefix):]
+		}
+		return number
+	}
+
+	if plan.MinimumNationalLength > 0 && uint32(len(number)) >= plan.MinimumNationalLength {
+		// NOTE: Already E.164 (e.g. trunks that take numbers of the country with its code and no prefix), national numbers are not that long
+		if strings.HasPrefix(number, plan.CountryCode) && uint32(len(number)) >= uint32(len(plan.CountryCode))+plan.MinimumNationalLength {
+			return number
+		}
+		return plan.CountryCode + number
+	}
+
+	return number
+
+}
+
+// longestPrefix Returns the longest of "prefixes" that "number" starts with, an empty string if none
+func longestPrefix(number string, prefixes []string) string {
+
+	result := ""
+	for _, prefix := range prefixes {
+		if len(prefix) > len(result) && strings.HasPrefix(number, prefix) {
+			result = prefix
+		}
+	}
+
+	return result
+
+}
+
+// NumberNormalizer Sits in front of a Softswitch and turns the DialedNumbers of its CDRs into E.164 numbers (see NumberingPlan.Normalize)
+// before monitors see them, what was actually dialed is kept in the CDRs' RawDialedNumbers. Everything else goes straight to the wrapped
+// Softswitch
+type NumberNormalizer struct {
+	Softswitch
+	Plan *NumberingPlan
+}
+
+// NewNumberNormalizer ...
+func NewNumberNormalizer(softswitch Softswitch, plan *NumberingPlan) *NumberNormalizer {
+
+	normalizer := new(NumberNormalizer)
+	normalizer.Softswitch = softswitch
+	normalizer.Plan = plan
+
+	return normalizer
+
+}
+
+// GetHits ...
+func (normalizer *NumberNormalizer) GetHits(matches func(string, ...uint32) (string, bool, error), considerCDRsFromLast time.Duration, considerCallDuration bool, groupBy GroupBy) (map[string]*Hits, error) {
+	return getHits(normalizer, matches, considerCDRsFromLast, considerCallDuration, groupBy)
+}
+
+// GetCDRs ...
+func (normalizer *NumberNormalizer) GetCDRs(since time.Time) (CDRsIterator, error) {
+
+	cdrs, err := normalizer.Softswitch.GetCDRs(since)
+	if err != nil {
+		return nil, err
+	}
+
+	dialedNumbers := func(cdr *CDR) []string {
+
+		cdr.RawDialedNumbers = cdr.DialedNumbers
+
+		var normalized []string
+		for _, dialedNumber := range cdr.DialedNumbers {
+			normalized = append(normalized, normalizer.Plan.Normalize(dialedNumber))
+		}
+
+		return normalized
+
+	}
+
+	return &cdrsIteratorDialedNumbers{CDRsIterator: cdrs, dialedNumbers: dialedNumbers}, nil
+
+}
@@ -0,0 +1,71 @@
+package softswitches
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNumberingPlanNormalize(t *testing.T) {
+
+	// NOTE: National numbers have a national prefix, "9" gets an outside line
+	uk := &NumberingPlan{InternationalPrefixes: []string{"00"}, NationalPrefix: "0", TrunkPrefixes: []string{"9"}, CountryCode: "44"}
+	// NOTE: Two international prefixes, one is the beginning of the other
+	us := &NumberingPlan{InternationalPrefixes: []string{"011", "0111"}, NationalPrefix: "1", CountryCode: "1"}
+	// NOTE: No national prefix, national numbers are 9 digits
+	pt := &NumberingPlan{InternationalPrefixes: []string{"00"}, CountryCode: "351", MinimumNationalLength: 9}
+
+	tests := []struct {
+		plan     *NumberingPlan
+		number   string
+		expected string
+	}{
+		{uk, "00244123456789", "244123456789"},
+		{uk, "900244123456789", "244123456789"},
+		{uk, "02071234567", "442071234567"},
+		{uk, "902071234567", "442071234567"},
+		{uk, "+244123456789", "244123456789"},
+		{uk, "0", "0"},
+		{uk, "1000", "1000"},
+		{us, "011244123456789", "244123456789"},
+		{us, "0111244123456789", "244123456789"},
+		{us, "12125551234", "12125551234"},
+		{us, "+12125551234", "12125551234"},
+		{pt, "00244123456789", "244123456789"},
+		{pt, "912345678", "351912345678"},
+		{pt, "+351912345678", "351912345678"},
+		// NOTE: Already E.164 but without the "+"
+		{pt, "351912345678", "351912345678"},
+		{pt, "1000", "1000"},
+		{pt, "*97", "*97"},
+	}
+
+	for _, test := range tests {
+		if normalized := test.plan.Normalize(test.number); normalized != test.expected {
+			t.Errorf("+%s: expected \"%s\" to be \"%s\", got \"%s\"", test.plan.CountryCode, test.number, test.expected, normalized)
+		}
+	}
+
+}
+
+func TestNumberNormalizer(t *testing.T) {
+
+	softswitch := new(recordingSoftswitch)
+	softswitch.cdrs = []*CDR{{CallDate: time.Now(), DialedNumbers: []string{"00244123456789", "912345678"}}}
+
+	normalizer := NewNumberNormalizer(softswitch, &NumberingPlan{InternationalPrefixes: []string{"00"}, CountryCode: "351", MinimumNationalLength: 9})
+
+	cdrs, err := normalizer.GetCDRs(time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cdrs.Next() == false {
+		t.Fatal("expected a CDR")
+	}
+
+	if cdr := cdrs.CDR(); !reflect.DeepEqual(cdr.DialedNumbers, []string{"244123456789", "351912345678"}) || !reflect.DeepEqual(cdr.RawDialedNumbers, []string{"00244123456789", "912345678"}) {
+		t.Fatalf("expected the dialed numbers to be normalized and kept as dialed, got %v and %v", cdr.DialedNumbers, cdr.RawDialedNumbers)
+	}
+
+}
@@ -0,0 +1,79 @@
+package softswitches
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/andmar/marlog"
+)
+
+// Replay Sits in front of a Softswitch with the CDRs it had in a period of time and answers GetCDRs/GetHits as if the current time was
+// Now, which whoever replays that period (e.g. a backtest of the monitors) moves along it. At Now only the CDRs of the calls that had
+// already ended by then are there, like they would have been, everything else goes straight to the wrapped Softswitch
+type Replay struct {
+	Softswitch
+	Now  time.Time
+	cdrs []*CDR
+}
+
+// NewReplay Gets from "softswitch" the CDRs started from "from" to "to", calls that did not end yet are left out
+func NewReplay(softswitch Softswitch, from time.Time, to time.Time) (*Replay, error) {
+
+	log := marlog.MarLog
+
+	cdrs, err := softswitch.GetCDRs(from)
+	if err != nil {
+		return nil, err
+	}
+
+	defer cdrs.Close()
+
+	replay := new(Replay)
+	replay.Softswitch = softswitch
+	replay.Now = from
+
+	for cdrs.Next() {
+		cdr := cdrs.CDR()
+		if cdr.InProgress || cdr.CallDate.After(to) {
+			continue
+		}
+		copied := *cdr
+		replay.cdrs = append(replay.cdrs, &copied)
+	}
+
+	if err := cdrs.Err(); err != nil {
+		return nil, err
+	}
+
+	log.LogS("DEBUG", "Replaying "+strconv.Itoa(len(replay.cdrs))+" CDRs from "+from.String()+" to "+to.String())
+
+	return replay, nil
+
+}
+
+// GetHits Counts the Hits in the CDRs from "considerCDRsFromLast" before Now
+func (replay *Replay) GetHits(matches func(string, ...uint32) (string, bool, error), considerCDRsFromLast time.Duration, considerCallDuration bool, groupBy GroupBy) (map[string]*Hits, error) {
+	return getHitsSince(replay, replay.Now.Add(-considerCDRsFromLast), matches, considerCallDuration, groupBy)
+}
+
+// GetCDRs Returns the CDRs started at or after "since" of the calls that ended by Now
+func (replay *Replay) GetCDRs(since time.Time) (CDRsIterator, error) {
+
+	var cdrs []*CDR
+	for _, cdr := range replay.cdrs {
+		endedAt := cdr.CallDate.Add(time.Duration(cdr.Duration) * time.Second)
+		if !cdr.CallDate.Before(since) && !endedAt.After(replay.Now) {
+			copied := *cdr
+			cdrs = append(cdrs, &copied)
+		}
+	}
+
+	return newCDRsIteratorSlice(cdrs), nil
+
+}
+
+// GetCurrentActiveCalls ...
+func (replay *Replay) GetCurrentActiveCalls(minimumNumberLength uint32) (uint32, error) {
+	return 0, fmt.Errorf("there are no live calls in a replay")
+}
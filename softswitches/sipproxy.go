@@ -0,0 +1,121 @@
+package softswitches
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// KamailioCDRColumnMap Column of Kamailio's "acc" and "missed_calls" tables for each CDR field, the "src_*" and "dst_*" ones are the
+// "db_extra" of the default kamailio.cfg ("src_user=$fU;src_domain=$fd;src_ip=$si;dst_ouser=$tU;dst_user=$rU;dst_domain=$rd"), "dst_user"
+// is the user part of the R-URI which is what was dialed. Fields mapped to an empty column name don't exist there, any of them can be
+// changed via "column_map" in the CDRs Source configuration (e.g. "dst": "ruri" if the whole R-URI is logged)
+var KamailioCDRColumnMap = map[string]string{
+	CDRFieldCallDate:    "time",
+	CDRFieldCLID:        "",
+	CDRFieldSrc:         "src_user",
+	CDRFieldDst:         "dst_user",
+	CDRFieldDContext:    "dst_domain",
+	CDRFieldChannel:     "src_ip",
+	CDRFieldDstChannel:  "",
+	CDRFieldLastApp:     "method",
+	CDRFieldLastData:    "sip_reason",
+	CDRFieldDuration:    "",
+	CDRFieldBillSec:     "",
+	CDRFieldDisposition: "sip_code",
+	CDRFieldAMAFlags:    "",
+	CDRFieldAccountCode: "src_domain",
+	CDRFieldUniqueID:    "callid",
+	CDRFieldUserField:   "",
+}
+
+// OpenSIPSCDRColumnMap Same as KamailioCDRColumnMap but with OpenSIPS's "duration" column, which is there when calls are accounted with
+// the "cdr" flag
+var OpenSIPSCDRColumnMap = map[string]string{
+	CDRFieldCallDate:    "time",
+	CDRFieldCLID:        "",
+	CDRFieldSrc:         "src_user",
+	CDRFieldDst:         "dst_user",
+	CDRFieldDContext:    "dst_domain",
+	CDRFieldChannel:     "src_ip",
+	CDRFieldDstChannel:  "",
+	CDRFieldLastApp:     "method",
+	CDRFieldLastData:    "sip_reason",
+	CDRFieldDuration:    "duration",
+	CDRFieldBillSec:     "duration",
+	CDRFieldDisposition: "sip_code",
+	CDRFieldAMAFlags:    "",
+	CDRFieldAccountCode: "src_domain",
+	CDRFieldUniqueID:    "callid",
+	CDRFieldUserField:   "",
+}
+
+// SIPProxy A SIP proxy (Kamailio, OpenSIPS) that accounts calls with its "acc" module (see CDRsSourceAccounting), proxies don't have
+// channels so there are no live calls to count
+type SIPProxy struct {
+	Version    string
+	CDRsSource CDRsSource
+}
+
+// GetHits Tries to match the user part of the R-URI of the INVITEs (Dst) against the "matches" function
+func (proxy *SIPProxy) GetHits(matches func(string, ...uint32) (string, bool, error), considerCDRsFromLast time.Duration, considerCallDuration bool, groupBy GroupBy) (map[string]*Hits, error) {
+	return getHits(proxy, matches, considerCDRsFromLast, considerCallDuration, groupBy)
+}
+
+// GetCDRs Gets the CDRs started at or after "since" from the CDRs Source, with DialedNumbers set to the user part of the R-URI (Dst) if
+// it's a number
+func (proxy *SIPProxy) GetCDRs(since time.Time) (CDRsIterator, error) {
+
+	cdrs, err := proxy.CDRsSource.GetCDRs(since)
+	if err != nil {
+		return nil, err
+	}
+
+	dialedNumbers := func(cdr *CDR) []string {
+
+		number := strings.TrimPrefix(sipURIUser(cdr.Dst), "+")
+
+		// NOTE: Ignore if the R-URI user is not a number (e.g. a registered user's name)
+		if !isDialedNumber(number) {
+			return nil
+		}
+
+		return []string{number}
+
+	}
+
+	return &cdrsIteratorDialedNumbers{CDRsIterator: cdrs, dialedNumbers: dialedNumbers}, nil
+
+}
+
+// GetCurrentActiveCalls ...
+func (proxy *SIPProxy) GetCurrentActiveCalls(minimumNumberLength uint32) (uint32, error) {
+	return 0, fmt.Errorf("SIP proxies have no live calls to count")
+}
+
+// GetCDRsSource ...
+func (proxy *SIPProxy) GetCDRsSource() CDRsSource {
+	return proxy.CDRsSource
+}
+
+// sipURIUser Returns the user part of "value", which can be just the user (e.g. "$rU") or a whole URI (e.g. "$ru", "<sip:1234@host>",
+// "tel:+1234"), without its parameters (e.g. "1234;npdi;rn=5678")
+func sipURIUser(value string) string {
+
+	value = strings.TrimSpace(value)
+	value = strings.TrimPrefix(value, "<")
+
+	for _, scheme := range []string{"sips:", "sip:", "tel:"} {
+		if strings.HasPrefix(strings.ToLower(value), scheme) {
+			value = value[len(scheme):]
+			break
+		}
+	}
+
+	if index := strings.IndexAny(value, "@;>"); index != -1 {
+		value = value[:index]
+	}
+
+	return value
+
+}
@@ -0,0 +1,64 @@
+package softswitches
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSIPURIUser(t *testing.T) {
+
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"00244123456789", "00244123456789"},
+		{"sip:00244123456789@sip.example.com", "00244123456789"},
+		{"<sip:+244123456789@sip.example.com;user=phone>", "+244123456789"},
+		{"SIPS:00244123456789@sip.example.com:5061", "00244123456789"},
+		{"tel:+244123456789;npdi", "+244123456789"},
+		{"00244123456789;rn=00244987654321", "00244123456789"},
+		{"alice@sip.example.com", "alice"},
+	}
+
+	for _, test := range tests {
+		if user := sipURIUser(test.value); user != test.expected {
+			t.Errorf("%s: expected \"%s\", got \"%s\"", test.value, test.expected, user)
+		}
+	}
+
+}
+
+func TestSIPProxyGetCDRs(t *testing.T) {
+
+	now := time.Now()
+
+	cdrs := NewCDRsSourceMemory(time.Hour)
+	cdrs.Add(
+		&CDR{CallDate: now, Dst: "sip:+244123456789@sip.example.com", UniqueID: "a"},
+		&CDR{CallDate: now, Dst: "00244123456789", UniqueID: "b"},
+		// NOTE: Calls to registered users are not dialed numbers
+		&CDR{CallDate: now, Dst: "alice", UniqueID: "c"},
+	)
+
+	proxy := &SIPProxy{CDRsSource: cdrs}
+
+	iterator, err := proxy.GetCDRs(now.Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][]string{"a": {"244123456789"}, "b": {"00244123456789"}, "c": nil}
+
+	for iterator.Next() {
+		cdr := iterator.CDR()
+		if !reflect.DeepEqual(cdr.DialedNumbers, expected[cdr.UniqueID]) {
+			t.Errorf("%s: expected the dialed numbers %v, got %v", cdr.UniqueID, expected[cdr.UniqueID], cdr.DialedNumbers)
+		}
+	}
+
+	if _, err := proxy.GetCurrentActiveCalls(5); err == nil {
+		t.Error("expected an error, SIP proxies have no live calls")
+	}
+
+}
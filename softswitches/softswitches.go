@@ -1,18 +1,12 @@
 package softswitches
 
 import (
-	"bufio"
-	"bytes"
 	"fmt"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	"database/sql"
-	"os/exec"
-
-	"github.com/andmar/fraudion/system"
 
 	"github.com/andmar/marlog"
 )
@@ -22,231 +16,396 @@ const (
 	TypeAsterisk = "*asterisk"
 	// TypeFreeSwitch ...
 	TypeFreeSwitch = "*freeswitch"
+	// TypeKamailio ...
+	TypeKamailio = "*kamailio"
+	// TypeOpenSIPS ...
+	TypeOpenSIPS = "*opensips"
 	// CDRSourceDatabase ...
 	CDRSourceDatabase = "*database"
+	// CDRSourceCSVFile ...
+	CDRSourceCSVFile = "*csv_file"
+	// CDRSourceEventSocket ...
+	CDRSourceEventSocket = "*event_socket"
+	// CDRSourceCEL ...
+	CDRSourceCEL = "*cel"
+	// CDRSourceAccounting ...
+	CDRSourceAccounting = "*accounting"
+	// CDRSourceHTTP ...
+	CDRSourceHTTP = "*http"
+	// CDRSourceStream ...
+	CDRSourceStream = "*stream"
+	// DBMSMySQL ...
+	DBMSMySQL = "*mysql"
+	// DBMSPostgreSQL ...
+	DBMSPostgreSQL = "*postgresql"
+	// DBMSSQLite ...
+	DBMSSQLite = "*sqlite"
 )
 
 const (
-	// NOTE: Currently supported dial string format for Asterisk
-	// TODO: Maybe this should support multiple call dial string somewhow e.g. SIP/test/1234&Dahdi/g0/1234...
-	// see regexr.com for help! > something like ((?:SIP|DAHDI)\/[^@&]+\/[0-9]+)(&\1)? validates that case.
-	asteriskDialString = "(?:SIP|DAHDI)/[^@&]+/[0-9]+"
+	// CDRFieldCallDate ...
+	CDRFieldCallDate = "calldate"
+	// CDRFieldCLID ...
+	CDRFieldCLID = "clid"
+	// CDRFieldSrc ...
+	CDRFieldSrc = "src"
+	// CDRFieldDst ...
+	CDRFieldDst = "dst"
+	// CDRFieldDContext ...
+	CDRFieldDContext = "dcontext"
+	// CDRFieldChannel ...
+	CDRFieldChannel = "channel"
+	// CDRFieldDstChannel ...
+	CDRFieldDstChannel = "dstchannel"
+	// CDRFieldLastApp ...
+	CDRFieldLastApp = "lastapp"
+	// CDRFieldLastData ...
+	CDRFieldLastData = "lastdata"
+	// CDRFieldDuration ...
+	CDRFieldDuration = "duration"
+	// CDRFieldBillSec ...
+	CDRFieldBillSec = "billsec"
+	// CDRFieldDisposition ...
+	CDRFieldDisposition = "disposition"
+	// CDRFieldAMAFlags ...
+	CDRFieldAMAFlags = "amaflags"
+	// CDRFieldAccountCode ...
+	CDRFieldAccountCode = "accountcode"
+	// CDRFieldUniqueID ...
+	CDRFieldUniqueID = "uniqueid"
+	// CDRFieldUserField ...
+	CDRFieldUserField = "userfield"
 )
 
-// Monitored ...
-var Monitored Softswitch
+// NOTE: All CDR fields in the order they are selected from the Database
+var cdrFields = []string{
+	CDRFieldCallDate,
+	CDRFieldCLID,
+	CDRFieldSrc,
+	CDRFieldDst,
+	CDRFieldDContext,
+	CDRFieldChannel,
+	CDRFieldDstChannel,
+	CDRFieldLastApp,
+	CDRFieldLastData,
+	CDRFieldDuration,
+	CDRFieldBillSec,
+	CDRFieldDisposition,
+	CDRFieldAMAFlags,
+	CDRFieldAccountCode,
+	CDRFieldUniqueID,
+	CDRFieldUserField,
+}
+
+// AsteriskCDRColumnMap Column of Asterisk's "cdr" table for each CDR field, any of them can be changed via "column_map" in the CDRs Source
+// configuration
+var AsteriskCDRColumnMap = map[string]string{
+	CDRFieldCallDate:    "calldate",
+	CDRFieldCLID:        "clid",
+	CDRFieldSrc:         "src",
+	CDRFieldDst:         "dst",
+	CDRFieldDContext:    "dcontext",
+	CDRFieldChannel:     "channel",
+	CDRFieldDstChannel:  "dstchannel",
+	CDRFieldLastApp:     "lastapp",
+	CDRFieldLastData:    "lastdata",
+	CDRFieldDuration:    "duration",
+	CDRFieldBillSec:     "billsec",
+	CDRFieldDisposition: "disposition",
+	CDRFieldAMAFlags:    "amaflags",
+	CDRFieldAccountCode: "accountcode",
+	CDRFieldUniqueID:    "uniqueid",
+	CDRFieldUserField:   "userfield",
+}
+
+// Monitored Softswitches by name
+var Monitored = make(map[string]Softswitch)
 
 // Softswitch ...
 type Softswitch interface {
 	GetCDRsSource() CDRsSource
-	GetHits(func(string, ...uint32) (string, bool, error), time.Duration, bool) (map[string]*Hits, error)
+	GetCDRs(time.Time) (CDRsIterator, error)
+	GetHits(func(string, ...uint32) (string, bool, error), time.Duration, bool, GroupBy) (map[string]*Hits, error)
 	GetCurrentActiveCalls(uint32) (uint32, error)
 }
 
 // Asterisk ...
 type Asterisk struct {
-	Version    string
-	CDRsSource CDRsSource
+	Version         string
+	CDRsSource      CDRsSource
+	LiveCallsSource LiveCallsSource
 }
 
-// GetHits Tries to match "lastdata" CDR field's value against "asteriskDialString" but only if the value of "lastapp" is "Dial"
-func (asterisk *Asterisk) GetHits(matches func(string, ...uint32) (string, bool, error), considerCDRsFromLast time.Duration, considerCallDuration bool) (map[string]*Hits, error) {
+// GetHits Tries to match the numbers dialed in "lastdata" CDR field's value (see "parseAsteriskDialString") but only if the value of
+// "lastapp" is "Dial"
+func (asterisk *Asterisk) GetHits(matches func(string, ...uint32) (string, bool, error), considerCDRsFromLast time.Duration, considerCallDuration bool, groupBy GroupBy) (map[string]*Hits, error) {
+	return getHits(asterisk, matches, considerCDRsFromLast, considerCallDuration, groupBy)
+}
 
-	log := marlog.MarLog
+// GetCDRs Gets the CDRs started at or after "since" from the CDRs Source, with DialedNumbers set to the numbers dialed in "lastdata" if
+// "lastapp" is "Dial" (see "parseAsteriskDialString")
+func (asterisk *Asterisk) GetCDRs(since time.Time) (CDRsIterator, error) {
 
-	switch asterisk.CDRsSource.(type) {
-	case *CDRsSourceDatabase:
+	cdrs, err := asterisk.CDRsSource.GetCDRs(since)
+	if err != nil {
+		return nil, err
+	}
 
-		log.LogS("DEBUG", "CDRs Source is Database")
+	dialedNumbers := func(cdr *CDR) []string {
+		return asteriskDialedNumbers(cdr.LastApp, cdr.LastData)
+	}
 
-		cdrsSource, ok := asterisk.CDRsSource.(*CDRsSourceDatabase)
-		if ok == false {
-			return nil, fmt.Errorf("could not convert CDRs Source to the appropriate type")
-		}
+	return &cdrsIteratorDialedNumbers{CDRsIterator: cdrs, dialedNumbers: dialedNumbers}, nil
 
-		if err := cdrsSource.GetConnections().Ping(); err != nil {
-			return nil, err
-		}
+}
 
-		log.LogS("DEBUG", "Database connection is A-Ok!")
-
-		// TODO: The Query format should depend on DBMS?
-		// TODO: This query should consider CDRs only from StartUpTime onwards. This is currenctly the only though
-		// of way of resetting the system state to Normal, if we don't do this, when we restart the system will
-		// still be in alarm and will be until considerCDRsFromLast.Hours passes...
-		var rows *sql.Rows
-		var err error
-		if system.DEBUG {
-			rows, err = cdrsSource.GetConnections().Query(fmt.Sprintf("SELECT * FROM cdr WHERE calldate >= DATE_SUB(CURDATE(), INTERVAL %v HOUR) ORDER BY calldate DESC;", uint32(considerCDRsFromLast.Hours())))
-		} else {
-			sinceStartUp := time.Since(system.State.StartUpTime)
-			rows, err = cdrsSource.GetConnections().Query(fmt.Sprintf("SELECT * FROM cdr WHERE calldate >= DATE_SUB(CURDATE(), INTERVAL %v HOUR) AND calldate >= DATE_SUB(CURDATE(), INTERVAL %v HOUR) ORDER BY calldate DESC;", uint32(considerCDRsFromLast.Hours()), sinceStartUp.Hours()))
-		}
+// GetCurrentActiveCalls Counts the numbers longer than "minimumNumberLength" being dialed by the channels the Live Calls Source lists that
+// are running "Dial", each leg of a forked Dial (e.g. SIP/a/1234&SIP/b/2345) is a call
+func (asterisk *Asterisk) GetCurrentActiveCalls(minimumNumberLength uint32) (uint32, error) {
 
-		if err != nil {
-			log.LogS("ERROR", "could not query the database")
-			return nil, err
-		}
+	log := marlog.MarLog
 
-		result := make(map[string]*Hits)
+	liveCallsSource := asterisk.LiveCallsSource
+	if liveCallsSource == nil {
+		liveCallsSource = &LiveCallsSourceCLI{Version: asterisk.Version}
+	}
 
-		numberOfCDRsTotal := 0
-		numberOfCDRsSuitable := 0
-		numberOfCDRsMatched := 0
+	channels, err := liveCallsSource.GetActiveChannels()
+	if err != nil {
+		return 0, err
+	}
 
-		for rows.Next() {
+	numberOfCalls := 0
+
+	for _, channel := range channels {
 
-			var calldate, clid, src, dst, dcontext, channel, dstchannel, lastapp, lastdata, disposition, accountcode, uniqueid, userfield string
-			var duration, billsec, amaflags uint32
+		for _, dialedNumber := range asteriskDialedNumbers(channel.Application, channel.Data) {
 
-			err := rows.Scan(&calldate, &clid, &src, &dst, &dcontext, &channel, &dstchannel, &lastapp, &lastdata, &duration, &billsec, &disposition, &amaflags, &accountcode, &uniqueid, &userfield)
-			if err != nil {
-				log.LogS("ERROR", "Could not bring query results to variables")
-				return nil, err
+			if uint32(len(dialedNumber)) > minimumNumberLength {
+				numberOfCalls++
+			} else {
+				log.LogS("DEBUG", "Number \""+dialedNumber+"\" is ignored due to length")
 			}
 
-			numberOfCDRsTotal++
+		}
 
-			//fmt.Println(calldate, clid, src, dst, dcontext, channel, dstchannel, lastapp, lastdata, duration, billsec, disposition, amaflags, accountcode, uniqueid, userfield)
+	}
 
-			matchesDialString := regexp.MustCompile(asteriskDialString)
-			matchedString := matchesDialString.FindString(lastdata)
-			// NOTE: Ignore if "lastapp" is not Dial and "lastdata" does not contain an expected dial string
-			if lastapp != "Dial" || matchedString == "" {
-				continue
-			}
+	log.LogS("DEBUG", "Analized "+strconv.Itoa(len(channels))+" channels and found "+strconv.Itoa(numberOfCalls)+" suitable calls")
 
-			numberOfCDRsSuitable++
+	return uint32(numberOfCalls), nil
 
-			// TODO: Above, I mentioned that currently we do not support multi-dial dial strings (e.g. SIP/sfurls/1234&SIP/Sfurls/2345),
-			// actually we do but we just consider the first call, the changes to support that are related with the following line
-			dialedNumber := matchesDialString.FindStringSubmatch(lastdata)[1]
+}
 
-			var prefix string
-			var matched bool
-			if !considerCallDuration {
-				prefix, matched, err = matches(dialedNumber)
-			} else {
-				prefix, matched, err = matches(dialedNumber, billsec)
-			}
-			if err != nil {
-				log.LogS("ERROR", "Number not suitable")
-				return nil, err
-			}
+// SubscribeCallEvents Only Live Calls Sources that follow calls as they happen (e.g. LiveCallsSourceAMIEvents) have CallEvents, with others
+// the returned channel is nil
+func (asterisk *Asterisk) SubscribeCallEvents(types ...int) <-chan CallEvent {
 
-			if matched == true {
+	if source, ok := asterisk.LiveCallsSource.(CallEventsSource); ok {
+		return source.SubscribeCallEvents(types...)
+	}
 
-				numberOfCDRsMatched++
+	return nil
 
-				// NOTE: If the prefix doesn't have matches already, create a new hits object ELSE add to the count for that prefix
-				if _, found := result[prefix]; found != true {
-					result[prefix] = new(Hits)
-					result[prefix].Prefix = prefix
-				}
-				result[prefix].NumberOfHits++
-				result[prefix].Destinations = append(result[prefix].Destinations, dialedNumber)
+}
 
-			}
+// GetCDRsSource ...
+func (asterisk *Asterisk) GetCDRsSource() CDRsSource {
+	return asterisk.CDRsSource
+}
 
-		}
+// CDRsSource ...
+type CDRsSource interface {
+	GetCDRs(since time.Time) (CDRsIterator, error)
+}
 
-		log.LogS("INFO", "Results: Suitable: "+strconv.Itoa(numberOfCDRsSuitable)+", Matched: "+strconv.Itoa(numberOfCDRsMatched)+", Total: "+strconv.Itoa(numberOfCDRsTotal))
+// CDRsSourceDatabase ...
+type CDRsSourceDatabase struct {
+	// NOTE: Name of the Softswitch these are the CDRs of, it keeps the connection settings of each Softswitch apart where the driver
+	// keeps them globally (e.g. MySQL's TLS configurations)
+	SoftswitchName        string
+	DBMS                  string
+	UserName              string
+	UserPassword          string
+	DatabaseName          string
+	TableName             string
+	Host                  string
+	Port                  uint32
+	Socket                string
+	TLS                   DatabaseTLS
+	Timeout               time.Duration
+	MaxOpenConnections    uint32
+	MaxIdleConnections    uint32
+	ConnectionMaxLifetime time.Duration
+	DSNOptions            map[string]string
+	// NOTE: Date/time values without a time zone (e.g. "DATETIME", "timestamp without time zone") are in GMT, like Asterisk writes them
+	// with "usegmtime", instead of local time
+	UseGMTime        bool
+	ColumnMap        map[string]string
+	DefaultColumnMap map[string]string
+	connections      *sql.DB
+}
 
-		return result, nil
+// Connect ...
+func (cdrSource *CDRsSourceDatabase) Connect() error {
 
-	default:
-		return nil, fmt.Errorf("unknown CDRs Source object type)")
+	dialect, err := getDatabaseDialect(cdrSource.DBMS)
+	if err != nil {
+		return err
+	}
+
+	dataSourceName, err := dialect.dataSourceName(cdrSource)
+	if err != nil {
+		return err
 	}
 
+	connections, err := sql.Open(dialect.driverName, dataSourceName)
+	if err != nil {
+		return fmt.Errorf("could not create Database connections (" + err.Error() + ")")
+	}
+
+	// NOTE: Zero means "use the database/sql defaults" for all of these
+	if cdrSource.MaxOpenConnections > 0 {
+		connections.SetMaxOpenConns(int(cdrSource.MaxOpenConnections))
+	}
+	if cdrSource.MaxIdleConnections > 0 {
+		connections.SetMaxIdleConns(int(cdrSource.MaxIdleConnections))
+	}
+	if cdrSource.ConnectionMaxLifetime > 0 {
+		connections.SetConnMaxLifetime(cdrSource.ConnectionMaxLifetime)
+	}
+
+	cdrSource.connections = connections
+
+	return nil
+
 }
 
-// GetCurrentActiveCalls Tries to match "lastdata" CDR field's value against "asteriskDialString" but only if the value of "lastapp" is "Dial"
-func (asterisk *Asterisk) GetCurrentActiveCalls(minimumNumberLength uint32) (uint32, error) {
+// GetCDRs ...
+func (cdrSource *CDRsSourceDatabase) GetCDRs(since time.Time) (CDRsIterator, error) {
 
 	log := marlog.MarLog
 
-	// TODO: Make this depend on the Asterisk version because command format and result parsing may vary!
+	if err := cdrSource.GetConnections().Ping(); err != nil {
+		return nil, err
+	}
 
-	//command := exec.Command("asterisk", "-rx", "core show channels concise") // NOTE: Fraudion has to have the permission to do this...
-	command := exec.Command("cat", "asterisk.output") // NOTE: This is a just a test code to test Asterisk output without a local Asterisk via the non-commited text file: asterisk.output where one can put example output
+	log.LogS("DEBUG", "Database connection is A-Ok!")
 
-	output, err := command.Output()
+	rows, err := cdrSource.SelectCDRs(cdrFields, since)
 	if err != nil {
-		log.LogS("ERROR: ", err.Error())
-		return 0, err
+		log.LogS("ERROR", "could not query the database")
+		return nil, err
 	}
 
-	numberOfCalls := 0
-	numberOfLines := 0
+	return &cdrsIteratorDatabase{rows: rows, location: cdrSource.getLocation()}, nil
 
-	scanner := bufio.NewScanner(bytes.NewReader(output))
-	for scanner.Scan() {
+}
 
-		numberOfLines++
+// SinceCondition Returns a WHERE condition, in the configured DBMS's dialect, that selects the rows with "column" values at or after "since"
+// and the value of its one parameter
+func (cdrSource *CDRsSourceDatabase) SinceCondition(column string, since time.Time) (string, interface{}, error) {
 
-		lineItems := strings.Split(scanner.Text(), "!")
+	dialect, err := getDatabaseDialect(cdrSource.DBMS)
+	if err != nil {
+		return "", nil, err
+	}
 
-		if len(lineItems) == 14 {
+	return column + " >= " + dialect.placeholder, dialect.timeValue(since.In(cdrSource.getLocation())), nil
 
-			matchesDialString := regexp.MustCompile(asteriskDialString)
-			matchedString := matchesDialString.FindString(lineItems[6])
+}
 
-			// NOTE: Ignore if "lastapp" is not Dial and "lastdata" does not contain an expected dial string
-			if lineItems[5] == "Dial" || matchedString != "" {
+// SelectCDRs Queries the configured table for the given CDR "fields" of the CDRs started at or after "since", each field is read from
+// the column "column_map" says or, if not there, from the one in DefaultColumnMap. Fields mapped to an empty column name don't exist in the
+// table and come as NULL so every field has to be scanned into something that accepts NULLs
+func (cdrSource *CDRsSourceDatabase) SelectCDRs(fields []string, since time.Time) (*sql.Rows, error) {
 
-				dialedNumber := matchesDialString.FindStringSubmatch(lineItems[6])[1]
+	query, sinceValue, err := cdrSource.selectCDRsQuery(fields, since)
+	if err != nil {
+		return nil, err
+	}
 
-				if uint32(len(dialedNumber)) > minimumNumberLength {
-					numberOfCalls++
-				} else {
-					log.LogS("DEBUG", "Number \""+dialedNumber+"\" is ignored due to length")
-				}
+	return cdrSource.GetConnections().Query(query, sinceValue)
 
-			}
+}
 
-		} else {
-			log.LogS("ERROR", "Line has weird item count: "+strconv.Itoa(len(lineItems)))
-		}
+// selectCDRsQuery Returns the query SelectCDRs runs and the value of its one parameter
+func (cdrSource *CDRsSourceDatabase) selectCDRsQuery(fields []string, since time.Time) (string, interface{}, error) {
+
+	callDateColumn := cdrSource.getColumn(CDRFieldCallDate)
+	if callDateColumn == "" {
+		return "", nil, fmt.Errorf("no column configured for CDR field \"%s\"", CDRFieldCallDate)
+	}
 
+	columns := make([]string, len(fields))
+	for index, field := range fields {
+		columns[index] = cdrSource.getColumn(field)
+		if columns[index] == "" {
+			columns[index] = "NULL"
+		}
 	}
 
-	log.LogS("DEBUG", "Analized "+strconv.Itoa(numberOfLines)+" lines and found "+strconv.Itoa(numberOfCalls)+" suitable calls")
+	sinceCondition, sinceValue, err := cdrSource.SinceCondition(callDateColumn, since)
+	if err != nil {
+		return "", nil, err
+	}
 
-	return uint32(numberOfCalls), nil
+	return fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY %s DESC;", strings.Join(columns, ", "), cdrSource.getTableName(), sinceCondition, callDateColumn), sinceValue, nil
 
 }
 
-// GetCDRsSource ...
-func (asterisk *Asterisk) GetCDRsSource() CDRsSource {
-	return asterisk.CDRsSource
+func (cdrSource *CDRsSourceDatabase) getColumn(field string) string {
+
+	if column, found := cdrSource.ColumnMap[field]; found {
+		return column
+	}
+
+	if cdrSource.DefaultColumnMap == nil {
+		return AsteriskCDRColumnMap[field]
+	}
+
+	return cdrSource.DefaultColumnMap[field]
+
 }
 
-// CDRsSource ...
-type CDRsSource interface {
-	//GetCDRs()
+func (cdrSource *CDRsSourceDatabase) getTableName() string {
+
+	if cdrSource.TableName == "" {
+		return "cdr"
+	}
+
+	return cdrSource.TableName
+
 }
 
-// CDRsSourceDatabase ...
-type CDRsSourceDatabase struct {
-	DBMS         string
-	UserName     string
-	UserPassword string
-	DatabaseName string
-	TableName    string
-	connections  *sql.DB
+// getLocation Returns the time zone of the date/time values without one
+func (cdrSource *CDRsSourceDatabase) getLocation() *time.Location {
+
+	if cdrSource.UseGMTime {
+		return time.UTC
+	}
+
+	return time.Local
+
 }
 
-// Connect ...
-func (cdrSource *CDRsSourceDatabase) Connect() error {
+func (cdrSource *CDRsSourceDatabase) getHost() string {
 
-	connections, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(localhost:3306)/%s?allowOldPasswords=1", cdrSource.UserName, cdrSource.UserPassword, cdrSource.DatabaseName))
-	if err != nil {
-		return fmt.Errorf("could not create Database connections (" + err.Error() + ")")
+	if cdrSource.Host == "" {
+		return "localhost"
 	}
 
-	cdrSource.connections = connections
+	return cdrSource.Host
 
-	return nil
+}
+
+func (cdrSource *CDRsSourceDatabase) getPort(defaultPort uint32) uint32 {
+
+	if cdrSource.Port == 0 {
+		return defaultPort
+	}
+
+	return cdrSource.Port
 
 }
 
@@ -257,9 +416,155 @@ func (cdrSource CDRsSourceDatabase) GetConnections() *sql.DB {
 
 }
 
+// cdrsIteratorDatabase ...
+type cdrsIteratorDatabase struct {
+	rows     *sql.Rows
+	location *time.Location
+	cdr      *CDR
+}
+
+// Next ...
+func (iterator *cdrsIteratorDatabase) Next() bool {
+
+	log := marlog.MarLog
+
+	for iterator.rows.Next() {
+
+		var callDate interface{}
+		values := make([]sql.NullString, len(cdrFields)-1)
+
+		destinations := []interface{}{&callDate}
+		for index := range values {
+			destinations = append(destinations, &values[index])
+		}
+
+		if err := iterator.rows.Scan(destinations...); err != nil {
+			log.LogS("ERROR", "Could not bring query results to variables ("+err.Error()+")")
+			continue
+		}
+
+		cdr, err := newCDRFromDatabaseValues(callDate, values, iterator.location)
+		if err != nil {
+			log.LogS("ERROR", "Could not convert query results to a CDR ("+err.Error()+")")
+			continue
+		}
+
+		iterator.cdr = cdr
+
+		return true
+
+	}
+
+	return false
+
+}
+
+// CDR ...
+func (iterator *cdrsIteratorDatabase) CDR() *CDR {
+	return iterator.cdr
+}
+
+// Err ...
+func (iterator *cdrsIteratorDatabase) Err() error {
+	return iterator.rows.Err()
+}
+
+// Close ...
+func (iterator *cdrsIteratorDatabase) Close() error {
+	return iterator.rows.Close()
+}
+
+// newCDRFromDatabaseValues Builds a CDR from the "calldate" and the rest of the values, which are in the order of "cdrFields"
+func newCDRFromDatabaseValues(callDate interface{}, values []sql.NullString, location *time.Location) (*CDR, error) {
+
+	parsedCallDate, err := parseDatabaseTime(callDate, location)
+	if err != nil {
+		return nil, err
+	}
+
+	duration, err := parseCDRSeconds(values[8].String)
+	if err != nil {
+		return nil, err
+	}
+
+	billSec, err := parseCDRSeconds(values[9].String)
+	if err != nil {
+		return nil, err
+	}
+
+	cdr := new(CDR)
+	cdr.CallDate = parsedCallDate
+	cdr.CLID = values[0].String
+	cdr.Src = values[1].String
+	cdr.Dst = values[2].String
+	cdr.DContext = values[3].String
+	cdr.Channel = values[4].String
+	cdr.DstChannel = values[5].String
+	cdr.LastApp = values[6].String
+	cdr.LastData = values[7].String
+	cdr.Duration = duration
+	cdr.BillSec = billSec
+	cdr.Disposition = values[10].String
+	cdr.AMAFlags = values[11].String
+	cdr.AccountCode = values[12].String
+	cdr.UniqueID = values[13].String
+	cdr.UserField = values[14].String
+
+	return cdr, nil
+
+}
+
+// parseDatabaseTime Converts a date/time column's value, which depending on the driver and the column's type comes as a time.Time or as
+// text, to a time.Time. Values without a time zone are in "location" (see CDRsSourceDatabase.UseGMTime)
+func parseDatabaseTime(value interface{}, location *time.Location) (time.Time, error) {
+
+	switch timeValue := value.(type) {
+	case time.Time:
+		// NOTE: Drivers return "timestamp without time zone"/"DATETIME" columns as times at offset 0 (UTC or, lib/pq, a zone with no name)
+		// with the wall clock values that are there, they have to be moved to "location" without changing the wall clock. Times the driver
+		// returns in any other zone (e.g. "timestamptz" columns in the session's time zone, MySQL's with "loc=Local") are right as they are.
+		// A "timestamptz" read in a UTC session looks just like one without a time zone, that's what UseGMTime is for
+		if _, offset := timeValue.Zone(); offset == 0 && timeValue.Location() != time.Local {
+			timeValue = time.Date(timeValue.Year(), timeValue.Month(), timeValue.Day(), timeValue.Hour(), timeValue.Minute(), timeValue.Second(), timeValue.Nanosecond(), location)
+		}
+		return timeValue, nil
+	case []byte:
+		return parseCDRTimeInLocation(string(timeValue), location)
+	case string:
+		return parseCDRTimeInLocation(timeValue, location)
+	}
+
+	return time.Time{}, fmt.Errorf("unexpected date/time value %v", value)
+
+}
+
 // Hits ...
 type Hits struct {
+	Softswitch string
+	// NOTE: The key of the group (see GroupBy) and the value of each of its fields, Prefix is only set if it's one of them
+	Group        string
+	GroupValues  map[string]string
 	Prefix       string
 	NumberOfHits uint32
 	Destinations []string
+	// NOTE: Destinations as they were dialed, before they were normalized (see NumberNormalizer)
+	RawDestinations []string
+	// NOTE: How long each of the calls to Destinations was billed for, in seconds
+	BillSecs []uint32
+}
+
+// DescribeDestinations Returns the Destinations, the ones that were normalized followed by how they were dialed (e.g. "244123456789
+// (dialed 00244123456789)")
+func (hits *Hits) DescribeDestinations() []string {
+
+	var result []string
+	for index, destination := range hits.Destinations {
+		if index < len(hits.RawDestinations) && hits.RawDestinations[index] != destination {
+			destination = destination + " (dialed " + hits.RawDestinations[index] + ")"
+		}
+		result = append(result, destination)
+	}
+
+	return result
+
 }
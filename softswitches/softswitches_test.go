@@ -0,0 +1,114 @@
+package softswitches
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSelectCDRsQuery(t *testing.T) {
+
+	fields := []string{CDRFieldCallDate, CDRFieldSrc, CDRFieldDst, CDRFieldLastData, CDRFieldBillSec, CDRFieldUniqueID}
+	since := time.Date(2016, 7, 29, 10, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		cdrSource CDRsSourceDatabase
+		expected  string
+	}{
+		{
+			CDRsSourceDatabase{DBMS: DBMSMySQL, DefaultColumnMap: AsteriskCDRColumnMap},
+			"SELECT calldate, src, dst, lastdata, billsec, uniqueid FROM cdr WHERE calldate >= ? ORDER BY calldate DESC;",
+		},
+		// NOTE: Fields the table doesn't have are selected as NULL
+		{
+			CDRsSourceDatabase{DBMS: DBMSPostgreSQL, TableName: "cdr", DefaultColumnMap: FreeSwitchCDRColumnMap},
+			"SELECT start_stamp, caller_id_number, destination_number, NULL, billsec, uuid FROM cdr WHERE start_stamp >= $1 ORDER BY start_stamp DESC;",
+		},
+		// NOTE: The column map goes before the defaults, a field can be mapped to nothing or to a field that's not in the defaults
+		{
+			CDRsSourceDatabase{DBMS: DBMSMySQL, TableName: "asteriskcdrdb.cdr", DefaultColumnMap: AsteriskCDRColumnMap, ColumnMap: map[string]string{CDRFieldCallDate: "start", CDRFieldLastData: "", CDRFieldUniqueID: "linkedid"}},
+			"SELECT start, src, dst, NULL, billsec, linkedid FROM asteriskcdrdb.cdr WHERE start >= ? ORDER BY start DESC;",
+		},
+		// NOTE: Without defaults the columns are named as Asterisk's
+		{
+			CDRsSourceDatabase{DBMS: DBMSSQLite, TableName: "master", ColumnMap: map[string]string{CDRFieldDst: "destination"}},
+			"SELECT calldate, src, destination, lastdata, billsec, uniqueid FROM master WHERE calldate >= ? ORDER BY calldate DESC;",
+		},
+	}
+
+	for _, test := range tests {
+
+		query, _, err := test.cdrSource.selectCDRsQuery(fields, since)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if query != test.expected {
+			t.Errorf("expected \"%s\", got \"%s\"", test.expected, query)
+		}
+
+	}
+
+	// NOTE: Without "calldate" there's no way of selecting the CDRs started since some time
+	noCallDate := CDRsSourceDatabase{DBMS: DBMSMySQL, DefaultColumnMap: AsteriskCDRColumnMap, ColumnMap: map[string]string{CDRFieldCallDate: ""}}
+	if _, _, err := noCallDate.selectCDRsQuery(fields, since); err == nil {
+		t.Error("expected an error without a \"calldate\" column")
+	}
+
+}
+
+func TestParseDatabaseTime(t *testing.T) {
+
+	// NOTE: Not time.Local, so that it's not UTC whatever the time zone the tests run in
+	local := time.FixedZone("WEST", 3600)
+	// NOTE: What lib/pq returns for "timestamptz" columns in a session whose time zone is not UTC
+	session := time.FixedZone("", 7200)
+
+	tests := []struct {
+		value    interface{}
+		location *time.Location
+		expected time.Time
+	}{
+		// NOTE: Columns without a time zone come at offset 0 with the wall clock that's stored, which is in the given location
+		{time.Date(2016, 7, 29, 10, 0, 0, 0, time.UTC), local, time.Date(2016, 7, 29, 10, 0, 0, 0, local)},
+		{time.Date(2016, 7, 29, 10, 0, 0, 0, time.FixedZone("", 0)), local, time.Date(2016, 7, 29, 10, 0, 0, 0, local)},
+		// NOTE: With "usegmtime" they're left as they are
+		{time.Date(2016, 7, 29, 10, 0, 0, 0, time.UTC), time.UTC, time.Date(2016, 7, 29, 10, 0, 0, 0, time.UTC)},
+		// NOTE: Times with a time zone are the same instant whatever the location
+		{time.Date(2016, 7, 29, 12, 0, 0, 0, session), local, time.Date(2016, 7, 29, 10, 0, 0, 0, time.UTC)},
+		{time.Date(2016, 7, 29, 12, 0, 0, 0, session), time.UTC, time.Date(2016, 7, 29, 10, 0, 0, 0, time.UTC)},
+		{time.Date(2016, 7, 29, 10, 0, 0, 0, time.Local), time.UTC, time.Date(2016, 7, 29, 10, 0, 0, 0, time.Local)},
+		// NOTE: Text never has a time zone
+		{[]byte("2016-07-29 10:00:00"), local, time.Date(2016, 7, 29, 10, 0, 0, 0, local)},
+		{"2016-07-29 10:00:00", time.UTC, time.Date(2016, 7, 29, 10, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+
+		parsed, err := parseDatabaseTime(test.value, test.location)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !parsed.Equal(test.expected) {
+			t.Errorf("%v in %s: expected %s, got %s", test.value, test.location, test.expected, parsed)
+		}
+
+	}
+
+	if _, err := parseDatabaseTime(int64(1469786400), time.UTC); err == nil {
+		t.Error("expected an error for a value that's not a date/time")
+	}
+
+}
+
+func TestGetLocation(t *testing.T) {
+
+	if location := (&CDRsSourceDatabase{}).getLocation(); location != time.Local {
+		t.Errorf("expected local time by default, got %s", location)
+	}
+
+	if location := (&CDRsSourceDatabase{UseGMTime: true}).getLocation(); location != time.UTC {
+		t.Errorf("expected UTC with \"usegmtime\", got %s", location)
+	}
+
+}
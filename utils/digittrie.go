@@ -0,0 +1,67 @@
+package utils
+
+// digitTrie A trie of numbers, one node per digit, where values are kept at the end of their prefixes
+type digitTrie struct {
+	children [10]*digitTrie
+	value    interface{}
+}
+
+// insert Sets the value of "prefix", returns false if "prefix" is not all digits
+func (trie *digitTrie) insert(prefix string, value interface{}) bool {
+
+	node := trie
+	for _, digit := range prefix {
+		if digit < '0' || digit > '9' {
+			return false
+		}
+		if node.children[digit-'0'] == nil {
+			node.children[digit-'0'] = new(digitTrie)
+		}
+		node = node.children[digit-'0']
+	}
+
+	node.value = value
+
+	return true
+
+}
+
+// longestMatch Returns the value of the longest prefix "number" starts with, nil if none, it stops at the first character that is not a digit
+func (trie *digitTrie) longestMatch(number string) interface{} {
+
+	var found interface{}
+
+	node := trie
+	for _, digit := range number {
+		if digit < '0' || digit > '9' {
+			break
+		}
+		node = node.children[digit-'0']
+		if node == nil {
+			break
+		}
+		if node.value != nil {
+			found = node.value
+		}
+	}
+
+	return found
+
+}
+
+// stripInternationalPrefix Returns "number" without a leading "+" or "00", for lookups of numbers that may not have been normalized (see
+// softswitches.NumberingPlan)
+func stripInternationalPrefix(number string) string {
+
+	if len(number) > 0 && number[0] == '+' {
+		return number[1:]
+	}
+
+	if len(number) > 1 && number[0] == '0' && number[1] == '0' {
+		// NOTE: No country code starts with "0", so this can't be a number without the international prefix
+		return number[2:]
+	}
+
+	return number
+
+}
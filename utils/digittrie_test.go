@@ -0,0 +1,70 @@
+package utils
+
+import "testing"
+
+func TestDigitTrieLongestMatch(t *testing.T) {
+
+	trie := new(digitTrie)
+	for _, prefix := range []string{"1", "1268", "7", "77", "441481"} {
+		if !trie.insert(prefix, prefix) {
+			t.Fatalf("expected \"%s\" to be inserted", prefix)
+		}
+	}
+
+	if trie.insert("12a", "12a") {
+		t.Fatal("expected a prefix that is not all digits not to be inserted")
+	}
+
+	tests := []struct {
+		number   string
+		expected interface{}
+	}{
+		{"14155550100", "1"},
+		{"12685551234", "1268"},
+		// NOTE: Down a longer prefix's path but off it before its end
+		{"1261", "1"},
+		{"79161234567", "7"},
+		{"77011234567", "77"},
+		{"441481123456", "441481"},
+		{"4414", nil},
+		{"1268", "1268"},
+		// NOTE: Matching stops at the first character that is not a digit
+		{"1268#", "1268"},
+		{"12#68", "1"},
+		{"244923000000", nil},
+		{"", nil},
+	}
+
+	for _, test := range tests {
+		if found := trie.longestMatch(test.number); found != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.number, test.expected, found)
+		}
+	}
+
+	// NOTE: Inserting a prefix again replaces its value
+	trie.insert("77", "Kazakhstan")
+	if found := trie.longestMatch("77011234567"); found != "Kazakhstan" {
+		t.Errorf("expected the value of \"77\" to be replaced, got %v", found)
+	}
+
+}
+
+func TestStripInternationalPrefix(t *testing.T) {
+
+	tests := map[string]string{
+		"+244923000000":   "244923000000",
+		"00244923000000":  "244923000000",
+		"244923000000":    "244923000000",
+		"0244923000000":   "0244923000000",
+		"0":               "0",
+		"":                "",
+		"+00244923000000": "00244923000000",
+	}
+
+	for number, expected := range tests {
+		if stripped := stripInternationalPrefix(number); stripped != expected {
+			t.Errorf("%s: expected \"%s\", got \"%s\"", number, expected, stripped)
+		}
+	}
+
+}
@@ -0,0 +1,72 @@
+package utils
+
+//go:generate go run gen_e164.go
+
+// E164Range A range of E.164 numbers assigned to a country or to a non geographic service (e.g. satellite networks, which have no
+// ISO), it's the whole CountryCode or a more specific range inside it (e.g. "1268" Antigua and Barbuda inside "1", "77" Kazakhstan
+// inside "7" Russia), Prefix is what numbers in it start with
+type E164Range struct {
+	Prefix      string
+	CountryCode string
+	ISO         string
+	Country     string
+	Region      string
+}
+
+// E164 The ITU-T E.164 assignments (see e164.csv)
+var E164 = NewE164Trie(e164Ranges)
+
+// E164Trie Finds the E164Range of a number in as many steps as it has digits, with one node per digit of the ranges' prefixes
+type E164Trie struct {
+	root digitTrie
+}
+
+// NewE164Trie ...
+func NewE164Trie(ranges []E164Range) *E164Trie {
+
+	trie := new(E164Trie)
+	for index := range ranges {
+		trie.Insert(&ranges[index])
+	}
+
+	return trie
+
+}
+
+// Insert Adds "e164Range" to the trie, it replaces the one with the same Prefix if there's one
+func (trie *E164Trie) Insert(e164Range *E164Range) {
+	trie.root.insert(e164Range.Prefix, e164Range)
+}
+
+// Lookup Returns the most specific E164Range "number" is in (the one with the longest Prefix it starts with), "number" has to be an
+// E.164 number without the "+"
+func (trie *E164Trie) Lookup(number string) (*E164Range, bool) {
+
+	found, ok := trie.root.longestMatch(number).(*E164Range)
+
+	return found, ok
+
+}
+
+// FindE164Range Returns the E164Range of "number", which can also start with the "00" international prefix or a "+" (e.g. numbers that
+// were not normalized, see softswitches.NumberingPlan)
+func FindE164Range(number string) (*E164Range, bool) {
+	return E164.Lookup(stripInternationalPrefix(number))
+}
+
+// DescribeE164Prefix Returns "prefix" followed by the country and region of its E164Range (e.g. "244 (Angola, Africa)"), for alerts and
+// reports, or just "prefix" if it's not in one
+func DescribeE164Prefix(prefix string) string {
+
+	e164Range, found := FindE164Range(prefix)
+	if !found {
+		return prefix
+	}
+
+	if e164Range.ISO == "" {
+		return prefix + " (" + e164Range.Country + ")"
+	}
+
+	return prefix + " (" + e164Range.Country + " " + e164Range.ISO + ", " + e164Range.Region + ")"
+
+}
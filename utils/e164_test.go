@@ -0,0 +1,88 @@
+package utils
+
+import "testing"
+
+func TestFindE164Range(t *testing.T) {
+
+	tests := []struct {
+		number      string
+		expectedISO string
+		expected    string
+	}{
+		{"14155550100", "US", "1"},
+		{"14165550100", "CA", "1416"},
+		{"12685551234", "AG", "1268"},
+		{"79161234567", "RU", "7"},
+		{"77011234567", "KZ", "77"},
+		{"441481123456", "GG", "441481"},
+		{"442071234567", "GB", "44"},
+		{"39066981234", "VA", "3906698"},
+		{"39066123456", "VA", "39066"},
+		{"390612345678", "IT", "39"},
+		{"59997361234", "CW", "5999"},
+		{"59971234567", "BQ", "5997"},
+		{"6723221234", "NF", "6723"},
+		{"67210123", "AQ", "6721"},
+		{"88160000000", "", "8816"},
+		// NOTE: Not normalized
+		{"0077011234567", "KZ", "77"},
+		{"+244923000000", "AO", "244"},
+	}
+
+	for _, test := range tests {
+
+		e164Range, found := FindE164Range(test.number)
+		if !found {
+			t.Errorf("%s: expected it to be in \"%s\"", test.number, test.expected)
+			continue
+		}
+
+		if e164Range.Prefix != test.expected || e164Range.ISO != test.expectedISO {
+			t.Errorf("%s: expected \"%s\" (%s), got \"%s\" (%s)", test.number, test.expected, test.expectedISO, e164Range.Prefix, e164Range.ISO)
+		}
+
+	}
+
+	for _, number := range []string{"", "0244923000000", "99912345"} {
+		if e164Range, found := FindE164Range(number); found {
+			t.Errorf("%s: expected it not to be in a range, got \"%s\"", number, e164Range.Prefix)
+		}
+	}
+
+}
+
+func TestE164Ranges(t *testing.T) {
+
+	// NOTE: Every range is in its country code and is what its prefix finds
+	for index := range e164Ranges {
+
+		e164Range := &e164Ranges[index]
+
+		if len(e164Range.Prefix) < len(e164Range.CountryCode) || e164Range.Prefix[:len(e164Range.CountryCode)] != e164Range.CountryCode {
+			t.Errorf("%s: expected it to start with its country code %s", e164Range.Prefix, e164Range.CountryCode)
+		}
+
+		if found, _ := E164.Lookup(e164Range.Prefix); found != e164Range {
+			t.Errorf("%s: expected the prefix to find its own range, got %+v", e164Range.Prefix, found)
+		}
+
+	}
+
+}
+
+func TestDescribeE164Prefix(t *testing.T) {
+
+	tests := map[string]string{
+		"244":  "244 (Angola AO, Africa)",
+		"77":   "77 (Kazakhstan KZ, Asia)",
+		"8816": "8816 (Iridium)",
+		"999":  "999",
+	}
+
+	for prefix, expected := range tests {
+		if description := DescribeE164Prefix(prefix); description != expected {
+			t.Errorf("%s: expected \"%s\", got \"%s\"", prefix, expected, description)
+		}
+	}
+
+}
@@ -0,0 +1,328 @@
+// Code generated by gen_e164.go from e164.csv; DO NOT EDIT.
+
+package utils
+
+var e164Ranges = []E164Range{
+	{Prefix: "1", CountryCode: "1", ISO: "US", Country: "United States", Region: "North America"},
+	{Prefix: "1204", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1226", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1236", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1249", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1250", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1263", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1289", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1306", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1343", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1354", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1365", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1367", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1368", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1382", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1403", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1416", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1418", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1428", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1431", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1437", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1438", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1450", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1468", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1474", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1506", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1514", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1519", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1548", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1579", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1581", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1584", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1587", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1604", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1613", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1639", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1647", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1672", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1683", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1705", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1709", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1742", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1753", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1778", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1780", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1782", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1807", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1819", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1825", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1867", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1873", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1879", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1902", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1905", CountryCode: "1", ISO: "CA", Country: "Canada", Region: "North America"},
+	{Prefix: "1242", CountryCode: "1", ISO: "BS", Country: "Bahamas", Region: "Caribbean"},
+	{Prefix: "1246", CountryCode: "1", ISO: "BB", Country: "Barbados", Region: "Caribbean"},
+	{Prefix: "1264", CountryCode: "1", ISO: "AI", Country: "Anguilla", Region: "Caribbean"},
+	{Prefix: "1268", CountryCode: "1", ISO: "AG", Country: "Antigua and Barbuda", Region: "Caribbean"},
+	{Prefix: "1284", CountryCode: "1", ISO: "VG", Country: "British Virgin Islands", Region: "Caribbean"},
+	{Prefix: "1340", CountryCode: "1", ISO: "VI", Country: "United States Virgin Islands", Region: "Caribbean"},
+	{Prefix: "1345", CountryCode: "1", ISO: "KY", Country: "Cayman Islands", Region: "Caribbean"},
+	{Prefix: "1441", CountryCode: "1", ISO: "BM", Country: "Bermuda", Region: "North America"},
+	{Prefix: "1473", CountryCode: "1", ISO: "GD", Country: "Grenada", Region: "Caribbean"},
+	{Prefix: "1649", CountryCode: "1", ISO: "TC", Country: "Turks and Caicos Islands", Region: "Caribbean"},
+	{Prefix: "1658", CountryCode: "1", ISO: "JM", Country: "Jamaica", Region: "Caribbean"},
+	{Prefix: "1664", CountryCode: "1", ISO: "MS", Country: "Montserrat", Region: "Caribbean"},
+	{Prefix: "1670", CountryCode: "1", ISO: "MP", Country: "Northern Mariana Islands", Region: "Oceania"},
+	{Prefix: "1671", CountryCode: "1", ISO: "GU", Country: "Guam", Region: "Oceania"},
+	{Prefix: "1684", CountryCode: "1", ISO: "AS", Country: "American Samoa", Region: "Oceania"},
+	{Prefix: "1721", CountryCode: "1", ISO: "SX", Country: "Sint Maarten", Region: "Caribbean"},
+	{Prefix: "1758", CountryCode: "1", ISO: "LC", Country: "Saint Lucia", Region: "Caribbean"},
+	{Prefix: "1767", CountryCode: "1", ISO: "DM", Country: "Dominica", Region: "Caribbean"},
+	{Prefix: "1784", CountryCode: "1", ISO: "VC", Country: "Saint Vincent and the Grenadines", Region: "Caribbean"},
+	{Prefix: "1787", CountryCode: "1", ISO: "PR", Country: "Puerto Rico", Region: "Caribbean"},
+	{Prefix: "1809", CountryCode: "1", ISO: "DO", Country: "Dominican Republic", Region: "Caribbean"},
+	{Prefix: "1829", CountryCode: "1", ISO: "DO", Country: "Dominican Republic", Region: "Caribbean"},
+	{Prefix: "1849", CountryCode: "1", ISO: "DO", Country: "Dominican Republic", Region: "Caribbean"},
+	{Prefix: "1868", CountryCode: "1", ISO: "TT", Country: "Trinidad and Tobago", Region: "Caribbean"},
+	{Prefix: "1869", CountryCode: "1", ISO: "KN", Country: "Saint Kitts and Nevis", Region: "Caribbean"},
+	{Prefix: "1876", CountryCode: "1", ISO: "JM", Country: "Jamaica", Region: "Caribbean"},
+	{Prefix: "1939", CountryCode: "1", ISO: "PR", Country: "Puerto Rico", Region: "Caribbean"},
+	{Prefix: "20", CountryCode: "20", ISO: "EG", Country: "Egypt", Region: "Africa"},
+	{Prefix: "211", CountryCode: "211", ISO: "SS", Country: "South Sudan", Region: "Africa"},
+	{Prefix: "212", CountryCode: "212", ISO: "MA", Country: "Morocco", Region: "Africa"},
+	{Prefix: "2125288", CountryCode: "212", ISO: "EH", Country: "Western Sahara", Region: "Africa"},
+	{Prefix: "2125289", CountryCode: "212", ISO: "EH", Country: "Western Sahara", Region: "Africa"},
+	{Prefix: "213", CountryCode: "213", ISO: "DZ", Country: "Algeria", Region: "Africa"},
+	{Prefix: "216", CountryCode: "216", ISO: "TN", Country: "Tunisia", Region: "Africa"},
+	{Prefix: "218", CountryCode: "218", ISO: "LY", Country: "Libya", Region: "Africa"},
+	{Prefix: "220", CountryCode: "220", ISO: "GM", Country: "Gambia", Region: "Africa"},
+	{Prefix: "221", CountryCode: "221", ISO: "SN", Country: "Senegal", Region: "Africa"},
+	{Prefix: "222", CountryCode: "222", ISO: "MR", Country: "Mauritania", Region: "Africa"},
+	{Prefix: "223", CountryCode: "223", ISO: "ML", Country: "Mali", Region: "Africa"},
+	{Prefix: "224", CountryCode: "224", ISO: "GN", Country: "Guinea", Region: "Africa"},
+	{Prefix: "225", CountryCode: "225", ISO: "CI", Country: "Ivory Coast", Region: "Africa"},
+	{Prefix: "226", CountryCode: "226", ISO: "BF", Country: "Burkina Faso", Region: "Africa"},
+	{Prefix: "227", CountryCode: "227", ISO: "NE", Country: "Niger", Region: "Africa"},
+	{Prefix: "228", CountryCode: "228", ISO: "TG", Country: "Togo", Region: "Africa"},
+	{Prefix: "229", CountryCode: "229", ISO: "BJ", Country: "Benin", Region: "Africa"},
+	{Prefix: "230", CountryCode: "230", ISO: "MU", Country: "Mauritius", Region: "Africa"},
+	{Prefix: "231", CountryCode: "231", ISO: "LR", Country: "Liberia", Region: "Africa"},
+	{Prefix: "232", CountryCode: "232", ISO: "SL", Country: "Sierra Leone", Region: "Africa"},
+	{Prefix: "233", CountryCode: "233", ISO: "GH", Country: "Ghana", Region: "Africa"},
+	{Prefix: "234", CountryCode: "234", ISO: "NG", Country: "Nigeria", Region: "Africa"},
+	{Prefix: "235", CountryCode: "235", ISO: "TD", Country: "Chad", Region: "Africa"},
+	{Prefix: "236", CountryCode: "236", ISO: "CF", Country: "Central African Republic", Region: "Africa"},
+	{Prefix: "237", CountryCode: "237", ISO: "CM", Country: "Cameroon", Region: "Africa"},
+	{Prefix: "238", CountryCode: "238", ISO: "CV", Country: "Cape Verde", Region: "Africa"},
+	{Prefix: "239", CountryCode: "239", ISO: "ST", Country: "Sao Tome and Principe", Region: "Africa"},
+	{Prefix: "240", CountryCode: "240", ISO: "GQ", Country: "Equatorial Guinea", Region: "Africa"},
+	{Prefix: "241", CountryCode: "241", ISO: "GA", Country: "Gabon", Region: "Africa"},
+	{Prefix: "242", CountryCode: "242", ISO: "CG", Country: "Republic of the Congo", Region: "Africa"},
+	{Prefix: "243", CountryCode: "243", ISO: "CD", Country: "Democratic Republic of the Congo", Region: "Africa"},
+	{Prefix: "244", CountryCode: "244", ISO: "AO", Country: "Angola", Region: "Africa"},
+	{Prefix: "245", CountryCode: "245", ISO: "GW", Country: "Guinea-Bissau", Region: "Africa"},
+	{Prefix: "246", CountryCode: "246", ISO: "IO", Country: "British Indian Ocean Territory", Region: "Asia"},
+	{Prefix: "247", CountryCode: "247", ISO: "SH", Country: "Ascension Island", Region: "Africa"},
+	{Prefix: "248", CountryCode: "248", ISO: "SC", Country: "Seychelles", Region: "Africa"},
+	{Prefix: "249", CountryCode: "249", ISO: "SD", Country: "Sudan", Region: "Africa"},
+	{Prefix: "250", CountryCode: "250", ISO: "RW", Country: "Rwanda", Region: "Africa"},
+	{Prefix: "251", CountryCode: "251", ISO: "ET", Country: "Ethiopia", Region: "Africa"},
+	{Prefix: "252", CountryCode: "252", ISO: "SO", Country: "Somalia", Region: "Africa"},
+	{Prefix: "253", CountryCode: "253", ISO: "DJ", Country: "Djibouti", Region: "Africa"},
+	{Prefix: "254", CountryCode: "254", ISO: "KE", Country: "Kenya", Region: "Africa"},
+	{Prefix: "255", CountryCode: "255", ISO: "TZ", Country: "Tanzania", Region: "Africa"},
+	{Prefix: "256", CountryCode: "256", ISO: "UG", Country: "Uganda", Region: "Africa"},
+	{Prefix: "257", CountryCode: "257", ISO: "BI", Country: "Burundi", Region: "Africa"},
+	{Prefix: "258", CountryCode: "258", ISO: "MZ", Country: "Mozambique", Region: "Africa"},
+	{Prefix: "260", CountryCode: "260", ISO: "ZM", Country: "Zambia", Region: "Africa"},
+	{Prefix: "261", CountryCode: "261", ISO: "MG", Country: "Madagascar", Region: "Africa"},
+	{Prefix: "262", CountryCode: "262", ISO: "RE", Country: "Reunion", Region: "Africa"},
+	{Prefix: "262269", CountryCode: "262", ISO: "YT", Country: "Mayotte", Region: "Africa"},
+	{Prefix: "262639", CountryCode: "262", ISO: "YT", Country: "Mayotte", Region: "Africa"},
+	{Prefix: "263", CountryCode: "263", ISO: "ZW", Country: "Zimbabwe", Region: "Africa"},
+	{Prefix: "264", CountryCode: "264", ISO: "NA", Country: "Namibia", Region: "Africa"},
+	{Prefix: "265", CountryCode: "265", ISO: "MW", Country: "Malawi", Region: "Africa"},
+	{Prefix: "266", CountryCode: "266", ISO: "LS", Country: "Lesotho", Region: "Africa"},
+	{Prefix: "267", CountryCode: "267", ISO: "BW", Country: "Botswana", Region: "Africa"},
+	{Prefix: "268", CountryCode: "268", ISO: "SZ", Country: "Eswatini", Region: "Africa"},
+	{Prefix: "269", CountryCode: "269", ISO: "KM", Country: "Comoros", Region: "Africa"},
+	{Prefix: "27", CountryCode: "27", ISO: "ZA", Country: "South Africa", Region: "Africa"},
+	{Prefix: "290", CountryCode: "290", ISO: "SH", Country: "Saint Helena", Region: "Africa"},
+	{Prefix: "291", CountryCode: "291", ISO: "ER", Country: "Eritrea", Region: "Africa"},
+	{Prefix: "297", CountryCode: "297", ISO: "AW", Country: "Aruba", Region: "Caribbean"},
+	{Prefix: "298", CountryCode: "298", ISO: "FO", Country: "Faroe Islands", Region: "Europe"},
+	{Prefix: "299", CountryCode: "299", ISO: "GL", Country: "Greenland", Region: "North America"},
+	{Prefix: "30", CountryCode: "30", ISO: "GR", Country: "Greece", Region: "Europe"},
+	{Prefix: "31", CountryCode: "31", ISO: "NL", Country: "Netherlands", Region: "Europe"},
+	{Prefix: "32", CountryCode: "32", ISO: "BE", Country: "Belgium", Region: "Europe"},
+	{Prefix: "33", CountryCode: "33", ISO: "FR", Country: "France", Region: "Europe"},
+	{Prefix: "34", CountryCode: "34", ISO: "ES", Country: "Spain", Region: "Europe"},
+	{Prefix: "350", CountryCode: "350", ISO: "GI", Country: "Gibraltar", Region: "Europe"},
+	{Prefix: "351", CountryCode: "351", ISO: "PT", Country: "Portugal", Region: "Europe"},
+	{Prefix: "352", CountryCode: "352", ISO: "LU", Country: "Luxembourg", Region: "Europe"},
+	{Prefix: "353", CountryCode: "353", ISO: "IE", Country: "Ireland", Region: "Europe"},
+	{Prefix: "354", CountryCode: "354", ISO: "IS", Country: "Iceland", Region: "Europe"},
+	{Prefix: "355", CountryCode: "355", ISO: "AL", Country: "Albania", Region: "Europe"},
+	{Prefix: "356", CountryCode: "356", ISO: "MT", Country: "Malta", Region: "Europe"},
+	{Prefix: "357", CountryCode: "357", ISO: "CY", Country: "Cyprus", Region: "Europe"},
+	{Prefix: "358", CountryCode: "358", ISO: "FI", Country: "Finland", Region: "Europe"},
+	{Prefix: "35818", CountryCode: "358", ISO: "AX", Country: "Aland Islands", Region: "Europe"},
+	{Prefix: "359", CountryCode: "359", ISO: "BG", Country: "Bulgaria", Region: "Europe"},
+	{Prefix: "36", CountryCode: "36", ISO: "HU", Country: "Hungary", Region: "Europe"},
+	{Prefix: "370", CountryCode: "370", ISO: "LT", Country: "Lithuania", Region: "Europe"},
+	{Prefix: "371", CountryCode: "371", ISO: "LV", Country: "Latvia", Region: "Europe"},
+	{Prefix: "372", CountryCode: "372", ISO: "EE", Country: "Estonia", Region: "Europe"},
+	{Prefix: "373", CountryCode: "373", ISO: "MD", Country: "Moldova", Region: "Europe"},
+	{Prefix: "374", CountryCode: "374", ISO: "AM", Country: "Armenia", Region: "Asia"},
+	{Prefix: "375", CountryCode: "375", ISO: "BY", Country: "Belarus", Region: "Europe"},
+	{Prefix: "376", CountryCode: "376", ISO: "AD", Country: "Andorra", Region: "Europe"},
+	{Prefix: "377", CountryCode: "377", ISO: "MC", Country: "Monaco", Region: "Europe"},
+	{Prefix: "378", CountryCode: "378", ISO: "SM", Country: "San Marino", Region: "Europe"},
+	{Prefix: "379", CountryCode: "379", ISO: "VA", Country: "Vatican City", Region: "Europe"},
+	{Prefix: "380", CountryCode: "380", ISO: "UA", Country: "Ukraine", Region: "Europe"},
+	{Prefix: "381", CountryCode: "381", ISO: "RS", Country: "Serbia", Region: "Europe"},
+	{Prefix: "382", CountryCode: "382", ISO: "ME", Country: "Montenegro", Region: "Europe"},
+	{Prefix: "383", CountryCode: "383", ISO: "XK", Country: "Kosovo", Region: "Europe"},
+	{Prefix: "385", CountryCode: "385", ISO: "HR", Country: "Croatia", Region: "Europe"},
+	{Prefix: "386", CountryCode: "386", ISO: "SI", Country: "Slovenia", Region: "Europe"},
+	{Prefix: "387", CountryCode: "387", ISO: "BA", Country: "Bosnia and Herzegovina", Region: "Europe"},
+	{Prefix: "389", CountryCode: "389", ISO: "MK", Country: "North Macedonia", Region: "Europe"},
+	{Prefix: "39", CountryCode: "39", ISO: "IT", Country: "Italy", Region: "Europe"},
+	{Prefix: "39066", CountryCode: "39", ISO: "VA", Country: "Vatican City", Region: "Europe"},
+	{Prefix: "3906698", CountryCode: "39", ISO: "VA", Country: "Vatican City", Region: "Europe"},
+	{Prefix: "40", CountryCode: "40", ISO: "RO", Country: "Romania", Region: "Europe"},
+	{Prefix: "41", CountryCode: "41", ISO: "CH", Country: "Switzerland", Region: "Europe"},
+	{Prefix: "420", CountryCode: "420", ISO: "CZ", Country: "Czech Republic", Region: "Europe"},
+	{Prefix: "421", CountryCode: "421", ISO: "SK", Country: "Slovakia", Region: "Europe"},
+	{Prefix: "423", CountryCode: "423", ISO: "LI", Country: "Liechtenstein", Region: "Europe"},
+	{Prefix: "43", CountryCode: "43", ISO: "AT", Country: "Austria", Region: "Europe"},
+	{Prefix: "44", CountryCode: "44", ISO: "GB", Country: "United Kingdom", Region: "Europe"},
+	{Prefix: "441481", CountryCode: "44", ISO: "GG", Country: "Guernsey", Region: "Europe"},
+	{Prefix: "441534", CountryCode: "44", ISO: "JE", Country: "Jersey", Region: "Europe"},
+	{Prefix: "441624", CountryCode: "44", ISO: "IM", Country: "Isle of Man", Region: "Europe"},
+	{Prefix: "45", CountryCode: "45", ISO: "DK", Country: "Denmark", Region: "Europe"},
+	{Prefix: "46", CountryCode: "46", ISO: "SE", Country: "Sweden", Region: "Europe"},
+	{Prefix: "47", CountryCode: "47", ISO: "NO", Country: "Norway", Region: "Europe"},
+	{Prefix: "4779", CountryCode: "47", ISO: "SJ", Country: "Svalbard and Jan Mayen", Region: "Europe"},
+	{Prefix: "48", CountryCode: "48", ISO: "PL", Country: "Poland", Region: "Europe"},
+	{Prefix: "49", CountryCode: "49", ISO: "DE", Country: "Germany", Region: "Europe"},
+	{Prefix: "500", CountryCode: "500", ISO: "FK", Country: "Falkland Islands", Region: "South America"},
+	{Prefix: "501", CountryCode: "501", ISO: "BZ", Country: "Belize", Region: "Central America"},
+	{Prefix: "502", CountryCode: "502", ISO: "GT", Country: "Guatemala", Region: "Central America"},
+	{Prefix: "503", CountryCode: "503", ISO: "SV", Country: "El Salvador", Region: "Central America"},
+	{Prefix: "504", CountryCode: "504", ISO: "HN", Country: "Honduras", Region: "Central America"},
+	{Prefix: "505", CountryCode: "505", ISO: "NI", Country: "Nicaragua", Region: "Central America"},
+	{Prefix: "506", CountryCode: "506", ISO: "CR", Country: "Costa Rica", Region: "Central America"},
+	{Prefix: "507", CountryCode: "507", ISO: "PA", Country: "Panama", Region: "Central America"},
+	{Prefix: "508", CountryCode: "508", ISO: "PM", Country: "Saint Pierre and Miquelon", Region: "North America"},
+	{Prefix: "509", CountryCode: "509", ISO: "HT", Country: "Haiti", Region: "Caribbean"},
+	{Prefix: "51", CountryCode: "51", ISO: "PE", Country: "Peru", Region: "South America"},
+	{Prefix: "52", CountryCode: "52", ISO: "MX", Country: "Mexico", Region: "Central America"},
+	{Prefix: "53", CountryCode: "53", ISO: "CU", Country: "Cuba", Region: "Caribbean"},
+	{Prefix: "54", CountryCode: "54", ISO: "AR", Country: "Argentina", Region: "South America"},
+	{Prefix: "55", CountryCode: "55", ISO: "BR", Country: "Brazil", Region: "South America"},
+	{Prefix: "56", CountryCode: "56", ISO: "CL", Country: "Chile", Region: "South America"},
+	{Prefix: "57", CountryCode: "57", ISO: "CO", Country: "Colombia", Region: "South America"},
+	{Prefix: "58", CountryCode: "58", ISO: "VE", Country: "Venezuela", Region: "South America"},
+	{Prefix: "590", CountryCode: "590", ISO: "GP", Country: "Guadeloupe", Region: "Caribbean"},
+	{Prefix: "591", CountryCode: "591", ISO: "BO", Country: "Bolivia", Region: "South America"},
+	{Prefix: "592", CountryCode: "592", ISO: "GY", Country: "Guyana", Region: "South America"},
+	{Prefix: "593", CountryCode: "593", ISO: "EC", Country: "Ecuador", Region: "South America"},
+	{Prefix: "594", CountryCode: "594", ISO: "GF", Country: "French Guiana", Region: "South America"},
+	{Prefix: "595", CountryCode: "595", ISO: "PY", Country: "Paraguay", Region: "South America"},
+	{Prefix: "596", CountryCode: "596", ISO: "MQ", Country: "Martinique", Region: "Caribbean"},
+	{Prefix: "597", CountryCode: "597", ISO: "SR", Country: "Suriname", Region: "South America"},
+	{Prefix: "598", CountryCode: "598", ISO: "UY", Country: "Uruguay", Region: "South America"},
+	{Prefix: "599", CountryCode: "599", ISO: "CW", Country: "Curacao", Region: "Caribbean"},
+	{Prefix: "5993", CountryCode: "599", ISO: "BQ", Country: "Sint Eustatius", Region: "Caribbean"},
+	{Prefix: "5994", CountryCode: "599", ISO: "BQ", Country: "Saba", Region: "Caribbean"},
+	{Prefix: "5997", CountryCode: "599", ISO: "BQ", Country: "Bonaire", Region: "Caribbean"},
+	{Prefix: "5999", CountryCode: "599", ISO: "CW", Country: "Curacao", Region: "Caribbean"},
+	{Prefix: "60", CountryCode: "60", ISO: "MY", Country: "Malaysia", Region: "Asia"},
+	{Prefix: "61", CountryCode: "61", ISO: "AU", Country: "Australia", Region: "Oceania"},
+	{Prefix: "6189162", CountryCode: "61", ISO: "CC", Country: "Cocos (Keeling) Islands", Region: "Oceania"},
+	{Prefix: "6189164", CountryCode: "61", ISO: "CX", Country: "Christmas Island", Region: "Oceania"},
+	{Prefix: "62", CountryCode: "62", ISO: "ID", Country: "Indonesia", Region: "Asia"},
+	{Prefix: "63", CountryCode: "63", ISO: "PH", Country: "Philippines", Region: "Asia"},
+	{Prefix: "64", CountryCode: "64", ISO: "NZ", Country: "New Zealand", Region: "Oceania"},
+	{Prefix: "65", CountryCode: "65", ISO: "SG", Country: "Singapore", Region: "Asia"},
+	{Prefix: "66", CountryCode: "66", ISO: "TH", Country: "Thailand", Region: "Asia"},
+	{Prefix: "670", CountryCode: "670", ISO: "TL", Country: "East Timor", Region: "Asia"},
+	{Prefix: "672", CountryCode: "672", ISO: "NF", Country: "Norfolk Island", Region: "Oceania"},
+	{Prefix: "6721", CountryCode: "672", ISO: "AQ", Country: "Antarctica", Region: "Antarctica"},
+	{Prefix: "6723", CountryCode: "672", ISO: "NF", Country: "Norfolk Island", Region: "Oceania"},
+	{Prefix: "673", CountryCode: "673", ISO: "BN", Country: "Brunei", Region: "Asia"},
+	{Prefix: "674", CountryCode: "674", ISO: "NR", Country: "Nauru", Region: "Oceania"},
+	{Prefix: "675", CountryCode: "675", ISO: "PG", Country: "Papua New Guinea", Region: "Oceania"},
+	{Prefix: "676", CountryCode: "676", ISO: "TO", Country: "Tonga", Region: "Oceania"},
+	{Prefix: "677", CountryCode: "677", ISO: "SB", Country: "Solomon Islands", Region: "Oceania"},
+	{Prefix: "678", CountryCode: "678", ISO: "VU", Country: "Vanuatu", Region: "Oceania"},
+	{Prefix: "679", CountryCode: "679", ISO: "FJ", Country: "Fiji", Region: "Oceania"},
+	{Prefix: "680", CountryCode: "680", ISO: "PW", Country: "Palau", Region: "Oceania"},
+	{Prefix: "681", CountryCode: "681", ISO: "WF", Country: "Wallis and Futuna", Region: "Oceania"},
+	{Prefix: "682", CountryCode: "682", ISO: "CK", Country: "Cook Islands", Region: "Oceania"},
+	{Prefix: "683", CountryCode: "683", ISO: "NU", Country: "Niue", Region: "Oceania"},
+	{Prefix: "685", CountryCode: "685", ISO: "WS", Country: "Samoa", Region: "Oceania"},
+	{Prefix: "686", CountryCode: "686", ISO: "KI", Country: "Kiribati", Region: "Oceania"},
+	{Prefix: "687", CountryCode: "687", ISO: "NC", Country: "New Caledonia", Region: "Oceania"},
+	{Prefix: "688", CountryCode: "688", ISO: "TV", Country: "Tuvalu", Region: "Oceania"},
+	{Prefix: "689", CountryCode: "689", ISO: "PF", Country: "French Polynesia", Region: "Oceania"},
+	{Prefix: "690", CountryCode: "690", ISO: "TK", Country: "Tokelau", Region: "Oceania"},
+	{Prefix: "691", CountryCode: "691", ISO: "FM", Country: "Micronesia", Region: "Oceania"},
+	{Prefix: "692", CountryCode: "692", ISO: "MH", Country: "Marshall Islands", Region: "Oceania"},
+	{Prefix: "7", CountryCode: "7", ISO: "RU", Country: "Russia", Region: "Europe"},
+	{Prefix: "76", CountryCode: "7", ISO: "KZ", Country: "Kazakhstan", Region: "Asia"},
+	{Prefix: "77", CountryCode: "7", ISO: "KZ", Country: "Kazakhstan", Region: "Asia"},
+	{Prefix: "800", CountryCode: "800", ISO: "", Country: "International Freephone Service", Region: "Non-geographic"},
+	{Prefix: "808", CountryCode: "808", ISO: "", Country: "International Shared Cost Service", Region: "Non-geographic"},
+	{Prefix: "81", CountryCode: "81", ISO: "JP", Country: "Japan", Region: "Asia"},
+	{Prefix: "82", CountryCode: "82", ISO: "KR", Country: "South Korea", Region: "Asia"},
+	{Prefix: "84", CountryCode: "84", ISO: "VN", Country: "Vietnam", Region: "Asia"},
+	{Prefix: "850", CountryCode: "850", ISO: "KP", Country: "North Korea", Region: "Asia"},
+	{Prefix: "852", CountryCode: "852", ISO: "HK", Country: "Hong Kong", Region: "Asia"},
+	{Prefix: "853", CountryCode: "853", ISO: "MO", Country: "Macau", Region: "Asia"},
+	{Prefix: "855", CountryCode: "855", ISO: "KH", Country: "Cambodia", Region: "Asia"},
+	{Prefix: "856", CountryCode: "856", ISO: "LA", Country: "Laos", Region: "Asia"},
+	{Prefix: "86", CountryCode: "86", ISO: "CN", Country: "China", Region: "Asia"},
+	{Prefix: "870", CountryCode: "870", ISO: "", Country: "Inmarsat", Region: "Non-geographic"},
+	{Prefix: "878", CountryCode: "878", ISO: "", Country: "Universal Personal Telecommunications", Region: "Non-geographic"},
+	{Prefix: "880", CountryCode: "880", ISO: "BD", Country: "Bangladesh", Region: "Asia"},
+	{Prefix: "881", CountryCode: "881", ISO: "", Country: "Global Mobile Satellite System", Region: "Non-geographic"},
+	{Prefix: "8816", CountryCode: "881", ISO: "", Country: "Iridium", Region: "Non-geographic"},
+	{Prefix: "8817", CountryCode: "881", ISO: "", Country: "Iridium", Region: "Non-geographic"},
+	{Prefix: "8818", CountryCode: "881", ISO: "", Country: "Globalstar", Region: "Non-geographic"},
+	{Prefix: "8819", CountryCode: "881", ISO: "", Country: "Globalstar", Region: "Non-geographic"},
+	{Prefix: "882", CountryCode: "882", ISO: "", Country: "International Networks", Region: "Non-geographic"},
+	{Prefix: "883", CountryCode: "883", ISO: "", Country: "International Networks", Region: "Non-geographic"},
+	{Prefix: "886", CountryCode: "886", ISO: "TW", Country: "Taiwan", Region: "Asia"},
+	{Prefix: "888", CountryCode: "888", ISO: "", Country: "Telecommunications for Disaster Relief", Region: "Non-geographic"},
+	{Prefix: "90", CountryCode: "90", ISO: "TR", Country: "Turkey", Region: "Europe"},
+	{Prefix: "91", CountryCode: "91", ISO: "IN", Country: "India", Region: "Asia"},
+	{Prefix: "92", CountryCode: "92", ISO: "PK", Country: "Pakistan", Region: "Asia"},
+	{Prefix: "93", CountryCode: "93", ISO: "AF", Country: "Afghanistan", Region: "Asia"},
+	{Prefix: "94", CountryCode: "94", ISO: "LK", Country: "Sri Lanka", Region: "Asia"},
+	{Prefix: "95", CountryCode: "95", ISO: "MM", Country: "Myanmar", Region: "Asia"},
+	{Prefix: "960", CountryCode: "960", ISO: "MV", Country: "Maldives", Region: "Asia"},
+	{Prefix: "961", CountryCode: "961", ISO: "LB", Country: "Lebanon", Region: "Middle East"},
+	{Prefix: "962", CountryCode: "962", ISO: "JO", Country: "Jordan", Region: "Middle East"},
+	{Prefix: "963", CountryCode: "963", ISO: "SY", Country: "Syria", Region: "Middle East"},
+	{Prefix: "964", CountryCode: "964", ISO: "IQ", Country: "Iraq", Region: "Middle East"},
+	{Prefix: "965", CountryCode: "965", ISO: "KW", Country: "Kuwait", Region: "Middle East"},
+	{Prefix: "966", CountryCode: "966", ISO: "SA", Country: "Saudi Arabia", Region: "Middle East"},
+	{Prefix: "967", CountryCode: "967", ISO: "YE", Country: "Yemen", Region: "Middle East"},
+	{Prefix: "968", CountryCode: "968", ISO: "OM", Country: "Oman", Region: "Middle East"},
+	{Prefix: "970", CountryCode: "970", ISO: "PS", Country: "Palestine", Region: "Middle East"},
+	{Prefix: "971", CountryCode: "971", ISO: "AE", Country: "United Arab Emirates", Region: "Middle East"},
+	{Prefix: "972", CountryCode: "972", ISO: "IL", Country: "Israel", Region: "Middle East"},
+	{Prefix: "973", CountryCode: "973", ISO: "BH", Country: "Bahrain", Region: "Middle East"},
+	{Prefix: "974", CountryCode: "974", ISO: "QA", Country: "Qatar", Region: "Middle East"},
+	{Prefix: "975", CountryCode: "975", ISO: "BT", Country: "Bhutan", Region: "Asia"},
+	{Prefix: "976", CountryCode: "976", ISO: "MN", Country: "Mongolia", Region: "Asia"},
+	{Prefix: "977", CountryCode: "977", ISO: "NP", Country: "Nepal", Region: "Asia"},
+	{Prefix: "979", CountryCode: "979", ISO: "", Country: "International Premium Rate Service", Region: "Non-geographic"},
+	{Prefix: "98", CountryCode: "98", ISO: "IR", Country: "Iran", Region: "Middle East"},
+	{Prefix: "991", CountryCode: "991", ISO: "", Country: "International Telecommunications Public Correspondence Service", Region: "Non-geographic"},
+	{Prefix: "992", CountryCode: "992", ISO: "TJ", Country: "Tajikistan", Region: "Asia"},
+	{Prefix: "993", CountryCode: "993", ISO: "TM", Country: "Turkmenistan", Region: "Asia"},
+	{Prefix: "994", CountryCode: "994", ISO: "AZ", Country: "Azerbaijan", Region: "Asia"},
+	{Prefix: "995", CountryCode: "995", ISO: "GE", Country: "Georgia", Region: "Asia"},
+	{Prefix: "996", CountryCode: "996", ISO: "KG", Country: "Kyrgyzstan", Region: "Asia"},
+	{Prefix: "998", CountryCode: "998", ISO: "UZ", Country: "Uzbekistan", Region: "Asia"},
+}
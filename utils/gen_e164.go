@@ -0,0 +1,70 @@
+//go:build ignore
+// +build ignore
+
+// NOTE: Generates e164table.go from e164.csv, with "go generate" in this directory
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"os"
+	"strconv"
+
+	"encoding/csv"
+)
+
+func main() {
+
+	file, err := os.Open("e164.csv")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var buffer bytes.Buffer
+
+	fmt.Fprintln(&buffer, "// Code generated by gen_e164.go from e164.csv; DO NOT EDIT.")
+	fmt.Fprintln(&buffer)
+	fmt.Fprintln(&buffer, "package utils")
+	fmt.Fprintln(&buffer)
+	fmt.Fprintln(&buffer, "var e164Ranges = []E164Range{")
+
+	prefixes := make(map[string]bool)
+
+	// NOTE: The first line has the column names
+	for index, record := range records[1:] {
+
+		if len(record) != 5 {
+			log.Fatalf("line %d of e164.csv has %d columns, it should have 5", index+2, len(record))
+		}
+
+		prefix := record[0]
+		if prefixes[prefix] {
+			log.Fatalf("prefix %q is in e164.csv more than once", prefix)
+		}
+		prefixes[prefix] = true
+
+		fmt.Fprintf(&buffer, "{Prefix: %s, CountryCode: %s, ISO: %s, Country: %s, Region: %s},\n", strconv.Quote(prefix), strconv.Quote(record[1]), strconv.Quote(record[2]), strconv.Quote(record[3]), strconv.Quote(record[4]))
+
+	}
+
+	fmt.Fprintln(&buffer, "}")
+
+	source, err := format.Source(buffer.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile("e164table.go", source, 0644); err != nil {
+		log.Fatal(err)
+	}
+
+}
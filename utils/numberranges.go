@@ -0,0 +1,319 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"encoding/csv"
+
+	"github.com/andmar/marlog"
+)
+
+const (
+	// RiskLow ...
+	RiskLow = iota + 1
+	// RiskMedium ...
+	RiskMedium
+	// RiskHigh ...
+	RiskHigh
+)
+
+// ParseRisk Returns the risk level of "value" ("low", "medium" or "high"), an empty one is RiskHigh since being in the list at all is
+// what matters
+func ParseRisk(value string) (int, error) {
+
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "low":
+		return RiskLow, nil
+	case "medium":
+		return RiskMedium, nil
+	case "high", "":
+		return RiskHigh, nil
+	}
+
+	return 0, fmt.Errorf("unknown risk level \"" + value + "\"")
+
+}
+
+// RiskString ...
+func RiskString(risk int) string {
+
+	switch risk {
+	case RiskLow:
+		return "low"
+	case RiskMedium:
+		return "medium"
+	}
+
+	return "high"
+
+}
+
+// NumberRange A range of E.164 numbers known to be used for fraud (e.g. IRSF, premium rate numbers), all the numbers starting with Prefix
+type NumberRange struct {
+	Prefix   string
+	Label    string
+	Risk     int
+	FilePath string
+}
+
+// NumberRanges A database of NumberRanges loaded from CSV files, one range per line as "range,label,risk" where "range" is a prefix
+// (e.g. "37259") or the first and last numbers of the range, with the same number of digits, separated by "-" (e.g.
+// "3725900000-3725949999"), and "risk" is one of "low", "medium" or "high" (the default). Empty lines, lines starting with "#" and a first
+// line that does not start with a digit (a header) are skipped, numbers can start with "+". Load can be called again at any time, while
+// it's loading lookups get the previous ranges and if any of the files can't be loaded the previous ranges are kept
+type NumberRanges struct {
+	FilePaths []string
+	mutex     sync.RWMutex
+	root      *digitTrie
+	count     int
+	modTimes  map[string]time.Time
+}
+
+// NewNumberRanges ...
+func NewNumberRanges(filePaths []string) *NumberRanges {
+
+	ranges := new(NumberRanges)
+	ranges.FilePaths = filePaths
+	ranges.root = new(digitTrie)
+
+	return ranges
+
+}
+
+// Load (Re)Loads the ranges from all of FilePaths
+func (ranges *NumberRanges) Load() error {
+
+	log := marlog.MarLog
+
+	root := new(digitTrie)
+	count := 0
+	modTimes := make(map[string]time.Time)
+
+	for _, filePath := range ranges.FilePaths {
+
+		file, err := os.Open(filePath)
+		if err != nil {
+			return fmt.Errorf("could not open number ranges file \"" + filePath + "\" (" + err.Error() + ")")
+		}
+
+		if info, err := file.Stat(); err == nil {
+			modTimes[filePath] = info.ModTime()
+		}
+
+		fileCount, err := loadNumberRanges(root, file, filePath)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("could not load number ranges file \"" + filePath + "\" (" + err.Error() + ")")
+		}
+
+		count += fileCount
+
+	}
+
+	ranges.mutex.Lock()
+	ranges.root = root
+	ranges.count = count
+	ranges.modTimes = modTimes
+	ranges.mutex.Unlock()
+
+	log.LogS("INFO", "Loaded "+strconv.Itoa(count)+" number ranges from "+strconv.Itoa(len(ranges.FilePaths))+" files")
+
+	return nil
+
+}
+
+// loadNumberRanges Adds the ranges in "reader" to the trie in "root" and returns how many it added
+func loadNumberRanges(root *digitTrie, reader io.Reader, filePath string) (int, error) {
+
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.Comment = '#'
+	csvReader.TrimLeadingSpace = true
+
+	count := 0
+
+	for recordNumber := 1; ; recordNumber++ {
+
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		value := strings.Replace(strings.TrimSpace(record[0]), "+", "", -1)
+		if value == "" {
+			continue
+		}
+
+		if recordNumber == 1 && (value[0] < '0' || value[0] > '9') {
+			continue
+		}
+
+		label := ""
+		if len(record) > 1 {
+			label = strings.TrimSpace(record[1])
+		}
+
+		risk := RiskHigh
+		if len(record) > 2 {
+			if risk, err = ParseRisk(record[2]); err != nil {
+				return 0, fmt.Errorf("record " + strconv.Itoa(recordNumber) + ": " + err.Error())
+			}
+		}
+
+		prefixes, err := numberRangePrefixes(value)
+		if err != nil {
+			return 0, fmt.Errorf("record " + strconv.Itoa(recordNumber) + ": " + err.Error())
+		}
+
+		for _, prefix := range prefixes {
+			root.insert(prefix, &NumberRange{Prefix: prefix, Label: label, Risk: risk, FilePath: filePath})
+			count++
+		}
+
+	}
+
+	return count, nil
+
+}
+
+// numberRangePrefixes Returns the prefixes that cover the range in "value", a prefix or "first-last"
+func numberRangePrefixes(value string) ([]string, error) {
+
+	first, last := value, value
+	if index := strings.Index(value, "-"); index != -1 {
+		first, last = strings.TrimSpace(value[:index]), strings.TrimSpace(value[index+1:])
+	}
+
+	for _, number := range []string{first, last} {
+		if number == "" || strings.Trim(number, "0123456789") != "" {
+			return nil, fmt.Errorf("\"" + value + "\" is not a prefix nor a range of numbers")
+		}
+	}
+
+	if len(first) != len(last) || first > last {
+		return nil, fmt.Errorf("\"" + value + "\" is not a range of numbers with the same number of digits")
+	}
+
+	return coveringPrefixes(first, last), nil
+
+}
+
+// coveringPrefixes Returns the fewest prefixes of the numbers from "first" to "last", which have the same number of digits (e.g.
+// "1200"-"1359" is "12", "130", "131", "132", "133", "134" and "135")
+func coveringPrefixes(first string, last string) []string {
+
+	common := 0
+	for common < len(first) && first[common] == last[common] {
+		common++
+	}
+
+	if common == len(first) {
+		return []string{first}
+	}
+
+	prefix := first[:common]
+	firstRest, lastRest := first[common+1:], last[common+1:]
+
+	if strings.Trim(firstRest, "0") == "" && strings.Trim(lastRest, "9") == "" && first[common] == '0' && last[common] == '9' {
+		return []string{prefix}
+	}
+
+	var prefixes []string
+
+	from, to := first[common], last[common]
+
+	if strings.Trim(firstRest, "0") != "" {
+		prefixes = append(prefixes, coveringPrefixes(first, prefix+string(from)+strings.Repeat("9", len(firstRest)))...)
+		from++
+	}
+
+	lastIsPartial := strings.Trim(lastRest, "9") != ""
+	if lastIsPartial {
+		to--
+	}
+
+	for digit := from; digit <= to && digit <= '9'; digit++ {
+		prefixes = append(prefixes, prefix+string(digit))
+	}
+
+	if lastIsPartial {
+		prefixes = append(prefixes, coveringPrefixes(prefix+string(last[common])+strings.Repeat("0", len(lastRest)), last)...)
+	}
+
+	return prefixes
+
+}
+
+// Lookup Returns the most specific NumberRange "number" is in, "number" has to be an E.164 number, it can start with "+" or "00"
+func (ranges *NumberRanges) Lookup(number string) (*NumberRange, bool) {
+
+	ranges.mutex.RLock()
+	defer ranges.mutex.RUnlock()
+
+	found, ok := ranges.root.longestMatch(stripInternationalPrefix(number)).(*NumberRange)
+
+	return found, ok
+
+}
+
+// Count Returns how many prefixes were loaded
+func (ranges *NumberRanges) Count() int {
+
+	ranges.mutex.RLock()
+	defer ranges.mutex.RUnlock()
+
+	return ranges.count
+
+}
+
+// Watch Checks every "interval" if any of the files changed since they were loaded and, if so, loads them again
+func (ranges *NumberRanges) Watch(interval time.Duration) {
+
+	log := marlog.MarLog
+
+	for {
+
+		time.Sleep(interval)
+
+		if ranges.changed() == false {
+			continue
+		}
+
+		log.LogS("INFO", "Number ranges files changed, loading them again...")
+
+		if err := ranges.Load(); err != nil {
+			log.LogS("ERROR", "Could not load the number ranges again, keeping the ones that were loaded ("+err.Error()+")")
+		}
+
+	}
+
+}
+
+func (ranges *NumberRanges) changed() bool {
+
+	ranges.mutex.RLock()
+	defer ranges.mutex.RUnlock()
+
+	for _, filePath := range ranges.FilePaths {
+		info, err := os.Stat(filePath)
+		if err != nil {
+			// NOTE: Being replaced, it's going to be there on the next check
+			continue
+		}
+		if modTime, found := ranges.modTimes[filePath]; !found || !modTime.Equal(info.ModTime()) {
+			return true
+		}
+	}
+
+	return false
+
+}
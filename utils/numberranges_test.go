@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"io/ioutil"
+)
+
+func TestCoveringPrefixes(t *testing.T) {
+
+	tests := []struct {
+		first    string
+		last     string
+		expected []string
+	}{
+		// NOTE: Aligned with powers of ten, a single prefix
+		{"1000", "1999", []string{"1"}},
+		{"3725900000", "3725999999", []string{"37259"}},
+		{"0000", "9999", []string{""}},
+		{"1234", "1234", []string{"1234"}},
+		// NOTE: Aligned with powers of ten, a prefix per digit
+		{"3725900000", "3725949999", []string{"372590", "372591", "372592", "372593", "372594"}},
+		// NOTE: Only the first or the last isn't aligned
+		{"1200", "1359", []string{"12", "130", "131", "132", "133", "134", "135"}},
+		{"1250", "1399", []string{"125", "126", "127", "128", "129", "13"}},
+		// NOTE: Neither is aligned
+		{"1205", "1213", []string{"1205", "1206", "1207", "1208", "1209", "1210", "1211", "1212", "1213"}},
+		{"1295", "1304", []string{"1295", "1296", "1297", "1298", "1299", "1300", "1301", "1302", "1303", "1304"}},
+	}
+
+	for _, test := range tests {
+		if prefixes := coveringPrefixes(test.first, test.last); !reflect.DeepEqual(prefixes, test.expected) {
+			t.Errorf("%s-%s: expected %v, got %v", test.first, test.last, test.expected, prefixes)
+		}
+	}
+
+}
+
+func TestNumberRangePrefixes(t *testing.T) {
+
+	if prefixes, err := numberRangePrefixes("37259"); err != nil || !reflect.DeepEqual(prefixes, []string{"37259"}) {
+		t.Errorf("expected a prefix to be itself, got %v (%v)", prefixes, err)
+	}
+
+	for _, value := range []string{"", "12a4", "1200-", "1200-135", "1359-1200"} {
+		if _, err := numberRangePrefixes(value); err == nil {
+			t.Errorf("%s: expected an error", value)
+		}
+	}
+
+}
+
+func TestNumberRangesReload(t *testing.T) {
+
+	file, err := ioutil.TempFile("", "fraudion")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove(file.Name())
+
+	file.WriteString("range,label,risk\n# IRSF\n+37259,Estonia,high\n881,Satellite,low\n2392200-2392299,Sao Tome premium,medium\n")
+	file.Close()
+
+	ranges := NewNumberRanges([]string{file.Name()})
+	if err := ranges.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		number string
+		prefix string
+		risk   int
+	}{
+		{"00372591234", "37259", RiskHigh},
+		{"+8816123456", "881", RiskLow},
+		{"2392250000", "23922", RiskMedium},
+		{"2392300000", "", 0},
+	}
+
+	for _, test := range tests {
+		numberRange, found := ranges.Lookup(test.number)
+		if found != (test.prefix != "") || (found && (numberRange.Prefix != test.prefix || numberRange.Risk != test.risk)) {
+			t.Errorf("%s: expected the range \"%s\" (%s), got %+v", test.number, test.prefix, RiskString(test.risk), numberRange)
+		}
+	}
+
+	if ranges.changed() {
+		t.Fatal("expected the file not to have changed")
+	}
+
+	// NOTE: A file that can't be loaded keeps the ranges that were loaded
+	if err := ioutil.WriteFile(file.Name(), []byte("2,Africa\n12a4,Typo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Hour)
+	os.Chtimes(file.Name(), later, later)
+
+	if !ranges.changed() {
+		t.Fatal("expected the file to have changed")
+	}
+
+	if err := ranges.Load(); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if numberRange, found := ranges.Lookup("00372591234"); !found || numberRange.Prefix != "37259" || ranges.Count() != 3 {
+		t.Fatalf("expected the previous ranges to be kept, got %+v and %d ranges", numberRange, ranges.Count())
+	}
+
+	// NOTE: Once it can be loaded, the new ranges replace the previous ones
+	if err := ioutil.WriteFile(file.Name(), []byte("2,Africa\n23922,Sao Tome,low\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ranges.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, found := ranges.Lookup("00372591234"); found || ranges.Count() != 2 {
+		t.Fatalf("expected the previous ranges to be gone, got %d ranges", ranges.Count())
+	}
+
+	if numberRange, found := ranges.Lookup("2392250000"); !found || numberRange.Prefix != "23922" || numberRange.Risk != RiskLow {
+		t.Errorf("expected the reloaded range, got %+v", numberRange)
+	}
+
+	if numberRange, found := ranges.Lookup("2441234"); !found || numberRange.Prefix != "2" || numberRange.Risk != RiskHigh {
+		t.Errorf("expected the shorter reloaded range, got %+v", numberRange)
+	}
+
+}
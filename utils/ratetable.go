@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"encoding/csv"
+)
+
+// Rate What calls to the numbers starting with Prefix cost, PerMinute is charged by the second of billed time and ConnectionFee once per
+// answered call
+type Rate struct {
+	Prefix        string
+	PerMinute     float64
+	ConnectionFee float64
+	Currency      string
+}
+
+// Cost Returns what a call that was billed "billSec" seconds costs, calls that were not answered cost nothing
+func (rate *Rate) Cost(billSec uint32) float64 {
+
+	if billSec == 0 {
+		return 0
+	}
+
+	return rate.ConnectionFee + rate.PerMinute*float64(billSec)/60
+
+}
+
+// RateTable Rates by prefix, the one of a number is the one with the longest prefix it starts with
+type RateTable struct {
+	FilePath string
+	Currency string
+	root     digitTrie
+	count    int
+}
+
+// LoadRateTable Loads the rates in the CSV file in "filePath", one per line as "prefix,rate per minute,connection fee,currency" (e.g.
+// "8816,7.50,0.50,EUR"), all in the same currency. Empty lines, lines starting with "#" and a first line that does not start with a digit
+// (a header) are skipped, prefixes can start with "+". A file with no rates in it is an error, every call would cost nothing
+func LoadRateTable(filePath string) (*RateTable, error) {
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("could not open rate table \"" + filePath + "\" (" + err.Error() + ")")
+	}
+
+	defer file.Close()
+
+	table := new(RateTable)
+	table.FilePath = filePath
+
+	if err := table.load(file); err != nil {
+		return nil, fmt.Errorf("could not load rate table \"" + filePath + "\" (" + err.Error() + ")")
+	}
+
+	return table, nil
+
+}
+
+func (table *RateTable) load(reader io.Reader) error {
+
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.Comment = '#'
+	csvReader.TrimLeadingSpace = true
+
+	for recordNumber := 1; ; recordNumber++ {
+
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		prefix := strings.TrimPrefix(strings.TrimSpace(record[0]), "+")
+		if prefix == "" {
+			continue
+		}
+
+		if recordNumber == 1 && (prefix[0] < '0' || prefix[0] > '9') {
+			continue
+		}
+
+		if len(record) < 4 {
+			return fmt.Errorf("record " + strconv.Itoa(recordNumber) + ": expected prefix, rate per minute, connection fee and currency")
+		}
+
+		rate := &Rate{Prefix: prefix, Currency: strings.ToUpper(strings.TrimSpace(record[3]))}
+
+		if rate.PerMinute, err = strconv.ParseFloat(strings.TrimSpace(record[1]), 64); err != nil || rate.PerMinute < 0 {
+			return fmt.Errorf("record " + strconv.Itoa(recordNumber) + ": \"" + record[1] + "\" is not a rate per minute")
+		}
+
+		if rate.ConnectionFee, err = strconv.ParseFloat(strings.TrimSpace(record[2]), 64); err != nil || rate.ConnectionFee < 0 {
+			return fmt.Errorf("record " + strconv.Itoa(recordNumber) + ": \"" + record[2] + "\" is not a connection fee")
+		}
+
+		// NOTE: Costs are added up, they have to be in the same currency
+		if table.Currency == "" {
+			table.Currency = rate.Currency
+		} else if rate.Currency != table.Currency {
+			return fmt.Errorf("record " + strconv.Itoa(recordNumber) + ": rates are in " + table.Currency + ", not in " + rate.Currency)
+		}
+
+		if table.root.insert(prefix, rate) == false {
+			return fmt.Errorf("record " + strconv.Itoa(recordNumber) + ": \"" + record[0] + "\" is not a prefix")
+		}
+
+		table.count++
+
+	}
+
+	if table.count == 0 {
+		return fmt.Errorf("no rates in it")
+	}
+
+	return nil
+
+}
+
+// Lookup Returns the Rate of "number", which can start with "+" or "00"
+func (table *RateTable) Lookup(number string) (*Rate, bool) {
+
+	found, ok := table.root.longestMatch(stripInternationalPrefix(number)).(*Rate)
+
+	return found, ok
+
+}
+
+// Count Returns how many rates were loaded
+func (table *RateTable) Count() int {
+	return table.count
+}
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"io/ioutil"
+)
+
+func TestRateTableLookup(t *testing.T) {
+
+	file, err := ioutil.TempFile("", "fraudion")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove(file.Name())
+
+	file.WriteString("prefix,rate per minute,connection fee,currency\n# Satellite\n+881,6.00,0.50,eur\n351,0.01,0,EUR\n3519,0.06,0,EUR\n35191,0.08,0.01,EUR\n")
+	file.Close()
+
+	table, err := LoadRateTable(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if table.Count() != 4 || table.Currency != "EUR" {
+		t.Fatalf("expected 4 rates in EUR, got %d in \"%s\"", table.Count(), table.Currency)
+	}
+
+	tests := []struct {
+		number string
+		prefix string
+	}{
+		{"351212345678", "351"},
+		{"00351912345678", "35191"},
+		{"+351931234567", "3519"},
+		{"8816123456", "881"},
+		// NOTE: Shorter than the prefix it starts like
+		{"3519", "3519"},
+		{"35", ""},
+		{"244123456789", ""},
+	}
+
+	for _, test := range tests {
+		rate, found := table.Lookup(test.number)
+		if found != (test.prefix != "") || (found && rate.Prefix != test.prefix) {
+			t.Errorf("%s: expected the rate of \"%s\", got %+v", test.number, test.prefix, rate)
+		}
+	}
+
+}
+
+func TestRateCost(t *testing.T) {
+
+	rate := &Rate{Prefix: "881", PerMinute: 6, ConnectionFee: 0.5}
+
+	tests := []struct {
+		billSec  uint32
+		expected float64
+	}{
+		// NOTE: Not answered, not even the connection fee
+		{0, 0},
+		// NOTE: Charged by the second, minutes are not rounded up
+		{1, 0.6},
+		{30, 3.5},
+		{60, 6.5},
+		{61, 6.6},
+		{90, 9.5},
+		{600, 60.5},
+	}
+
+	for _, test := range tests {
+		if cost := rate.Cost(test.billSec); math.Abs(cost-test.expected) > 1e-9 {
+			t.Errorf("%ds: expected %f, got %f", test.billSec, test.expected, cost)
+		}
+	}
+
+}
+
+func TestRateTableLoadErrors(t *testing.T) {
+
+	tests := []string{
+		"prefix,rate per minute,connection fee,currency\n# Nothing yet\n\n",
+		"1,1,0,EUR\n2,1,0,USD\n",
+		"244,1,0\n",
+		"244,one,0,EUR\n",
+		"244,-1,0,EUR\n",
+		"244,1,-0.5,EUR\n",
+		"24a,1,0,EUR\n",
+	}
+
+	for _, test := range tests {
+		if err := new(RateTable).load(strings.NewReader(test)); err == nil {
+			t.Errorf("%q: expected an error", test)
+		}
+	}
+
+	if _, err := LoadRateTable(os.DevNull + "/rates.csv"); err == nil {
+		t.Error("expected an error, the file does not exist")
+	}
+
+}